@@ -15,12 +15,11 @@ func main() {
 	ctl.Name = "neo-go"
 	ctl.Usage = "Official Go client for Neo"
 
-	ctl.Commands = []cli.Command{
-		server.NewCommand(),
+	ctl.Commands = append(server.NewCommands(),
 		smartcontract.NewCommand(),
 		wallet.NewCommand(),
 		vm.NewCommand(),
-	}
+	)
 
 	ctl.Run(os.Args)
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"os/signal"
@@ -15,23 +16,67 @@ import (
 	"github.com/urfave/cli"
 )
 
-// NewCommand creates a new Node command.
-func NewCommand() cli.Command {
-	return cli.Command{
-		Name:   "node",
-		Usage:  "start a NEO node",
-		Action: startServer,
-		Flags: []cli.Flag{
-			cli.StringFlag{Name: "config-path"},
-			cli.BoolFlag{Name: "privnet, p"},
-			cli.BoolFlag{Name: "mainnet, m"},
-			cli.BoolFlag{Name: "testnet, t"},
-			cli.BoolFlag{Name: "debug, d"},
+// NewCommands returns the node command together with the db one handling
+// the chain data.
+func NewCommands() []cli.Command {
+	var cfgFlags = []cli.Flag{
+		cli.StringFlag{Name: "config-path"},
+		cli.BoolFlag{Name: "privnet, p"},
+		cli.BoolFlag{Name: "mainnet, m"},
+		cli.BoolFlag{Name: "testnet, t"},
+		cli.BoolFlag{Name: "debug, d"},
+	}
+	var dumpFlags = append(cfgFlags,
+		cli.StringFlag{Name: "out, o", Usage: "file to write the blocks to, chain.acc or chain.{start}.acc by default"},
+		cli.UintFlag{Name: "start, s", Usage: "index of the first block to dump"},
+		cli.UintFlag{Name: "count, c", Usage: "number of blocks to dump, all the following ones if 0"},
+	)
+	var restoreFlags = append(cfgFlags,
+		cli.StringFlag{Name: "in, i", Value: "chain.acc", Usage: "file to read the blocks from, in the chain.acc or chain.{start}.acc format"},
+		cli.UintFlag{Name: "start, s", Usage: "index of the first block to restore"},
+		cli.UintFlag{Name: "count, c", Usage: "number of blocks to restore, all the following ones if 0"},
+		cli.BoolFlag{Name: "verify", Usage: "verify the restored blocks"},
+	)
+	var resetFlags = append(cfgFlags,
+		cli.UintFlag{Name: "height", Usage: "index of the block to roll the chain back to"},
+	)
+	return []cli.Command{
+		{
+			Name:   "node",
+			Usage:  "start a NEO node",
+			Action: startServer,
+			Flags:  cfgFlags,
+		},
+		{
+			Name:  "db",
+			Usage: "database manipulations",
+			Subcommands: []cli.Command{
+				{
+					Name:   "dump",
+					Usage:  "dump blocks to the file in the chain.acc format",
+					Action: dumpDB,
+					Flags:  dumpFlags,
+				},
+				{
+					Name:   "restore",
+					Usage:  "restore blocks from the file in the chain.acc format",
+					Action: restoreDB,
+					Flags:  restoreFlags,
+				},
+				{
+					Name:   "reset",
+					Usage:  "roll the chain back to the given height",
+					Action: resetDB,
+					Flags:  resetFlags,
+				},
+			},
 		},
 	}
 }
 
-func startServer(ctx *cli.Context) error {
+// getConfigFromContext loads the configuration of the network selected by
+// the flags of the given context.
+func getConfigFromContext(ctx *cli.Context) (config.Config, error) {
 	net := config.ModePrivNet
 	if ctx.Bool("testnet") {
 		net = config.ModeTestNet
@@ -44,7 +89,107 @@ func startServer(ctx *cli.Context) error {
 	if argCp := ctx.String("config-path"); argCp != "" {
 		configPath = argCp
 	}
-	cfg, err := config.Load(configPath, net)
+	return config.Load(configPath, net)
+}
+
+func dumpDB(ctx *cli.Context) error {
+	cfg, err := getConfigFromContext(ctx)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if ctx.Bool("debug") {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	start := uint32(ctx.Uint("start"))
+	out := ctx.String("out")
+	if out == "" {
+		out = "chain.acc"
+		if start > 0 {
+			out = fmt.Sprintf("chain.%d.acc", start)
+		}
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer f.Close()
+
+	chain, err := newBlockchain(cfg)
+	if err != nil {
+		err = fmt.Errorf("could not initialize blockchain: %s", err)
+		return cli.NewExitError(err, 1)
+	}
+	defer chain.Close()
+
+	w := bufio.NewWriter(f)
+	if err := core.DumpBlocks(chain, w, start, uint32(ctx.Uint("count"))); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := w.Flush(); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
+
+func restoreDB(ctx *cli.Context) error {
+	cfg, err := getConfigFromContext(ctx)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if ctx.Bool("debug") {
+		log.SetLevel(log.DebugLevel)
+	}
+	cfg.ProtocolConfiguration.VerifyBlocks = ctx.Bool("verify")
+
+	in := ctx.String("in")
+	f, err := os.Open(in)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer f.Close()
+
+	chain, err := newBlockchain(cfg)
+	if err != nil {
+		err = fmt.Errorf("could not initialize blockchain: %s", err)
+		return cli.NewExitError(err, 1)
+	}
+	defer chain.Close()
+
+	if err := core.RestoreBlocks(chain, f, uint32(ctx.Uint("start")), uint32(ctx.Uint("count"))); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Printf("restored up to block %d\n", chain.BlockHeight())
+	return nil
+}
+
+func resetDB(ctx *cli.Context) error {
+	if !ctx.IsSet("height") {
+		return cli.NewExitError("the height to roll back to is required", 1)
+	}
+	cfg, err := getConfigFromContext(ctx)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if ctx.Bool("debug") {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	chain, err := newBlockchain(cfg)
+	if err != nil {
+		err = fmt.Errorf("could not initialize blockchain: %s", err)
+		return cli.NewExitError(err, 1)
+	}
+	defer chain.Close()
+	if err := chain.Reset(uint32(ctx.Uint("height"))); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Printf("reset to block %d\n", chain.BlockHeight())
+	return nil
+}
+
+func startServer(ctx *cli.Context) error {
+	cfg, err := getConfigFromContext(ctx)
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
@@ -90,6 +235,9 @@ Main:
 			break Main
 		}
 	}
+	if err := chain.Close(); err != nil && shutdownErr == nil {
+		shutdownErr = errors.Wrap(err, "Error encountered whilst closing the chain")
+	}
 
 	if shutdownErr != nil {
 		return cli.NewExitError(shutdownErr, 1)
@@ -99,16 +247,17 @@ Main:
 }
 
 func newBlockchain(cfg config.Config) (*core.Blockchain, error) {
-	// Hardcoded for now.
-	store, err := storage.NewLevelDBStore(
-		cfg.ApplicationConfiguration.DataDirectoryPath,
-		nil,
-	)
+	store, err := storage.NewStore(cfg.ApplicationConfiguration.DBConfiguration)
 	if err != nil {
 		return nil, err
 	}
 
-	return core.NewBlockchain(store, cfg.ProtocolConfiguration)
+	chain, err := core.NewBlockchain(store, cfg)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	return chain, nil
 }
 
 func logo() string {
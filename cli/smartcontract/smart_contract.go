@@ -2,7 +2,6 @@ package smartcontract
 
 import (
 	"context"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -106,13 +105,12 @@ func testInvoke(ctx *cli.Context) error {
 		return cli.NewExitError(err, 1)
 	}
 
-	scriptHex := hex.EncodeToString(b)
-	resp, err := client.InvokeScript(scriptHex)
+	resp, err := client.InvokeScript(b)
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
 
-	b, err = json.MarshalIndent(resp.Result, "", "  ")
+	b, err = json.MarshalIndent(resp, "", "  ")
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
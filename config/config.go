@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
 	"github.com/go-yaml/yaml"
 	"github.com/pkg/errors"
 )
@@ -44,6 +45,8 @@ type (
 		StandbyValidators       []string  `yaml:"StandbyValidators"`
 		SeedList                []string  `yaml:"SeedList"`
 		SystemFee               SystemFee `yaml:"SystemFee"`
+		// Whether to verify received blocks.
+		VerifyBlocks bool `yaml:"VerifyBlocks"`
 	}
 
 	// SystemFee fees related to system.
@@ -56,13 +59,20 @@ type (
 
 	// ApplicationConfiguration config specific to the node.
 	ApplicationConfiguration struct {
-		DataDirectoryPath string        `yaml:"DataDirectoryPath"`
-		RPCPort           uint16        `yaml:"RPCPort"`
-		NodePort          uint16        `yaml:"NodePort"`
-		Relay             bool          `yaml:"Relay"`
-		DialTimeout       time.Duration `yaml:"DialTimeout"`
-		ProtoTickInterval time.Duration `yaml:"ProtoTickInterval"`
-		MaxPeers          int           `yaml:"MaxPeers"`
+		DBConfiguration   storage.DBConfiguration `yaml:"DBConfiguration"`
+		RPCPort           uint16                  `yaml:"RPCPort"`
+		NodePort          uint16                  `yaml:"NodePort"`
+		Relay             bool                    `yaml:"Relay"`
+		DialTimeout       time.Duration           `yaml:"DialTimeout"`
+		ProtoTickInterval time.Duration           `yaml:"ProtoTickInterval"`
+		MaxPeers          int                     `yaml:"MaxPeers"`
+		// Whether to index the transactions touching every address.
+		AddressHistory bool `yaml:"AddressHistory"`
+		// Number of the latest blocks that can be undone, none of them if 0.
+		MaxUndoBlocks uint32 `yaml:"MaxUndoBlocks"`
+		// LevelDB directory of the configurations written before
+		// DBConfiguration, used when its Type isn't set.
+		DataDirectoryPath string `yaml:"DataDirectoryPath"`
 	}
 
 	// NetMode describes the mode the blockchain will operate on.
@@ -113,5 +123,14 @@ func Load(path string, netMode NetMode) (Config, error) {
 		return Config{}, errors.Wrap(err, "Problem unmarshaling config json data")
 	}
 
+	app := &config.ApplicationConfiguration
+	if app.DBConfiguration.Type == "" {
+		if app.DataDirectoryPath == "" {
+			return Config{}, errors.New("neither DBConfiguration.Type nor the former DataDirectoryPath is set, the database has to be configured in DBConfiguration")
+		}
+		app.DBConfiguration.Type = "leveldb"
+		app.DBConfiguration.LevelDBOptions.DataDirectoryPath = app.DataDirectoryPath
+	}
+
 	return config, nil
 }
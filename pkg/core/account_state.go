@@ -11,40 +11,30 @@ import (
 	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
-// Accounts is mapping between a account address and AccountState.
-type Accounts map[util.Uint160]*AccountState
-
-func (a Accounts) getAndUpdate(s storage.Store, hash util.Uint160) (*AccountState, error) {
-	if account, ok := a[hash]; ok {
-		return account, nil
+// getAccountState returns the state of the account with the given script
+// hash in the given store, a new one if the account is unknown.
+func getAccountState(s storage.Store, hash util.Uint160) (*AccountState, error) {
+	b, err := s.Get(storage.AppendPrefix(storage.STAccount, hash.Bytes()))
+	if err == storage.ErrKeyNotFound {
+		return NewAccountState(hash), nil
+	}
+	if err != nil {
+		return nil, err
 	}
-
 	account := &AccountState{}
-	key := storage.AppendPrefix(storage.STAccount, hash.Bytes())
-	if b, err := s.Get(key); err == nil {
-		if err := account.DecodeBinary(bytes.NewReader(b)); err != nil {
-			return nil, fmt.Errorf("failed to decode (AccountState): %s", err)
-		}
-	} else {
-		account = NewAccountState(hash)
+	if err := account.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("failed to decode (AccountState): %s", err)
 	}
-
-	a[hash] = account
 	return account, nil
 }
 
-// commit writes all account states to the given Batch.
-func (a Accounts) commit(b storage.Batch) error {
+// putAccountState stores the given account state in the given store.
+func putAccountState(s storage.Store, account *AccountState) error {
 	buf := new(bytes.Buffer)
-	for hash, state := range a {
-		if err := state.EncodeBinary(buf); err != nil {
-			return err
-		}
-		key := storage.AppendPrefix(storage.STAccount, hash.Bytes())
-		b.Put(key, buf.Bytes())
-		buf.Reset()
+	if err := account.EncodeBinary(buf); err != nil {
+		return err
 	}
-	return nil
+	return s.Put(storage.AppendPrefix(storage.STAccount, account.ScriptHash.Bytes()), buf.Bytes())
 }
 
 // AccountState represents the state of a NEO account.
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// ErrAddressHistoryDisabled is returned when querying the address history
+// of a Blockchain that doesn't maintain it.
+var ErrAddressHistoryDisabled = errors.New("address history is disabled")
+
+// AddressTransaction is a transaction touching an address, identified by
+// its hash and its position in the chain.
+type AddressTransaction struct {
+	Hash   util.Uint256
+	Height uint32
+	Index  uint16
+}
+
+// makeAddressHistoryKey returns the key of the given transaction in the
+// history of the given address. The position of the transaction is stored
+// inverted so that the newest transactions come first.
+func makeAddressHistoryKey(scriptHash util.Uint160, height uint32, index uint16) []byte {
+	position := make([]byte, 6)
+	binary.BigEndian.PutUint32(position, ^height)
+	binary.BigEndian.PutUint16(position[4:], ^index)
+	return storage.AppendPrefix(storage.IXAddressHistory, append(scriptHash.Bytes(), position...))
+}
+
+// storeAddressHistory adds the given transaction, found at the given index of
+// the given block, to the history of the addresses it sends to, spends from
+// or, for invocations, is witnessed by. The outputs it spends are read from
+// the given store.
+func storeAddressHistory(s storage.Store, batch storage.Batch, block *Block, index uint16, tx *transaction.Transaction) error {
+	touched := make(map[util.Uint160]bool)
+	for _, output := range tx.Outputs {
+		touched[output.ScriptHash] = true
+	}
+	references, err := getReferences(s, tx)
+	if err != nil {
+		return err
+	}
+	for _, output := range references {
+		touched[output.ScriptHash] = true
+	}
+	if tx.Type == transaction.InvocationType {
+		for _, witness := range tx.Scripts {
+			if len(witness.VerificationScript) == 0 {
+				continue
+			}
+			hash, err := util.Uint160FromScript(witness.VerificationScript)
+			if err != nil {
+				return err
+			}
+			touched[hash] = true
+		}
+	}
+
+	for scriptHash := range touched {
+		batch.Put(makeAddressHistoryKey(scriptHash, block.Index, index), tx.Hash().BytesReverse())
+	}
+	return nil
+}
+
+// GetAddressHistory returns, newest first, at most limit transactions
+// touching the account with the given script hash that are older than the
+// given one, the newest ones if it's nil.
+func (bc *Blockchain) GetAddressHistory(scriptHash util.Uint160, before *AddressTransaction, limit int) ([]*AddressTransaction, error) {
+	if !bc.addressHistory {
+		return nil, ErrAddressHistoryDisabled
+	}
+
+	var (
+		prefix = storage.AppendPrefix(storage.IXAddressHistory, scriptHash.Bytes())
+		txs    []*AddressTransaction
+		err    error
+	)
+	bc.Seek(prefix, func(k, v []byte) {
+		if err != nil || len(txs) >= limit || len(k) != len(prefix)+6 {
+			return
+		}
+		tx := &AddressTransaction{
+			Height: ^binary.BigEndian.Uint32(k[len(prefix):]),
+			Index:  ^binary.BigEndian.Uint16(k[len(prefix)+4:]),
+		}
+		if before != nil && (tx.Height > before.Height || (tx.Height == before.Height && tx.Index >= before.Index)) {
+			return
+		}
+		tx.Hash, err = util.Uint256DecodeBytes(v)
+		txs = append(txs, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/CityOfZion/neo-go/config"
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAddressHistoryTestChain(t *testing.T) *Blockchain {
+	cfg, err := config.Load("../../config", config.ModePrivNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ProtocolConfiguration.VerifyBlocks = false
+	cfg.ApplicationConfiguration.AddressHistory = true
+	chain, err := NewBlockchain(storage.NewMemoryStore(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+func TestAddressHistoryDisabled(t *testing.T) {
+	bc := newTestChain(t)
+	_, err := bc.GetAddressHistory(util.Uint160{1, 2, 3}, nil, 10)
+	assert.Equal(t, ErrAddressHistoryDisabled, err)
+}
+
+func TestGetAddressHistory(t *testing.T) {
+	bc := newAddressHistoryTestChain(t)
+	genesis, err := createGenesisBlock(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueTX := genesis.Transactions[3]
+	neo := governingTokenTX().Hash()
+	amount := issueTX.Outputs[0].Amount
+	owner := issueTX.Outputs[0].ScriptHash
+	receiver := util.Uint160{1, 2, 3}
+
+	txs, err := bc.GetAddressHistory(owner, nil, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*AddressTransaction{{Hash: issueTX.Hash(), Height: 0, Index: 3}}, txs)
+
+	spend := &transaction.Transaction{
+		Type:       transaction.ContractType,
+		Data:       &transaction.ContractTX{},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{{PrevHash: issueTX.Hash(), PrevIndex: 0}},
+		Outputs:    []*transaction.Output{transaction.NewOutput(neo, amount, receiver)},
+	}
+	assert.Nil(t, bc.persistBlock(newBlock(1, spend)))
+
+	// Invocations are in the history of the addresses witnessing them.
+	script := []byte{byte(vm.Opusht)}
+	signer, err := util.Uint160FromScript(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invocation := newInvocationTX([]byte{byte(vm.Opusht)})
+	invocation.Scripts = []*transaction.Witness{{VerificationScript: script}}
+	assert.Nil(t, bc.persistBlock(newBlock(2, invocation)))
+
+	txs, err = bc.GetAddressHistory(owner, nil, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*AddressTransaction{
+		{Hash: spend.Hash(), Height: 1, Index: 0},
+		{Hash: issueTX.Hash(), Height: 0, Index: 3},
+	}, txs)
+	txs, err = bc.GetAddressHistory(receiver, nil, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*AddressTransaction{{Hash: spend.Hash(), Height: 1, Index: 0}}, txs)
+	txs, err = bc.GetAddressHistory(signer, nil, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*AddressTransaction{{Hash: invocation.Hash(), Height: 2, Index: 0}}, txs)
+
+	// Pages start after the given transaction.
+	txs, err = bc.GetAddressHistory(owner, nil, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []*AddressTransaction{{Hash: spend.Hash(), Height: 1, Index: 0}}, txs)
+	txs, err = bc.GetAddressHistory(owner, txs[0], 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []*AddressTransaction{{Hash: issueTX.Hash(), Height: 0, Index: 3}}, txs)
+	txs, err = bc.GetAddressHistory(owner, txs[0], 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(txs))
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
 
 	"github.com/CityOfZion/neo-go/pkg/core/storage"
@@ -13,20 +14,30 @@ import (
 
 const feeMode = 0x0
 
-// Assets is mapping between AssetID and the AssetState.
-type Assets map[util.Uint256]*AssetState
+// getAssetState returns the state of the asset with the given ID in the
+// given store.
+func getAssetState(s storage.Store, id util.Uint256) (*AssetState, error) {
+	b, err := s.Get(storage.AppendPrefix(storage.STAsset, id.Bytes()))
+	if err == storage.ErrKeyNotFound {
+		return nil, fmt.Errorf("unknown asset %s", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	asset := &AssetState{}
+	if err := asset.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("failed to decode (AssetState): %s", err)
+	}
+	return asset, nil
+}
 
-func (a Assets) commit(b storage.Batch) error {
+// putAssetState stores the given asset state in the given store.
+func putAssetState(s storage.Store, asset *AssetState) error {
 	buf := new(bytes.Buffer)
-	for hash, state := range a {
-		if err := state.EncodeBinary(buf); err != nil {
-			return err
-		}
-		key := storage.AppendPrefix(storage.STAsset, hash.Bytes())
-		b.Put(key, buf.Bytes())
-		buf.Reset()
-	}
-	return nil
+	if err := asset.EncodeBinary(buf); err != nil {
+		return err
+	}
+	return s.Put(storage.AppendPrefix(storage.STAsset, asset.ID.Bytes()), buf.Bytes())
 }
 
 // AssetState represents the state of an NEO registerd Asset.
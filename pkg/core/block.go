@@ -8,7 +8,6 @@ import (
 	"github.com/CityOfZion/neo-go/pkg/core/transaction"
 	"github.com/CityOfZion/neo-go/pkg/crypto"
 	"github.com/CityOfZion/neo-go/pkg/util"
-	log "github.com/sirupsen/logrus"
 )
 
 // Block represents one block in the chain.
@@ -30,24 +29,39 @@ func (b *Block) Header() *Header {
 	}
 }
 
-// rebuildMerkleRoot rebuild the merkleroot of the block.
-func (b *Block) rebuildMerkleRoot() error {
+// computeMerkleRoot computes the merkle root of the block's transactions.
+func (b *Block) computeMerkleRoot() (util.Uint256, error) {
 	hashes := make([]util.Uint256, len(b.Transactions))
 	for i, tx := range b.Transactions {
 		hashes[i] = tx.Hash()
 	}
 
 	merkle, err := crypto.NewMerkleTree(hashes)
+	if err != nil {
+		return util.Uint256{}, err
+	}
+	return merkle.Root(), nil
+}
+
+// rebuildMerkleRoot rebuild the merkleroot of the block.
+func (b *Block) rebuildMerkleRoot() error {
+	root, err := b.computeMerkleRoot()
 	if err != nil {
 		return err
 	}
 
-	b.MerkleRoot = merkle.Root()
+	b.MerkleRoot = root
 	return nil
 }
 
-// Verify the integrity of the block.
+// Verify the integrity of the block. When full is true the transactions are
+// also checked for duplicates and the merkle root is recomputed from them.
+// Checks that depend on the state of the chain, like the linkage with the
+// previous header and the witnesses, are done by the Blockchain.
 func (b *Block) Verify(full bool) bool {
+	if len(b.Transactions) == 0 {
+		return false
+	}
 	// The first TX has to be a miner transaction.
 	if b.Transactions[0].Type != transaction.MinerType {
 		return false
@@ -58,9 +72,19 @@ func (b *Block) Verify(full bool) bool {
 			return false
 		}
 	}
-	// TODO: When full is true, do a full verification.
 	if full {
-		log.Warn("full verification of blocks is not yet implemented")
+		hashes := make(map[util.Uint256]bool, len(b.Transactions))
+		for _, tx := range b.Transactions {
+			if hashes[tx.Hash()] {
+				return false
+			}
+			hashes[tx.Hash()] = true
+		}
+
+		root, err := b.computeMerkleRoot()
+		if err != nil || !root.Equals(b.MerkleRoot) {
+			return false
+		}
 	}
 	return true
 }
@@ -150,5 +174,16 @@ func (b *Block) DecodeBinary(r io.Reader) error {
 
 // EncodeBinary encodes the block to the given writer.
 func (b *Block) EncodeBinary(w io.Writer) error {
+	if err := b.BlockBase.EncodeBinary(w); err != nil {
+		return err
+	}
+	if err := util.WriteVarUint(w, uint64(len(b.Transactions))); err != nil {
+		return err
+	}
+	for _, tx := range b.Transactions {
+		if err := tx.EncodeBinary(w); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -89,6 +89,16 @@ func (b *BlockBase) EncodeBinary(w io.Writer) error {
 	return b.Script.EncodeBinary(w)
 }
 
+// GetHashableData returns the serialized fields of the block that are
+// covered by its hash, this is the data the witness has to sign.
+func (b *BlockBase) GetHashableData() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := b.encodeHashableFields(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // createHash creates the hash of the block.
 // When calculating the hash value of the block, instead of calculating the entire block,
 // only first seven fields in the block head will be calculated, which are
@@ -46,6 +46,29 @@ func TestDecodeBlock1(t *testing.T) {
 	assert.Equal(t, len(minerTX["attributes"].([]interface{})), len(block.Transactions[0].Attributes))
 }
 
+func TestEncodeDecodeBlock1(t *testing.T) {
+	data, err := getBlockData(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := hex.DecodeString(data["raw"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := &Block{}
+	if err := block.DecodeBinary(bytes.NewReader(b)); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := block.EncodeBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, b, buf.Bytes())
+}
+
 func TestTrimmedBlock(t *testing.T) {
 	block := getDecodedBlock(t, 1)
 
@@ -101,3 +124,19 @@ func TestBlockVerify(t *testing.T) {
 	}
 	assert.False(t, block.Verify(false))
 }
+
+func TestBlockVerifyFull(t *testing.T) {
+	block := getDecodedBlock(t, 2)
+	assert.True(t, block.Verify(true))
+
+	block.MerkleRoot[0]++
+	assert.False(t, block.Verify(true))
+	assert.True(t, block.Verify(false))
+
+	block = getDecodedBlock(t, 1)
+	block.Transactions = append(block.Transactions, block.Transactions[0])
+	assert.False(t, block.Verify(true))
+
+	block.Transactions = nil
+	assert.False(t, block.Verify(false))
+}
@@ -2,15 +2,23 @@ package core
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/CityOfZion/neo-go/config"
 	"github.com/CityOfZion/neo-go/pkg/core/storage"
 	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
 	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -18,7 +26,17 @@ import (
 const (
 	secondsPerBlock  = 15
 	headerBatchCount = 2000
-	version          = "0.0.1"
+
+	// Version of the storage schema, storages of older versions are
+	// upgraded by the registered migrations.
+	version = "0.1.0"
+
+	// Limits for transactions accepted by the node.
+	maxTransactionSize       = 102400
+	maxTransactionAttributes = 16
+
+	// GAS every script execution can consume for free.
+	freeGas util.Fixed8 = 10 * 100000000
 )
 
 var (
@@ -52,22 +70,51 @@ type Blockchain struct {
 	headersOp     chan headersOpFunc
 	headersOpDone chan struct{}
 
+	// Only one persist() may run at a time.
+	persistLock sync.Mutex
+
+	// Persists started by run(), waited for when closing.
+	persistWG sync.WaitGroup
+
+	// Closed to stop run(), which closes runDone once the cached blocks are
+	// persisted.
+	stopCh  chan struct{}
+	runDone chan struct{}
+
 	// Whether we will verify received blocks.
 	verifyBlocks bool
+
+	// Whether we will index the transactions touching every address.
+	addressHistory bool
+
+	// Number of the latest blocks we keep the undo data of, all if 0.
+	maxUndoBlocks uint32
+
+	// Verified transactions waiting to be included in a block.
+	memPool *MemPool
+
+	// Sends the persisted data to the subscribers.
+	events *eventDispatcher
 }
 
 type headersOpFunc func(headerList *HeaderHashList)
 
 // NewBlockchain return a new blockchain object the will use the
 // given Store as its underlying storage.
-func NewBlockchain(s storage.Store, cfg config.ProtocolConfiguration) (*Blockchain, error) {
+func NewBlockchain(s storage.Store, cfg config.Config) (*Blockchain, error) {
 	bc := &Blockchain{
-		config:        cfg,
-		Store:         s,
-		headersOp:     make(chan headersOpFunc),
-		headersOpDone: make(chan struct{}),
-		blockCache:    NewCache(),
-		verifyBlocks:  false,
+		config:         cfg.ProtocolConfiguration,
+		Store:          s,
+		headersOp:      make(chan headersOpFunc),
+		headersOpDone:  make(chan struct{}),
+		blockCache:     NewCache(),
+		verifyBlocks:   cfg.ProtocolConfiguration.VerifyBlocks,
+		addressHistory: cfg.ApplicationConfiguration.AddressHistory,
+		maxUndoBlocks:  cfg.ApplicationConfiguration.MaxUndoBlocks,
+		memPool:        NewMemPool(memPoolCapacity),
+		events:         newEventDispatcher(),
+		stopCh:         make(chan struct{}),
+		runDone:        make(chan struct{}),
 	}
 	go bc.run()
 
@@ -90,10 +137,17 @@ func (bc *Blockchain) init() error {
 	if err != nil {
 		log.Infof("no storage version found! creating genesis block")
 		storage.PutVersion(bc.Store, version)
-		return bc.persistBlock(genesisBlock)
+		if err := bc.persistBlock(genesisBlock); err != nil {
+			return err
+		}
+		// The genesis header isn't added with AddHeaders, it's stored as the
+		// current one for the chain to be restored.
+		return bc.Store.Put(storage.SYSCurrentHeader.Bytes(), hashAndIndexToBytes(genesisBlock.Hash(), genesisBlock.Index))
 	}
 	if ver != version {
-		return fmt.Errorf("storage version mismatch betweeen %s and %s", version, ver)
+		if err := bc.migrate(ver, version); err != nil {
+			return err
+		}
 	}
 
 	// At this point there was no version found in the storage which
@@ -141,9 +195,11 @@ func (bc *Blockchain) init() error {
 			hash = header.PrevHash
 		}
 
+		// The headers are already stored, possibly as persisted blocks
+		// that mustn't be overwritten, they're only listed again.
 		headerSliceReverse(headers)
-		if err := bc.AddHeaders(headers...); err != nil {
-			return err
+		for _, header := range headers {
+			bc.headerList.Add(header.Hash())
 		}
 	}
 
@@ -151,22 +207,61 @@ func (bc *Blockchain) init() error {
 }
 
 func (bc *Blockchain) run() {
-	persistTimer := time.NewTimer(persistInterval)
+	var (
+		persistTimer = time.NewTimer(persistInterval)
+		stop         = bc.stopCh
+		persisted    chan struct{}
+	)
 	for {
 		select {
 		case op := <-bc.headersOp:
 			op(bc.headerList)
 			bc.headersOpDone <- struct{}{}
 		case <-persistTimer.C:
-			go bc.persist()
+			if stop == nil {
+				break
+			}
+			bc.persistWG.Add(1)
+			go func() {
+				defer bc.persistWG.Done()
+				bc.persist()
+			}()
 			persistTimer.Reset(persistInterval)
+		case <-stop:
+			// The cached blocks are persisted before stopping, the
+			// headers operations they need being served meanwhile.
+			persistTimer.Stop()
+			stop = nil
+			persisted = make(chan struct{})
+			go func() {
+				bc.persistWG.Wait()
+				if err := bc.persist(); err != nil {
+					log.Warnf("failed to persist the cached blocks: %s", err)
+				}
+				close(persisted)
+			}()
+		case <-persisted:
+			close(bc.runDone)
+			return
 		}
 	}
 }
 
+// Close persists the cached blocks, stops sending events and closes the
+// store. The blockchain can't be used anymore once closed.
+func (bc *Blockchain) Close() error {
+	close(bc.stopCh)
+	<-bc.runDone
+	bc.events.close()
+	return bc.Store.Close()
+}
+
 // AddBlock processes the given block and will add it to the cache so it
 // can be persisted.
 func (bc *Blockchain) AddBlock(block *Block) error {
+	if bc.verifyBlocks && !block.Verify(true) {
+		return fmt.Errorf("block %s is invalid", block.Hash())
+	}
 	if !bc.blockCache.Has(block.Hash()) {
 		bc.blockCache.Add(block.Hash(), block)
 	}
@@ -176,9 +271,6 @@ func (bc *Blockchain) AddBlock(block *Block) error {
 		return nil
 	}
 	if int(block.Index) == headerLen {
-		if bc.verifyBlocks && !block.Verify(false) {
-			return fmt.Errorf("block %s is invalid", block.Hash())
-		}
 		return bc.AddHeaders(block.Header())
 	}
 	return nil
@@ -193,6 +285,7 @@ func (bc *Blockchain) AddHeaders(headers ...*Header) (err error) {
 	)
 
 	bc.headersOp <- func(headerList *HeaderHashList) {
+		var prevHeader *Header
 		for _, h := range headers {
 			if int(h.Index-1) >= headerList.Len() {
 				err = fmt.Errorf(
@@ -208,9 +301,24 @@ func (bc *Blockchain) AddHeaders(headers ...*Header) (err error) {
 				err = fmt.Errorf("header %v is invalid", h)
 				return
 			}
+			if bc.verifyBlocks {
+				// The previous header is either processed in this batch
+				// or already stored.
+				if prevHeader == nil || prevHeader.Index+1 != h.Index {
+					prevHeader, err = bc.getHeader(headerList.Get(int(h.Index) - 1))
+					if err != nil {
+						return
+					}
+				}
+				if err = bc.verifyHeader(h, prevHeader); err != nil {
+					err = fmt.Errorf("header %s is invalid: %s", h.Hash(), err)
+					return
+				}
+			}
 			if err = bc.processHeader(h, batch, headerList); err != nil {
 				return
 			}
+			prevHeader = h
 		}
 
 		if batch.Len() > 0 {
@@ -244,7 +352,9 @@ func (bc *Blockchain) processHeader(h *Header, batch storage.Batch, headerList *
 		buf.Reset()
 	}
 
+	// Headers are stored like the blocks, with no system fee yet.
 	buf.Reset()
+	buf.Write(make([]byte, 4))
 	if err := h.EncodeBinary(buf); err != nil {
 		return err
 	}
@@ -256,29 +366,80 @@ func (bc *Blockchain) processHeader(h *Header, batch storage.Batch, headerList *
 	return nil
 }
 
+// removeHeaders drops the headers above the given height together with the
+// stored header hashes batches that hold them. Note that this is only thread
+// safe if executed in headers operation.
+func (bc *Blockchain) removeHeaders(headerList *HeaderHashList, height uint32) error {
+	batch := bc.Batch()
+	for i := headerList.Len() - 1; i > int(height); i-- {
+		hash := headerList.Get(i)
+		batch.Delete(storage.AppendPrefix(storage.DataBlock, hash.BytesReverse()))
+		bc.blockCache.Delete(hash)
+	}
+	stored := (height + 1) / headerBatchCount * headerBatchCount
+	for i := stored; i < uint32(headerList.Len()); i += headerBatchCount {
+		batch.Delete(storage.AppendPrefixInt(storage.IXHeaderHashList, int(i)))
+	}
+	batch.Put(storage.SYSCurrentHeader.Bytes(), hashAndIndexToBytes(headerList.Get(int(height)), height))
+	if err := bc.PutBatch(batch); err != nil {
+		return err
+	}
+
+	headerList.hashes = headerList.Slice(0, int(height)+1)
+	if stored < bc.storedHeaderCount {
+		bc.storedHeaderCount = stored
+	}
+	return nil
+}
+
 // TODO: persistBlock needs some more love, its implemented as in the original
 // project. This for the sake of development speed and understanding of what
 // is happening here, quite allot as you can see :). If things are wired together
 // and all tests are in place, we can make a more optimized and cleaner implementation.
 func (bc *Blockchain) persistBlock(block *Block) error {
 	var (
-		batch        = bc.Batch()
-		unspentCoins = make(UnspentCoins)
-		spentCoins   = make(SpentCoins)
-		accounts     = make(Accounts)
-		assets       = make(Assets)
+		cache   = storage.NewMemCachedStore(bc.Store)
+		batch   = cache.Batch()
+		results = make(map[util.Uint256]*AppExecResult)
 	)
 
-	storeAsBlock(batch, block, 0)
-	storeAsCurrentBlock(batch, block)
+	validatorsCount, err := getValidatorsCount(cache)
+	if err != nil {
+		return err
+	}
+	oldValidatorsCount := *validatorsCount
 
+	sysFee := bc.GetSysFeeAmount(block.PrevHash)
 	for _, tx := range block.Transactions {
-		storeAsTransaction(batch, tx, block.Index)
-		unspentCoins[tx.Hash()] = NewUnspentCoinState(len(tx.Outputs))
+		sysFee += uint32(bc.SystemFee(tx).Value())
+	}
+	if err := storeAsBlock(batch, block, sysFee); err != nil {
+		return err
+	}
+	storeAsCurrentBlock(batch, block)
+
+	for i, tx := range block.Transactions {
+		// The transaction is cached right away for the following ones of
+		// the block to spend its outputs.
+		txBatch := cache.Batch()
+		if err := storeAsTransaction(txBatch, tx, block.Index); err != nil {
+			return err
+		}
+		if err := cache.PutBatch(txBatch); err != nil {
+			return err
+		}
+		if bc.addressHistory {
+			if err := storeAddressHistory(cache, batch, block, uint16(i), tx); err != nil {
+				return err
+			}
+		}
+		if err := putUnspentCoinState(cache, tx.Hash(), NewUnspentCoinState(len(tx.Outputs))); err != nil {
+			return err
+		}
 
 		// Process TX outputs.
-		for _, output := range tx.Outputs {
-			account, err := accounts.getAndUpdate(bc.Store, output.ScriptHash)
+		for i, output := range tx.Outputs {
+			account, err := getAccountState(cache, output.ScriptHash)
 			if err != nil {
 				return err
 			}
@@ -287,55 +448,129 @@ func (bc *Blockchain) persistBlock(block *Block) error {
 			} else {
 				account.Balances[output.AssetID] = output.Amount
 			}
+			if err := putAccountState(cache, account); err != nil {
+				return err
+			}
+			if output.AssetID.Equals(governingTokenTX().Hash()) {
+				if err := addVotes(cache, account, output.Amount, validatorsCount); err != nil {
+					return err
+				}
+			}
+
+			err = putUnspentOutput(cache, output.ScriptHash, &UnspentOutput{
+				TxHash:  tx.Hash(),
+				Index:   uint16(i),
+				AssetID: output.AssetID,
+				Amount:  output.Amount,
+			})
+			if err != nil {
+				return err
+			}
 		}
 
 		// Process TX inputs that are grouped by previous hash.
 		for prevHash, inputs := range tx.GroupInputsByPrevHash() {
-			prevTX, prevTXHeight, err := bc.GetTransaction(prevHash)
+			prevTX, prevTXHeight, err := getTransaction(cache, prevHash)
 			if err != nil {
 				return fmt.Errorf("could not find previous TX: %s", prevHash)
 			}
+			unspent, err := getUnspentCoinState(cache, prevHash)
+			if err != nil {
+				return err
+			}
 			for _, input := range inputs {
-				unspent, err := unspentCoins.getAndUpdate(bc.Store, input.PrevHash)
-				if err != nil {
-					return err
-				}
 				unspent.states[input.PrevIndex] = CoinStateSpent
 
 				prevTXOutput := prevTX.Outputs[input.PrevIndex]
-				account, err := accounts.getAndUpdate(bc.Store, prevTXOutput.ScriptHash)
+				account, err := getAccountState(cache, prevTXOutput.ScriptHash)
 				if err != nil {
 					return err
 				}
 
 				if prevTXOutput.AssetID.Equals(governingTokenTX().Hash()) {
-					spentCoin := NewSpentCoinState(input.PrevHash, prevTXHeight)
-					spentCoin.items[input.PrevIndex] = block.Index
-					spentCoins[input.PrevHash] = spentCoin
+					err = markCoinSpent(cache, &SpentCoin{
+						TxHash:      input.PrevHash,
+						Index:       input.PrevIndex,
+						Output:      prevTXOutput,
+						StartHeight: prevTXHeight,
+						EndHeight:   block.Index,
+					})
+					if err != nil {
+						return err
+					}
+					if err := addVotes(cache, account, -prevTXOutput.Amount, validatorsCount); err != nil {
+						return err
+					}
 				}
 
 				account.Balances[prevTXOutput.AssetID] -= prevTXOutput.Amount
+				if err := putAccountState(cache, account); err != nil {
+					return err
+				}
+
+				if err := deleteUnspentOutput(cache, prevTXOutput.ScriptHash, input.PrevHash, input.PrevIndex); err != nil {
+					return err
+				}
+			}
+			if err := putUnspentCoinState(cache, prevHash, unspent); err != nil {
+				return err
 			}
 		}
 
 		// Process the underlying type of the TX.
 		switch t := tx.Data.(type) {
 		case *transaction.RegisterTX:
-			assets[tx.Hash()] = &AssetState{
-				ID:        tx.Hash(),
-				AssetType: t.AssetType,
-				Name:      t.Name,
-				Amount:    t.Amount,
-				Precision: t.Precision,
-				Owner:     t.Owner,
-				Admin:     t.Admin,
+			err := putAssetState(cache, &AssetState{
+				ID:         tx.Hash(),
+				AssetType:  t.AssetType,
+				Name:       t.Name,
+				Amount:     t.Amount,
+				Precision:  t.Precision,
+				Owner:      t.Owner,
+				Admin:      t.Admin,
+				Issuer:     t.Admin,
+				Expiration: block.Index + 2*uint32(decrementInterval),
+			})
+			if err != nil {
+				return err
 			}
 		case *transaction.IssueTX:
+			results, err := getTransactionResults(cache, tx)
+			if err != nil {
+				return err
+			}
+			for assetID, amount := range results {
+				if amount >= 0 {
+					continue
+				}
+				asset, err := getAssetState(cache, assetID)
+				if err != nil {
+					return err
+				}
+				asset.Available -= amount
+				if err := putAssetState(cache, asset); err != nil {
+					return err
+				}
+			}
 		case *transaction.ClaimTX:
+			if err := markCoinsClaimed(cache, t.Claims); err != nil {
+				return err
+			}
 		case *transaction.EnrollmentTX:
+			validator, err := getValidatorState(cache, t.PublicKey)
+			if err != nil {
+				return err
+			}
+			validator.Registered = true
+			if err := putValidatorState(cache, validator); err != nil {
+				return err
+			}
 		case *transaction.StateTX:
+			if err := processStateTX(cache, t, validatorsCount); err != nil {
+				return err
+			}
 		case *transaction.PublishTX:
-			contract := &ContractState{
+			err := putContractState(cache, &ContractState{
 				Script:      t.Script,
 				ParamList:   t.ParamList,
 				ReturnType:  t.ReturnType,
@@ -345,35 +580,108 @@ func (bc *Blockchain) persistBlock(block *Block) error {
 				Author:      t.Author,
 				Email:       t.Email,
 				Description: t.Description,
+			})
+			if err != nil {
+				return err
 			}
-
-			fmt.Printf("%+v", contract)
-
 		case *transaction.InvocationTX:
+			aer := bc.persistInvocation(cache, block, tx, t)
+			if err := storeAsAppExecResult(batch, aer); err != nil {
+				return err
+			}
+			if err := processNEP5Transfers(cache, block, uint16(i), aer); err != nil {
+				return err
+			}
+			results[aer.TxHash] = aer
 		}
 	}
 
 	// Persist all to storage.
-	if err := accounts.commit(batch); err != nil {
-		return err
-	}
-	if err := unspentCoins.commit(batch); err != nil {
-		return err
+	if *validatorsCount != oldValidatorsCount {
+		if err := putValidatorsCount(cache, validatorsCount); err != nil {
+			return err
+		}
 	}
-	if err := spentCoins.commit(batch); err != nil {
+	if err := cache.PutBatch(batch); err != nil {
 		return err
 	}
-	if err := assets.commit(batch); err != nil {
-		return err
+	if bc.maxUndoBlocks != 0 {
+		if err := putUndoData(bc.Store, cache, block.Index); err != nil {
+			return err
+		}
+		if err := pruneUndoData(cache, block.Index, bc.maxUndoBlocks); err != nil {
+			return err
+		}
 	}
-	if err := bc.PutBatch(batch); err != nil {
+	if _, err := cache.Persist(); err != nil {
 		return err
 	}
 
 	atomic.StoreUint32(&bc.blockHeight, block.Index)
+	bc.events.push(chainEvent{block: block, results: results})
+
+	// Drop the transactions of this block from the memory pool together
+	// with the pooled ones that are now double spends or claim coins that
+	// are already claimed.
+	for _, tx := range block.Transactions {
+		bc.memPool.Remove(tx.Hash())
+	}
+	bc.memPool.RemoveStale(func(t *transaction.Transaction) bool {
+		if claim, ok := t.Data.(*transaction.ClaimTX); ok {
+			if _, err := bc.calculateClaimBonus(claim.Claims); err != nil {
+				return false
+			}
+		}
+		return !bc.IsDoubleSpend(t)
+	})
 	return nil
 }
 
+// persistInvocation runs the script of the given invocation transaction on
+// top of the changes made by the block so far, in a snapshot of the given
+// block cache, and returns the result of the execution. The changes made by
+// the script are only kept if it halts.
+func (bc *Blockchain) persistInvocation(cache *storage.MemCachedStore, block *Block, tx *transaction.Transaction, inv *transaction.InvocationTX) *AppExecResult {
+	ic := newInteropContext(TriggerApplication, bc, block, tx)
+	ic.store = storage.NewMemCachedStore(cache)
+
+	v := ic.newVM()
+	v.SetGasLimit(freeGas + inv.Gas)
+	v.LoadScript(inv.Script)
+	v.Run()
+
+	aer := &AppExecResult{
+		TxHash:      tx.Hash(),
+		Trigger:     TriggerApplication,
+		VMState:     v.State(),
+		GasConsumed: v.GasConsumed(),
+	}
+	// The results are listed from the bottom of the stack.
+	aer.Stack = make([]smartcontract.Parameter, v.Estack().Len())
+	i := len(aer.Stack)
+	v.Estack().Iter(func(e *vm.Element) {
+		i--
+		aer.Stack[i] = smartcontract.NewParameterFromStackItem(e.Item())
+	})
+
+	if !v.HasHalted() {
+		log.WithFields(log.Fields{
+			"tx":    tx.Hash(),
+			"block": block.Index,
+		}).Warnf("invocation failed with state %s", v.State())
+		return aer
+	}
+
+	if _, err := ic.store.Persist(); err != nil {
+		log.WithFields(log.Fields{
+			"tx":    tx.Hash(),
+			"block": block.Index,
+		}).Warnf("failed to keep the storage changes: %s", err)
+	}
+	aer.Events = ic.notifications
+	return aer
+}
+
 func (bc *Blockchain) persist() (err error) {
 	var (
 		start     = time.Now()
@@ -385,23 +693,48 @@ func (bc *Blockchain) persist() (err error) {
 		return nil
 	}
 
+	bc.persistLock.Lock()
+	defer bc.persistLock.Unlock()
+
+	// Collect the cached blocks that follow the current one, the header list
+	// can't be accessed while persisting them as the verification and the
+	// invocations may need it.
+	var blocks []*Block
 	bc.headersOp <- func(headerList *HeaderHashList) {
-		for i := 0; i < lenCache; i++ {
-			if uint32(headerList.Len()) <= bc.BlockHeight() {
-				return
+		height := int(bc.BlockHeight())
+		for i := 1; i <= lenCache && height+i < headerList.Len(); i++ {
+			block, ok := bc.blockCache.GetBlock(headerList.Get(height + i))
+			if !ok {
+				break
 			}
-			hash := headerList.Get(int(bc.BlockHeight() + 1))
-			if block, ok := bc.blockCache.GetBlock(hash); ok {
-				if err = bc.persistBlock(block); err != nil {
-					log.Warnf("failed to persist blocks: %s", err)
-					return
+			blocks = append(blocks, block)
+		}
+	}
+	<-bc.headersOpDone
+
+	for _, block := range blocks {
+		hash := block.Hash()
+		if bc.verifyBlocks {
+			if err = bc.verifyBlock(block); err != nil {
+				log.Warnf("block %s is invalid: %s", hash, err)
+				// Its header and the following ones are dropped for the
+				// blocks at this height to be requested again.
+				bc.headersOp <- func(headerList *HeaderHashList) {
+					if err := bc.removeHeaders(headerList, block.Index-1); err != nil {
+						log.Warnf("failed to remove the headers of block %s: %s", hash, err)
+					}
 				}
-				bc.blockCache.Delete(hash)
-				persisted++
+				<-bc.headersOpDone
+				break
 			}
 		}
+		if err = bc.persistBlock(block); err != nil {
+			log.Warnf("failed to persist blocks: %s", err)
+			break
+		}
+		bc.blockCache.Delete(hash)
+		persisted++
 	}
-	<-bc.headersOpDone
 
 	if persisted > 0 {
 		log.WithFields(log.Fields{
@@ -415,6 +748,111 @@ func (bc *Blockchain) persist() (err error) {
 	return
 }
 
+// verifyHeader checks the linkage of the given header with the previous one
+// and verifies its witness against the NextConsensus of the previous header.
+func (bc *Blockchain) verifyHeader(currHeader, prevHeader *Header) error {
+	if !prevHeader.Hash().Equals(currHeader.PrevHash) {
+		return errors.New("previous header hash doesn't match")
+	}
+	if prevHeader.Index+1 != currHeader.Index {
+		return errors.New("previous header index doesn't match")
+	}
+	if prevHeader.Timestamp >= currHeader.Timestamp {
+		return errors.New("block is not newer than the previous one")
+	}
+	data, err := currHeader.GetHashableData()
+	if err != nil {
+		return err
+	}
+	return bc.verifyHashAgainstScript(prevHeader.NextConsensus, currHeader.Script, data)
+}
+
+// verifyBlock performs the checks of the given block that depend on the
+// state of the chain at the time the block is about to be persisted.
+func (bc *Blockchain) verifyBlock(block *Block) error {
+	nextConsensus, err := bc.getNextConsensus(block.Transactions...)
+	if err != nil {
+		return err
+	}
+	if !nextConsensus.Equals(block.NextConsensus) {
+		return fmt.Errorf("next consensus %s doesn't match the validators (%s)", block.NextConsensus, nextConsensus)
+	}
+
+	spent := make(map[transaction.Input]bool)
+	for _, tx := range block.Transactions {
+		for _, input := range tx.Inputs {
+			if spent[*input] {
+				return fmt.Errorf("transaction %s spends an input spent in the same block", tx.Hash())
+			}
+			spent[*input] = true
+		}
+		if bc.IsDoubleSpend(tx) {
+			return fmt.Errorf("transaction %s is a double spend", tx.Hash())
+		}
+		if claim, ok := tx.Data.(*transaction.ClaimTX); ok {
+			if err := bc.verifyClaims(tx, claim, block); err != nil {
+				return fmt.Errorf("transaction %s has invalid claims: %s", tx.Hash(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// getNextConsensus returns the script hash of the multi signature contract
+// of the validators elected once the given transactions are persisted.
+func (bc *Blockchain) getNextConsensus(txs ...*transaction.Transaction) (util.Uint160, error) {
+	validators, err := bc.GetValidators(txs...)
+	if err != nil {
+		return util.Uint160{}, err
+	}
+	return getNextConsensusAddress(validators)
+}
+
+// verifyHashAgainstScript verifies the given witness: its verification script
+// should have the given hash and executing it after the invocation script
+// should leave exactly one true value on the stack. The signatures are
+// checked against the sha256 of the given data.
+func (bc *Blockchain) verifyHashAgainstScript(hash util.Uint160, witness *transaction.Witness, data []byte) error {
+	if witness == nil {
+		return errors.New("no witness")
+	}
+	scriptHash, err := util.Uint160FromScript(witness.VerificationScript)
+	if err != nil {
+		return err
+	}
+	if !hash.Equals(scriptHash) {
+		return fmt.Errorf("verification script hash %s doesn't match %s", scriptHash, hash)
+	}
+
+	checkedHash := sha256.Sum256(data)
+	v := vm.New(vm.ModeMute)
+	v.SetCheckedHash(checkedHash[:])
+	v.LoadScript(witness.VerificationScript)
+	v.LoadScript(witness.InvocationScript)
+	v.Run()
+	if v.HasFailed() {
+		return errors.New("witness execution failed")
+	}
+	if v.Estack().Len() != 1 {
+		return fmt.Errorf("expected exactly one item on the stack after witness execution, got %d", v.Estack().Len())
+	}
+	if ok, err := popBool(v.Estack()); err != nil || !ok {
+		return errors.New("witness verification failed")
+	}
+	return nil
+}
+
+// popBool pops the top element of the given stack as a boolean, returning
+// an error if the element can't be converted to one.
+func popBool(s *vm.Stack) (b bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("can't convert stack item to bool: %v", r)
+		}
+	}()
+	return s.Pop().Bool(), nil
+}
+
 func (bc *Blockchain) headerListLen() (n int) {
 	bc.headersOp <- func(headerList *HeaderHashList) {
 		n = headerList.Len()
@@ -425,8 +863,13 @@ func (bc *Blockchain) headerListLen() (n int) {
 
 // GetTransaction returns a TX and its height by the given hash.
 func (bc *Blockchain) GetTransaction(hash util.Uint256) (*transaction.Transaction, uint32, error) {
-	key := storage.AppendPrefix(storage.DataTransaction, hash.BytesReverse())
-	b, err := bc.Get(key)
+	return getTransaction(bc.Store, hash)
+}
+
+// getTransaction returns the transaction with the given hash in the given
+// store and the height of the block including it.
+func getTransaction(s storage.Store, hash util.Uint256) (*transaction.Transaction, uint32, error) {
+	b, err := s.Get(storage.AppendPrefix(storage.DataTransaction, hash.BytesReverse()))
 	if err != nil {
 		return nil, 0, err
 	}
@@ -444,14 +887,28 @@ func (bc *Blockchain) GetTransaction(hash util.Uint256) (*transaction.Transactio
 	return tx, height, nil
 }
 
-// GetBlock returns a Block by the given hash.
-func (bc *Blockchain) GetBlock(hash util.Uint256) (*Block, error) {
-	key := storage.AppendPrefix(storage.DataBlock, hash.BytesReverse())
-	b, err := bc.Get(key)
+// GetTransactionHeight returns the height of the block including the
+// transaction with the given hash without decoding the transaction.
+func (bc *Blockchain) GetTransactionHeight(hash util.Uint256) (uint32, error) {
+	return getTransactionHeight(bc.Store, hash)
+}
+
+// getTransactionHeight returns the height of the block including the
+// transaction with the given hash in the given store.
+func getTransactionHeight(s storage.Store, hash util.Uint256) (uint32, error) {
+	b, err := s.Get(storage.AppendPrefix(storage.DataTransaction, hash.BytesReverse()))
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	if len(b) < 4 {
+		return 0, fmt.Errorf("invalid transaction data for %s", hash)
 	}
-	block, err := NewBlockFromTrimmedBytes(b)
+	return binary.LittleEndian.Uint32(b[:4]), nil
+}
+
+// GetBlock returns a Block by the given hash.
+func (bc *Blockchain) GetBlock(hash util.Uint256) (*Block, error) {
+	block, _, err := bc.getBlockAndSysFee(hash)
 	if err != nil {
 		return nil, err
 	}
@@ -463,21 +920,629 @@ func (bc *Blockchain) GetBlock(hash util.Uint256) (*Block, error) {
 }
 
 func (bc *Blockchain) getHeader(hash util.Uint256) (*Header, error) {
+	block, _, err := bc.getBlockAndSysFee(hash)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+// getBlockAndSysFee returns the trimmed block stored under the given hash
+// together with the total system fee, in whole GAS, of the blocks up to it.
+func (bc *Blockchain) getBlockAndSysFee(hash util.Uint256) (*Block, uint32, error) {
 	b, err := bc.Get(storage.AppendPrefix(storage.DataBlock, hash.BytesReverse()))
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(b) < 4 {
+		return nil, 0, fmt.Errorf("invalid block data for %s", hash)
+	}
+	block, err := NewBlockFromTrimmedBytes(b[4:])
+	if err != nil {
+		return nil, 0, err
+	}
+	return block, binary.LittleEndian.Uint32(b[:4]), nil
+}
+
+// GetSysFeeAmount returns the total system fee, in whole GAS, of the blocks
+// up to and including the one with the given hash, 0 if there is no such
+// block.
+func (bc *Blockchain) GetSysFeeAmount(hash util.Uint256) uint32 {
+	_, sysFee, err := bc.getBlockAndSysFee(hash)
+	if err != nil {
+		return 0
+	}
+	return sysFee
+}
+
+// GetUnspentCoinState returns the unspent coin state of the outputs of the
+// transaction with the given hash or nil if there is no such state.
+func (bc *Blockchain) GetUnspentCoinState(hash util.Uint256) *UnspentCoinState {
+	unspent, err := getUnspentCoinState(bc.Store, hash)
+	if err != nil {
+		return nil
+	}
+	return unspent
+}
+
+// IsDoubleSpend returns true if at least one of the inputs of the given TX
+// refers to an output that doesn't exist or is already spent.
+func (bc *Blockchain) IsDoubleSpend(t *transaction.Transaction) bool {
+	for prevHash, inputs := range t.GroupInputsByPrevHash() {
+		unspent := bc.GetUnspentCoinState(prevHash)
+		if unspent == nil {
+			return true
+		}
+		for _, input := range inputs {
+			if int(input.PrevIndex) >= len(unspent.states) || unspent.states[input.PrevIndex]&CoinStateSpent != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetAssetState returns the state of the asset with the given ID or nil if
+// there is no such asset.
+func (bc *Blockchain) GetAssetState(assetID util.Uint256) *AssetState {
+	b, err := bc.Get(storage.AppendPrefix(storage.STAsset, assetID.Bytes()))
+	if err != nil {
+		return nil
+	}
+	asset := &AssetState{}
+	if err := asset.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil
+	}
+	return asset
+}
+
+// GetAccountState returns the state of the account with the given script
+// hash or nil if there is no such account.
+func (bc *Blockchain) GetAccountState(scriptHash util.Uint160) *AccountState {
+	b, err := bc.Get(storage.AppendPrefix(storage.STAccount, scriptHash.Bytes()))
+	if err != nil {
+		return nil
+	}
+	account := &AccountState{}
+	if err := account.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil
+	}
+	return account
+}
+
+// GetContractState returns the state of the contract with the given script
+// hash or nil if there is no such contract.
+func (bc *Blockchain) GetContractState(hash util.Uint160) *ContractState {
+	return getContractState(bc.Store, hash)
+}
+
+// GetStorageItem returns the item stored by the given contract under the
+// given key or nil if there is no such item.
+func (bc *Blockchain) GetStorageItem(scriptHash util.Uint160, key []byte) *StorageItem {
+	return getStorageItem(bc.Store, scriptHash, key)
+}
+
+// GetUnspent returns the output of the transaction with the given hash at the
+// given index or nil if there is no such output or it's already spent.
+func (bc *Blockchain) GetUnspent(hash util.Uint256, index uint16) *transaction.Output {
+	unspent := bc.GetUnspentCoinState(hash)
+	if unspent == nil || int(index) >= len(unspent.states) || unspent.states[index]&CoinStateSpent != 0 {
+		return nil
+	}
+	tx, _, err := bc.GetTransaction(hash)
+	if err != nil || int(index) >= len(tx.Outputs) {
+		return nil
+	}
+	return tx.Outputs[index]
+}
+
+// References returns the outputs referenced by the inputs of the given
+// transaction.
+func (bc *Blockchain) References(t *transaction.Transaction) (map[transaction.Input]*transaction.Output, error) {
+	return getReferences(bc.Store, t)
+}
+
+// getReferences returns the outputs referenced by the inputs of the given
+// transaction in the given store.
+func getReferences(s storage.Store, t *transaction.Transaction) (map[transaction.Input]*transaction.Output, error) {
+	references := make(map[transaction.Input]*transaction.Output, len(t.Inputs))
+	for prevHash, inputs := range t.GroupInputsByPrevHash() {
+		prevTX, _, err := getTransaction(s, prevHash)
+		if err != nil {
+			return nil, fmt.Errorf("could not find previous TX %s", prevHash)
+		}
+		for _, input := range inputs {
+			if int(input.PrevIndex) >= len(prevTX.Outputs) {
+				return nil, fmt.Errorf("previous TX %s has no output %d", prevHash, input.PrevIndex)
+			}
+			references[*input] = prevTX.Outputs[input.PrevIndex]
+		}
+	}
+	return references, nil
+}
+
+// GetTransactionResults returns the amount of every asset that is
+// destroyed (positive) or created (negative) by the given transaction,
+// that is the sum of its referenced outputs minus the sum of its outputs.
+// Assets that are fully transferred are not included in the result.
+func (bc *Blockchain) GetTransactionResults(t *transaction.Transaction) (map[util.Uint256]util.Fixed8, error) {
+	return getTransactionResults(bc.Store, t)
+}
+
+// getTransactionResults returns the results of the given transaction, its
+// references being read from the given store.
+func getTransactionResults(s storage.Store, t *transaction.Transaction) (map[util.Uint256]util.Fixed8, error) {
+	references, err := getReferences(s, t)
 	if err != nil {
 		return nil, err
 	}
-	block, err := NewBlockFromTrimmedBytes(b)
+	results := make(map[util.Uint256]util.Fixed8)
+	for _, output := range references {
+		results[output.AssetID] += output.Amount
+	}
+	for _, output := range t.Outputs {
+		results[output.AssetID] -= output.Amount
+	}
+	for assetID, amount := range results {
+		if amount == 0 {
+			delete(results, assetID)
+		}
+	}
+	return results, nil
+}
+
+// SystemFee returns the system fee of the given transaction as defined
+// by the protocol configuration.
+func (bc *Blockchain) SystemFee(t *transaction.Transaction) util.Fixed8 {
+	fees := bc.config.SystemFee
+	switch data := t.Data.(type) {
+	case *transaction.EnrollmentTX:
+		return util.NewFixed8(int(fees.EnrollmentTransaction))
+	case *transaction.IssueTX:
+		if t.Version >= 1 {
+			return 0
+		}
+		// Issuing the system assets is free.
+		for _, output := range t.Outputs {
+			if !output.AssetID.Equals(governingTokenTX().Hash()) &&
+				!output.AssetID.Equals(utilityTokenTX().Hash()) {
+				return util.NewFixed8(int(fees.IssueTransaction))
+			}
+		}
+		return 0
+	case *transaction.PublishTX:
+		return util.NewFixed8(int(fees.PublishTransaction))
+	case *transaction.RegisterTX:
+		if data.AssetType == transaction.GoverningToken ||
+			data.AssetType == transaction.UtilityToken {
+			return 0
+		}
+		return util.NewFixed8(int(fees.RegisterTransaction))
+	case *transaction.InvocationTX:
+		return data.Gas
+	case *transaction.StateTX:
+		// Registering a validator costs as much as an enrollment.
+		var fee util.Fixed8
+		for _, desc := range data.Descriptors {
+			if registersValidator(desc) {
+				fee += util.NewFixed8(int(fees.EnrollmentTransaction))
+			}
+		}
+		return fee
+	}
+	return 0
+}
+
+// NetworkFee returns the network fee of the given transaction, that is the
+// amount of GAS it destroys on top of its system fee.
+func (bc *Blockchain) NetworkFee(t *transaction.Transaction) util.Fixed8 {
+	results, err := bc.GetTransactionResults(t)
+	if err != nil {
+		return 0
+	}
+	return results[utilityTokenTX().Hash()] - bc.SystemFee(t)
+}
+
+// VerifyTx verifies the given transaction against the current state of the
+// chain. If block is not nil, the transaction is verified as a part of it and
+// should not conflict with the other transactions of the block.
+func (bc *Blockchain) VerifyTx(t *transaction.Transaction, block *Block) error {
+	buf := new(bytes.Buffer)
+	if err := t.EncodeBinary(buf); err != nil {
+		return err
+	}
+	if buf.Len() > maxTransactionSize {
+		return fmt.Errorf("transaction is too big (%d bytes)", buf.Len())
+	}
+	if err := verifyTxAttributes(t); err != nil {
+		return err
+	}
+	if err := verifyTxInputs(t, block); err != nil {
+		return err
+	}
+	if bc.IsDoubleSpend(t) {
+		return errors.New("transaction is a double spend")
+	}
+	if err := bc.verifyTxOutputs(t); err != nil {
+		return err
+	}
+	if err := bc.verifyResults(t); err != nil {
+		return err
+	}
+
+	switch data := t.Data.(type) {
+	case *transaction.MinerTX:
+		if block == nil {
+			return errors.New("miner transactions are only valid in blocks")
+		}
+	case *transaction.RegisterTX, *transaction.PublishTX, *transaction.EnrollmentTX:
+		return fmt.Errorf("%s is deprecated", t.Type)
+	case *transaction.ClaimTX:
+		if err := bc.verifyClaims(t, data, block); err != nil {
+			return err
+		}
+	case *transaction.IssueTX:
+		if err := bc.verifyIssue(t, block); err != nil {
+			return err
+		}
+	case *transaction.StateTX:
+		if err := bc.verifyStateTX(data); err != nil {
+			return err
+		}
+	case *transaction.InvocationTX:
+		if data.Gas < 0 || data.Gas%util.NewFixed8(1) != 0 {
+			return fmt.Errorf("invalid gas amount %s", data.Gas)
+		}
+	}
+
+	return bc.verifyTxWitnesses(t)
+}
+
+// GetMemPool returns the memory pool of the blockchain.
+func (bc *Blockchain) GetMemPool() *MemPool {
+	return bc.memPool
+}
+
+// GetAppExecResult returns the result of the execution of the script of the
+// invocation transaction with the given hash.
+func (bc *Blockchain) GetAppExecResult(hash util.Uint256) (*AppExecResult, error) {
+	b, err := bc.Get(storage.AppendPrefix(storage.STNotification, hash.BytesReverse()))
 	if err != nil {
 		return nil, err
 	}
-	return block.Header(), nil
+	aer := &AppExecResult{}
+	if err := aer.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	return aer, nil
 }
 
-// HasBlock return true if the blockchain contains he given
+// GetTestVM returns a VM bound to the current state of the chain, limited to
+// the free GAS. The changes made by the scripts it runs are never persisted.
+func (bc *Blockchain) GetTestVM() *vm.VM {
+	v := newInteropContext(TriggerApplication, bc, nil, nil).newVM()
+	v.SetGasLimit(freeGas)
+	return v
+}
+
+// PoolTx verifies the given transaction and adds it to the memory pool.
+func (bc *Blockchain) PoolTx(t *transaction.Transaction) error {
+	hash := t.Hash()
+	if bc.HasTransaction(hash) || bc.memPool.ContainsKey(hash) {
+		return ErrAlreadyExists
+	}
+	if err := bc.VerifyTx(t, nil); err != nil {
+		return err
+	}
+	if !bc.memPool.Verify(t) {
+		return ErrConflict
+	}
+
+	buf := new(bytes.Buffer)
+	if err := t.EncodeBinary(buf); err != nil {
+		return err
+	}
+	return bc.memPool.TryAdd(NewPoolItem(t, bc.NetworkFee(t), buf.Len()))
+}
+
+// verifyTxAttributes checks the number and the content of the attributes
+// of the given transaction.
+func verifyTxAttributes(t *transaction.Transaction) error {
+	if len(t.Attributes) > maxTransactionAttributes {
+		return fmt.Errorf("too many attributes: %d", len(t.Attributes))
+	}
+	ecdh := 0
+	for _, attr := range t.Attributes {
+		switch attr.Usage {
+		case transaction.ECDH02, transaction.ECDH03:
+			ecdh++
+		case transaction.Script:
+			if len(attr.Data) != 20 {
+				return errors.New("invalid script attribute")
+			}
+		}
+	}
+	if ecdh > 1 {
+		return errors.New("more than one ECDH attribute")
+	}
+	return nil
+}
+
+// verifyTxInputs checks that the inputs of the given transaction are unique
+// and don't conflict with the other transactions of the given block.
+func verifyTxInputs(t *transaction.Transaction, block *Block) error {
+	inputs := make(map[transaction.Input]bool, len(t.Inputs))
+	for _, input := range t.Inputs {
+		if inputs[*input] {
+			return fmt.Errorf("duplicate input %s:%d", input.PrevHash, input.PrevIndex)
+		}
+		inputs[*input] = true
+	}
+	if block == nil {
+		return nil
+	}
+	for _, tx := range block.Transactions {
+		if tx.Hash().Equals(t.Hash()) {
+			continue
+		}
+		for _, input := range tx.Inputs {
+			if inputs[*input] {
+				return fmt.Errorf("input %s:%d is also spent by %s", input.PrevHash, input.PrevIndex, tx.Hash())
+			}
+		}
+	}
+	return nil
+}
+
+// verifyTxOutputs checks that the outputs of the given transaction are
+// positive amounts of existing, not expired assets with the precision of
+// the asset.
+func (bc *Blockchain) verifyTxOutputs(t *transaction.Transaction) error {
+	for _, output := range t.Outputs {
+		if output.Amount <= 0 {
+			return fmt.Errorf("output amount %s is not positive", output.Amount)
+		}
+		asset := bc.GetAssetState(output.AssetID)
+		if asset == nil {
+			return fmt.Errorf("unknown asset %s", output.AssetID)
+		}
+		if asset.Expiration <= bc.BlockHeight()+1 &&
+			asset.AssetType != transaction.GoverningToken &&
+			asset.AssetType != transaction.UtilityToken {
+			return fmt.Errorf("asset %s is expired", output.AssetID)
+		}
+		if asset.Precision > maxAssetPrecision {
+			return fmt.Errorf("asset %s has an invalid precision %d", output.AssetID, asset.Precision)
+		}
+		if int64(output.Amount)%int64(math.Pow10(maxAssetPrecision-int(asset.Precision))) != 0 {
+			return fmt.Errorf("output amount %s doesn't match the precision of asset %s", output.Amount, output.AssetID)
+		}
+	}
+	return nil
+}
+
+// verifyResults checks that the assets are conserved by the given
+// transaction: only the utility token can be destroyed, to pay the system
+// fee, and assets can only be created by the transaction types that are
+// allowed to.
+func (bc *Blockchain) verifyResults(t *transaction.Transaction) error {
+	results, err := bc.GetTransactionResults(t)
+	if err != nil {
+		return err
+	}
+
+	utilityToken := utilityTokenTX().Hash()
+	var destroyed util.Fixed8
+	for assetID, amount := range results {
+		if amount > 0 {
+			if !assetID.Equals(utilityToken) {
+				return fmt.Errorf("asset %s is destroyed", assetID)
+			}
+			destroyed = amount
+		}
+		if amount < 0 {
+			switch t.Type {
+			case transaction.MinerType, transaction.ClaimType:
+				if !assetID.Equals(utilityToken) {
+					return fmt.Errorf("asset %s can't be issued by %s", assetID, t.Type)
+				}
+			case transaction.IssueType:
+				if assetID.Equals(utilityToken) {
+					return fmt.Errorf("asset %s can't be issued by %s", assetID, t.Type)
+				}
+			default:
+				return fmt.Errorf("asset %s can't be issued by %s", assetID, t.Type)
+			}
+		}
+	}
+	if sysFee := bc.SystemFee(t); destroyed < sysFee {
+		return fmt.Errorf("insufficient system fee: %s, required %s", destroyed, sysFee)
+	}
+	return nil
+}
+
+// verifyIssue checks that the given issue transaction doesn't issue more than
+// the remaining amount of the assets it creates, taking into account the
+// other issue transactions of the block or, if block is nil, of the memory
+// pool.
+func (bc *Blockchain) verifyIssue(t *transaction.Transaction, block *Block) error {
+	results, err := bc.GetTransactionResults(t)
+	if err != nil {
+		return err
+	}
+	others := bc.memPool.GetVerifiedTransactions()
+	if block != nil {
+		others = block.Transactions
+	}
+	for assetID, amount := range results {
+		if amount >= 0 {
+			continue
+		}
+		asset := bc.GetAssetState(assetID)
+		if asset == nil {
+			return fmt.Errorf("unknown asset %s", assetID)
+		}
+		// Assets with a negative amount can be issued without limit.
+		if asset.Amount < 0 {
+			continue
+		}
+		issued := asset.Available
+		for _, tx := range others {
+			if tx.Type != transaction.IssueType || tx.Hash().Equals(t.Hash()) {
+				continue
+			}
+			for _, output := range tx.Outputs {
+				if output.AssetID.Equals(assetID) {
+					issued += output.Amount
+				}
+			}
+		}
+		if asset.Amount-issued < -amount {
+			return fmt.Errorf("issuing %s of asset %s exceeds its remaining amount %s", -amount, assetID, asset.Amount-issued)
+		}
+	}
+	return nil
+}
+
+// GetScriptHashesForVerifying returns the sorted script hashes whose
+// witnesses are needed for the given transaction.
+func (bc *Blockchain) GetScriptHashesForVerifying(t *transaction.Transaction) ([]util.Uint160, error) {
+	references, err := bc.References(t)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[util.Uint160]bool)
+	for _, output := range references {
+		hashes[output.ScriptHash] = true
+	}
+	for _, attr := range t.Attributes {
+		if attr.Usage == transaction.Script {
+			h, err := util.Uint160DecodeBytes(attr.Data)
+			if err != nil {
+				return nil, err
+			}
+			hashes[h] = true
+		}
+	}
+	for _, output := range t.Outputs {
+		asset := bc.GetAssetState(output.AssetID)
+		if asset == nil {
+			return nil, fmt.Errorf("unknown asset %s", output.AssetID)
+		}
+		if asset.AssetType&transaction.DutyFlag != 0 {
+			hashes[output.ScriptHash] = true
+		}
+	}
+
+	switch data := t.Data.(type) {
+	case *transaction.ClaimTX:
+		for _, claim := range data.Claims {
+			prevTX, _, err := bc.GetTransaction(claim.PrevHash)
+			if err != nil {
+				return nil, fmt.Errorf("could not find claimed TX %s", claim.PrevHash)
+			}
+			if int(claim.PrevIndex) >= len(prevTX.Outputs) {
+				return nil, fmt.Errorf("claimed TX %s has no output %d", claim.PrevHash, claim.PrevIndex)
+			}
+			hashes[prevTX.Outputs[claim.PrevIndex].ScriptHash] = true
+		}
+	case *transaction.IssueTX:
+		results, err := bc.GetTransactionResults(t)
+		if err != nil {
+			return nil, err
+		}
+		for assetID, amount := range results {
+			if amount < 0 {
+				asset := bc.GetAssetState(assetID)
+				if asset == nil {
+					return nil, fmt.Errorf("unknown asset %s", assetID)
+				}
+				hashes[asset.Issuer] = true
+			}
+		}
+	case *transaction.RegisterTX:
+		h, err := signatureContractHash(data.Owner)
+		if err != nil {
+			return nil, err
+		}
+		hashes[h] = true
+	case *transaction.EnrollmentTX:
+		h, err := signatureContractHash(data.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		hashes[h] = true
+	case *transaction.StateTX:
+		for _, desc := range data.Descriptors {
+			switch desc.Type {
+			case transaction.Account:
+				h, err := util.Uint160DecodeBytes(desc.Key)
+				if err != nil {
+					return nil, err
+				}
+				hashes[h] = true
+			case transaction.Validator:
+				key := &crypto.PublicKey{}
+				if err := key.DecodeBytes(desc.Key); err != nil {
+					return nil, err
+				}
+				h, err := signatureContractHash(key)
+				if err != nil {
+					return nil, err
+				}
+				hashes[h] = true
+			}
+		}
+	}
+
+	result := make([]util.Uint160, 0, len(hashes))
+	for h := range hashes {
+		result = append(result, h)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].BytesReverse(), result[j].BytesReverse()) < 0
+	})
+	return result, nil
+}
+
+// signatureContractHash returns the script hash of the signature contract
+// of the given public key.
+func signatureContractHash(key *crypto.PublicKey) (util.Uint160, error) {
+	script, err := smartcontract.CreateSignatureRedeemScript(key)
+	if err != nil {
+		return util.Uint160{}, err
+	}
+	return util.Uint160FromScript(script)
+}
+
+// verifyTxWitnesses checks that the given transaction has a valid witness
+// for every script hash that needs to be verified.
+func (bc *Blockchain) verifyTxWitnesses(t *transaction.Transaction) error {
+	hashes, err := bc.GetScriptHashesForVerifying(t)
+	if err != nil {
+		return err
+	}
+	if len(hashes) != len(t.Scripts) {
+		return fmt.Errorf("expected %d witnesses, got %d", len(hashes), len(t.Scripts))
+	}
+	data, err := t.GetHashableData()
+	if err != nil {
+		return err
+	}
+	for i, h := range hashes {
+		if err := bc.verifyHashAgainstScript(h, t.Scripts[i], data); err != nil {
+			return fmt.Errorf("witness %d is invalid: %s", i, err)
+		}
+	}
+	return nil
+}
+
+// HasTransaction return true if the blockchain contains he given
 // transaction hash.
 func (bc *Blockchain) HasTransaction(hash util.Uint256) bool {
-	return false
+	key := storage.AppendPrefix(storage.DataTransaction, hash.BytesReverse())
+	_, err := bc.Get(key)
+	return err == nil
 }
 
 // HasBlock return true if the blockchain contains the given
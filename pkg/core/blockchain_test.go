@@ -1,10 +1,15 @@
 package core
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/CityOfZion/neo-go/config"
 	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -141,6 +146,456 @@ func TestGetTransaction(t *testing.T) {
 	}
 	assert.Equal(t, block.Index, height)
 	assert.Equal(t, block.Transactions[0], tx)
+
+	height, err = bc.GetTransactionHeight(block.Transactions[0].Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, block.Index, height)
+	_, err = bc.GetTransactionHeight(util.Uint256{1, 2, 3})
+	assert.NotNil(t, err)
+}
+
+func TestRestoreGenesisOnly(t *testing.T) {
+	cfg, err := config.Load("../../config", config.ModePrivNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := storage.NewMemoryStore()
+	bc, err := NewBlockchain(store, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewBlockchain(store, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(0), restored.BlockHeight())
+	assert.Equal(t, uint32(0), restored.HeaderHeight())
+	assert.Equal(t, bc.CurrentBlockHash(), restored.CurrentHeaderHash())
+}
+
+func TestVerifyHashAgainstScript(t *testing.T) {
+	bc := newTestChain(t)
+	block1 := getDecodedBlock(t, 1)
+	block2 := getDecodedBlock(t, 2)
+
+	data, err := block2.GetHashableData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, bc.verifyHashAgainstScript(block1.NextConsensus, block2.Script, data))
+
+	// Wrong script hash.
+	assert.NotNil(t, bc.verifyHashAgainstScript(util.Uint160{}, block2.Script, data))
+
+	// Signatures don't match the data.
+	data[0]++
+	assert.NotNil(t, bc.verifyHashAgainstScript(block1.NextConsensus, block2.Script, data))
+}
+
+func TestAddVerifiedBlocks(t *testing.T) {
+	bc := newVerifyingTestChain(t)
+	prev, err := bc.GetBlock(bc.CurrentBlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := make([]*Block, 3)
+	for i := range blocks {
+		blocks[i] = newSignedBlock(t, bc, prev.Header())
+		prev = blocks[i]
+		assert.Nil(t, bc.AddBlock(blocks[i]))
+	}
+	assert.Nil(t, bc.persist())
+	assert.Equal(t, uint32(3), bc.BlockHeight())
+	assert.Equal(t, blocks[2].Hash(), bc.CurrentBlockHash())
+}
+
+func TestAddInvalidBlocks(t *testing.T) {
+	bc := newVerifyingTestChain(t)
+	genesis, err := bc.GetBlock(bc.CurrentBlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Not signed by the validators.
+	block := newSignedBlock(t, bc, genesis.Header())
+	block.Script.InvocationScript = []byte{byte(vm.Opusht)}
+	assert.NotNil(t, bc.AddBlock(block))
+
+	// Doesn't follow the previous block.
+	block = newSignedBlock(t, bc, genesis.Header())
+	block = newSignedBlock(t, bc, block.Header())
+	block.Index = 1
+	assert.NotNil(t, bc.AddHeaders(block.Header()))
+
+	// Wrong merkle root.
+	block = newSignedBlock(t, bc, genesis.Header())
+	block.Transactions = append(block.Transactions, newTX(transaction.ContractType))
+	assert.NotNil(t, bc.AddBlock(block))
+
+	// Wrong next consensus.
+	block = newSignedBlock(t, bc, genesis.Header())
+	block.NextConsensus = util.Uint160{}
+	block.createHash()
+	data, err := block.GetHashableData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Script = signWithValidators(t, data)
+	assert.Nil(t, bc.AddBlock(block))
+	assert.NotNil(t, bc.persist())
+	assert.Equal(t, uint32(0), bc.BlockHeight())
+}
+
+func TestPersistAfterInvalidBlock(t *testing.T) {
+	bc := newVerifyingTestChain(t)
+	genesis, err := bc.GetBlock(bc.CurrentBlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The header is valid but not the block, which has a wrong next consensus.
+	invalid := newSignedBlock(t, bc, genesis.Header())
+	invalid.NextConsensus = util.Uint160{}
+	invalid.createHash()
+	data, err := invalid.GetHashableData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid.Script = signWithValidators(t, data)
+	assert.Nil(t, bc.AddBlock(invalid))
+	assert.Equal(t, uint32(1), bc.HeaderHeight())
+	assert.NotNil(t, bc.persist())
+	assert.Equal(t, uint32(0), bc.HeaderHeight())
+	assert.Equal(t, genesis.Hash(), bc.CurrentHeaderHash())
+	_, err = bc.getHeader(invalid.Hash())
+	assert.NotNil(t, err)
+
+	value, err := bc.Get(storage.SYSCurrentHeader.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, hashAndIndexToBytes(genesis.Hash(), 0), value)
+
+	block := newSignedBlock(t, bc, genesis.Header())
+	assert.Nil(t, bc.AddBlock(block))
+	assert.Nil(t, bc.persist())
+	assert.Equal(t, uint32(1), bc.BlockHeight())
+	assert.Equal(t, block.Hash(), bc.CurrentBlockHash())
+}
+
+func TestIsDoubleSpend(t *testing.T) {
+	bc := newTestChain(t)
+	genesis, err := createGenesisBlock(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueTX := genesis.Transactions[3]
+
+	tx := newTX(transaction.ContractType)
+	tx.Inputs = []*transaction.Input{{PrevHash: issueTX.Hash(), PrevIndex: 0}}
+	assert.False(t, bc.IsDoubleSpend(tx))
+
+	tx.Inputs[0].PrevIndex = 1
+	assert.True(t, bc.IsDoubleSpend(tx))
+
+	tx.Inputs[0] = &transaction.Input{PrevHash: util.Uint256{1, 2, 3}}
+	assert.True(t, bc.IsDoubleSpend(tx))
+}
+
+func TestVerifyTx(t *testing.T) {
+	bc := newTestChain(t)
+	genesis, err := createGenesisBlock(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueTX := genesis.Transactions[3]
+	neo := governingTokenTX().Hash()
+	amount := issueTX.Outputs[0].Amount
+	owner := issueTX.Outputs[0].ScriptHash
+
+	newContractTX := func(outputs ...*transaction.Output) *transaction.Transaction {
+		tx := &transaction.Transaction{
+			Type:       transaction.ContractType,
+			Data:       &transaction.ContractTX{},
+			Attributes: []*transaction.Attribute{},
+			Inputs:     []*transaction.Input{{PrevHash: issueTX.Hash(), PrevIndex: 0}},
+			Outputs:    outputs,
+		}
+		data, err := tx.GetHashableData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.Scripts = []*transaction.Witness{signWithValidators(t, data)}
+		return tx
+	}
+
+	tx := newContractTX(transaction.NewOutput(neo, amount, util.Uint160{1, 2, 3}))
+	assert.Nil(t, bc.VerifyTx(tx, nil))
+
+	// Change is fine too.
+	tx = newContractTX(
+		transaction.NewOutput(neo, util.NewFixed8(1), util.Uint160{1, 2, 3}),
+		transaction.NewOutput(neo, amount-util.NewFixed8(1), owner),
+	)
+	assert.Nil(t, bc.VerifyTx(tx, nil))
+
+	// NEO can't be destroyed.
+	tx = newContractTX(transaction.NewOutput(neo, util.NewFixed8(1), util.Uint160{1, 2, 3}))
+	assert.NotNil(t, bc.VerifyTx(tx, nil))
+
+	// NEO can't be issued by a contract transaction.
+	tx = newContractTX(transaction.NewOutput(neo, amount+util.NewFixed8(1), util.Uint160{1, 2, 3}))
+	assert.NotNil(t, bc.VerifyTx(tx, nil))
+
+	// NEO is indivisible.
+	tx = newContractTX(
+		transaction.NewOutput(neo, util.Fixed8(1), util.Uint160{1, 2, 3}),
+		transaction.NewOutput(neo, amount-util.Fixed8(1), owner),
+	)
+	assert.NotNil(t, bc.VerifyTx(tx, nil))
+
+	// Unknown asset.
+	tx = newContractTX(
+		transaction.NewOutput(neo, amount, owner),
+		transaction.NewOutput(util.Uint256{1, 2, 3}, util.NewFixed8(1), owner),
+	)
+	assert.NotNil(t, bc.VerifyTx(tx, nil))
+
+	// Asset with an invalid precision.
+	asset := &AssetState{
+		ID:         util.Uint256{4, 5, 6},
+		AssetType:  transaction.Token,
+		Precision:  maxAssetPrecision + 1,
+		Owner:      &crypto.PublicKey{},
+		Expiration: bc.BlockHeight() + 100,
+	}
+	assert.Nil(t, putAssetState(bc.Store, asset))
+	tx = newContractTX(
+		transaction.NewOutput(neo, amount, owner),
+		transaction.NewOutput(asset.ID, util.NewFixed8(1), owner),
+	)
+	assert.NotNil(t, bc.VerifyTx(tx, nil))
+
+	// Duplicate inputs.
+	tx = newContractTX(transaction.NewOutput(neo, amount, owner))
+	tx.Inputs = append(tx.Inputs, tx.Inputs[0])
+	assert.NotNil(t, bc.VerifyTx(tx, nil))
+
+	// Unknown input.
+	tx = newContractTX(transaction.NewOutput(neo, amount, owner))
+	tx.Inputs[0].PrevIndex = 1
+	assert.NotNil(t, bc.VerifyTx(tx, nil))
+
+	// Conflicts with another transaction of the block.
+	tx = newContractTX(transaction.NewOutput(neo, amount, owner))
+	block := newBlock(1, newTX(transaction.MinerType), newContractTX(transaction.NewOutput(neo, amount, util.Uint160{1})), tx)
+	assert.NotNil(t, bc.VerifyTx(tx, block))
+
+	// Too many attributes.
+	tx = newContractTX(transaction.NewOutput(neo, amount, owner))
+	for i := 0; i <= maxTransactionAttributes; i++ {
+		tx.Attributes = append(tx.Attributes, &transaction.Attribute{Usage: transaction.Remark, Data: []byte{byte(i)}})
+	}
+	assert.NotNil(t, bc.VerifyTx(tx, nil))
+
+	// Missing and wrong witnesses.
+	tx = newContractTX(transaction.NewOutput(neo, amount, owner))
+	tx.Scripts = nil
+	assert.NotNil(t, bc.VerifyTx(tx, nil))
+	tx = newContractTX(transaction.NewOutput(neo, amount, owner))
+	tx.Outputs[0].ScriptHash = util.Uint160{1, 2, 3}
+	assert.NotNil(t, bc.VerifyTx(tx, nil))
+}
+
+func TestPoolTx(t *testing.T) {
+	bc := newVerifyingTestChain(t)
+	genesis, err := createGenesisBlock(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueTX := genesis.Transactions[3]
+	neo := governingTokenTX().Hash()
+	amount := issueTX.Outputs[0].Amount
+
+	newContractTX := func(to util.Uint160) *transaction.Transaction {
+		tx := &transaction.Transaction{
+			Type:       transaction.ContractType,
+			Data:       &transaction.ContractTX{},
+			Attributes: []*transaction.Attribute{},
+			Inputs:     []*transaction.Input{{PrevHash: issueTX.Hash(), PrevIndex: 0}},
+			Outputs:    []*transaction.Output{transaction.NewOutput(neo, amount, to)},
+		}
+		data, err := tx.GetHashableData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.Scripts = []*transaction.Witness{signWithValidators(t, data)}
+		return tx
+	}
+
+	tx1 := newContractTX(util.Uint160{1})
+	tx2 := newContractTX(util.Uint160{2})
+	assert.Nil(t, bc.PoolTx(tx1))
+	assert.Equal(t, ErrAlreadyExists, bc.PoolTx(tx1))
+	assert.Equal(t, ErrConflict, bc.PoolTx(tx2))
+	assert.Equal(t, 1, bc.GetMemPool().Count())
+
+	// Invalid transactions never reach the pool.
+	tx3 := newContractTX(util.Uint160{3})
+	tx3.Scripts = nil
+	assert.NotNil(t, bc.PoolTx(tx3))
+	assert.False(t, bc.GetMemPool().ContainsKey(tx3.Hash()))
+
+	// A block spending the same input makes the pooled transaction stale.
+	block := newSignedBlock(t, bc, genesis.Header(), tx2)
+	assert.Nil(t, bc.AddBlock(block))
+	assert.Nil(t, bc.persist())
+	assert.Equal(t, uint32(1), bc.BlockHeight())
+	assert.Equal(t, 0, bc.GetMemPool().Count())
+	assert.True(t, bc.HasTransaction(tx2.Hash()))
+	assert.NotNil(t, bc.PoolTx(tx2))
+	assert.NotNil(t, bc.PoolTx(tx1))
+}
+
+func TestSystemFee(t *testing.T) {
+	bc := newTestChain(t)
+	fees := bc.config.SystemFee
+
+	assert.Equal(t, util.Fixed8(0), bc.SystemFee(newTX(transaction.ContractType)))
+
+	tx := &transaction.Transaction{Type: transaction.EnrollmentType, Data: &transaction.EnrollmentTX{}}
+	assert.Equal(t, util.NewFixed8(int(fees.EnrollmentTransaction)), bc.SystemFee(tx))
+
+	tx = &transaction.Transaction{
+		Type:    transaction.IssueType,
+		Data:    &transaction.IssueTX{},
+		Outputs: []*transaction.Output{{AssetID: utilityTokenTX().Hash()}},
+	}
+	assert.Equal(t, util.Fixed8(0), bc.SystemFee(tx))
+	tx.Outputs = append(tx.Outputs, &transaction.Output{AssetID: util.Uint256{1, 2, 3}})
+	assert.Equal(t, util.NewFixed8(int(fees.IssueTransaction)), bc.SystemFee(tx))
+
+	tx = &transaction.Transaction{Type: transaction.RegisterType, Data: &transaction.RegisterTX{AssetType: transaction.Token}}
+	assert.Equal(t, util.NewFixed8(int(fees.RegisterTransaction)), bc.SystemFee(tx))
+
+	tx = transaction.NewInvocationTX([]byte{byte(vm.Opusht)})
+	tx.Data.(*transaction.InvocationTX).Gas = util.NewFixed8(5)
+	assert.Equal(t, util.NewFixed8(5), bc.SystemFee(tx))
+}
+
+// newPublishTX returns a PublishTX of a contract with storage running the
+// given script.
+func newPublishTX(script []byte) *transaction.Transaction {
+	return &transaction.Transaction{
+		Type:    transaction.PublishType,
+		Version: 1,
+		Data: &transaction.PublishTX{
+			Script:      script,
+			NeedStorage: true,
+			Name:        "test",
+		},
+	}
+}
+
+func newInvocationTX(script []byte) *transaction.Transaction {
+	return &transaction.Transaction{
+		Type: transaction.InvocationType,
+		Data: &transaction.InvocationTX{Script: script},
+	}
+}
+
+func TestPersistInvocation(t *testing.T) {
+	bc := newTestChain(t)
+
+	contract := new(bytes.Buffer)
+	vm.EmitString(contract, "value")
+	vm.EmitString(contract, "key")
+	vm.EmitSyscall(contract, "Neo.Storage.GetContext")
+	vm.EmitSyscall(contract, "Neo.Storage.Put")
+	hash, err := util.Uint160FromScript(contract.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	invocation := new(bytes.Buffer)
+	vm.EmitAppCall(invocation, hash, false)
+
+	block := newBlock(1, newPublishTX(contract.Bytes()), newInvocationTX(invocation.Bytes()))
+	assert.Nil(t, bc.persistBlock(block))
+
+	cs := bc.GetContractState(hash)
+	if cs == nil {
+		t.Fatal("contract not stored")
+	}
+	assert.Equal(t, contract.Bytes(), cs.Script)
+	assert.True(t, cs.HasStorage)
+	item := bc.GetStorageItem(hash, []byte("key"))
+	if item == nil {
+		t.Fatal("storage item not stored")
+	}
+	assert.Equal(t, []byte("value"), item.Value)
+
+	aer, err := bc.GetAppExecResult(block.Transactions[1].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, TriggerApplication, aer.Trigger)
+	assert.Equal(t, "HALT", aer.VMState)
+}
+
+func TestPersistFailedInvocation(t *testing.T) {
+	bc := newTestChain(t)
+
+	contract := new(bytes.Buffer)
+	vm.EmitString(contract, "value")
+	vm.EmitString(contract, "key")
+	vm.EmitSyscall(contract, "Neo.Storage.GetContext")
+	vm.EmitSyscall(contract, "Neo.Storage.Put")
+	vm.EmitOpcode(contract, vm.Othrow)
+	hash, err := util.Uint160FromScript(contract.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	invocation := new(bytes.Buffer)
+	vm.EmitAppCall(invocation, hash, false)
+
+	block := newBlock(1, newPublishTX(contract.Bytes()), newInvocationTX(invocation.Bytes()))
+	assert.Nil(t, bc.persistBlock(block))
+
+	assert.NotNil(t, bc.GetContractState(hash))
+	assert.Nil(t, bc.GetStorageItem(hash, []byte("key")))
+
+	aer, err := bc.GetAppExecResult(block.Transactions[1].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "FAULT", aer.VMState)
+}
+
+func TestPersistInvocationStorage(t *testing.T) {
+	bc := newTestChain(t)
+
+	contract := new(bytes.Buffer)
+	vm.EmitString(contract, "value")
+	vm.EmitString(contract, "key")
+	vm.EmitSyscall(contract, "Neo.Storage.GetContext")
+	vm.EmitSyscall(contract, "Neo.Storage.Put")
+	hash, err := util.Uint160FromScript(contract.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	invocation := new(bytes.Buffer)
+	vm.EmitAppCall(invocation, hash, false)
+
+	block := newBlock(1, newPublishTX(contract.Bytes()), newInvocationTX(invocation.Bytes()))
+	assert.Nil(t, bc.persistBlock(block))
+
+	item := bc.GetStorageItem(hash, []byte("key"))
+	if item == nil {
+		t.Fatal("no item stored")
+	}
+	assert.Equal(t, []byte("value"), item.Value)
 }
 
 func newTestChain(t *testing.T) *Blockchain {
@@ -148,9 +603,186 @@ func newTestChain(t *testing.T) *Blockchain {
 	if err != nil {
 		t.Fatal(err)
 	}
-	chain, err := NewBlockchain(storage.NewMemoryStore(), cfg.ProtocolConfiguration)
+	// Test blocks are not signed, so we can't verify them.
+	cfg.ProtocolConfiguration.VerifyBlocks = false
+	chain, err := NewBlockchain(storage.NewMemoryStore(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+func newVerifyingTestChain(t *testing.T) *Blockchain {
+	cfg, err := config.Load("../../config", config.ModePrivNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ProtocolConfiguration.VerifyBlocks = true
+	chain, err := NewBlockchain(storage.NewMemoryStore(), cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
 	return chain
 }
+
+func TestGetSysFeeAmount(t *testing.T) {
+	bc := newTestChain(t)
+	assert.Equal(t, uint32(0), bc.GetSysFeeAmount(bc.GetHeaderHash(0)))
+
+	newPaidInvocationTX := func(gas int) *transaction.Transaction {
+		tx := newInvocationTX([]byte{byte(vm.Opusht)})
+		tx.Version = 1
+		tx.Data.(*transaction.InvocationTX).Gas = util.NewFixed8(gas)
+		return tx
+	}
+	block1 := newBlock(1, newPaidInvocationTX(5))
+	assert.Nil(t, bc.persistBlock(block1))
+	assert.Equal(t, uint32(5), bc.GetSysFeeAmount(block1.Hash()))
+
+	// The fees are cumulative.
+	block2 := newBlock(2, newPaidInvocationTX(2), newPaidInvocationTX(1))
+	block2.PrevHash = block1.Hash()
+	if err := block2.createHash(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, bc.persistBlock(block2))
+	assert.Equal(t, uint32(8), bc.GetSysFeeAmount(block2.Hash()))
+
+	// The stored blocks are not affected by the fee prefix.
+	block, err := bc.GetBlock(block2.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, block2.Index, block.Index)
+	assert.Equal(t, 2, len(block.Transactions))
+	assert.Equal(t, uint32(0), bc.GetSysFeeAmount(util.Uint256{1, 2, 3}))
+}
+
+func TestIssue(t *testing.T) {
+	bc := newTestChain(t)
+
+	// The genesis block issues all the governing token.
+	neo := bc.GetAssetState(governingTokenTX().Hash())
+	if assert.NotNil(t, neo) {
+		assert.Equal(t, neo.Amount, neo.Available)
+	}
+	gas := bc.GetAssetState(utilityTokenTX().Hash())
+	if assert.NotNil(t, gas) {
+		assert.Equal(t, util.Fixed8(0), gas.Available)
+	}
+
+	register := &transaction.Transaction{
+		Type: transaction.RegisterType,
+		Data: &transaction.RegisterTX{
+			AssetType: transaction.Token,
+			Name:      "token",
+			Amount:    util.NewFixed8(100),
+			Owner:     &crypto.PublicKey{},
+		},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{},
+		Outputs:    []*transaction.Output{},
+	}
+	assert.Nil(t, bc.persistBlock(newBlock(1, register)))
+	assetID := register.Hash()
+
+	newIssueTX := func(amount int) *transaction.Transaction {
+		return &transaction.Transaction{
+			Type:       transaction.IssueType,
+			Data:       &transaction.IssueTX{},
+			Attributes: []*transaction.Attribute{},
+			Inputs:     []*transaction.Input{},
+			Outputs:    []*transaction.Output{transaction.NewOutput(assetID, util.NewFixed8(amount), util.Uint160{1, 2, 3})},
+		}
+	}
+	assert.NotNil(t, bc.verifyIssue(newIssueTX(101), nil))
+	issue := newIssueTX(60)
+	assert.Nil(t, bc.verifyIssue(issue, nil))
+	assert.Nil(t, bc.persistBlock(newBlock(2, issue)))
+	asset := bc.GetAssetState(assetID)
+	if assert.NotNil(t, asset) {
+		assert.Equal(t, util.NewFixed8(60), asset.Available)
+	}
+	assert.NotNil(t, bc.verifyIssue(newIssueTX(50), nil))
+	assert.Nil(t, bc.verifyIssue(newIssueTX(40), nil))
+
+	// Pooled issues count too, so do the other issues of a block.
+	pooled := newIssueTX(30)
+	assert.Nil(t, bc.memPool.TryAdd(NewPoolItem(pooled, 0, 100)))
+	assert.NotNil(t, bc.verifyIssue(newIssueTX(20), nil))
+	assert.Nil(t, bc.verifyIssue(newIssueTX(10), nil))
+	assert.Nil(t, bc.verifyIssue(newIssueTX(20), newBlock(3)))
+	assert.NotNil(t, bc.verifyIssue(newIssueTX(20), newBlock(3, pooled)))
+}
+
+func TestClose(t *testing.T) {
+	bc := newTestChain(t)
+	ch := make(chan *Block)
+	bc.SubscribeForBlocks(ch)
+	go func() {
+		for range ch {
+		}
+	}()
+
+	for i := uint32(1); i <= 3; i++ {
+		if err := bc.AddBlock(newBlock(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Nil(t, bc.Close())
+
+	// The cached blocks were persisted and the dispatcher stopped.
+	assert.Equal(t, uint32(3), bc.BlockHeight())
+	select {
+	case <-bc.events.done:
+	default:
+		t.Fatal("events are still being dispatched")
+	}
+}
+
+func TestPersistIntraBlockSpend(t *testing.T) {
+	bc := newTestChain(t)
+	genesis, err := createGenesisBlock(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueTX := genesis.Transactions[3]
+	neo := governingTokenTX().Hash()
+	amount := issueTX.Outputs[0].Amount
+	owner := issueTX.Outputs[0].ScriptHash
+	alice, bob := util.Uint160{1, 2, 3}, util.Uint160{3, 2, 1}
+
+	// Every transaction spends the output of the previous one.
+	txs := make([]*transaction.Transaction, 3)
+	prev := issueTX.Hash()
+	for i, to := range []util.Uint160{owner, alice, bob} {
+		txs[i] = &transaction.Transaction{
+			Type:       transaction.ContractType,
+			Data:       &transaction.ContractTX{},
+			Attributes: []*transaction.Attribute{},
+			Inputs:     []*transaction.Input{{PrevHash: prev, PrevIndex: 0}},
+			Outputs:    []*transaction.Output{transaction.NewOutput(neo, amount, to)},
+		}
+		prev = txs[i].Hash()
+	}
+	block := newSignedBlock(t, bc, genesis.Header(), txs...)
+	assert.Nil(t, bc.AddBlock(block))
+	assert.Nil(t, bc.persist())
+	assert.Equal(t, uint32(1), bc.BlockHeight())
+
+	for i, hash := range []util.Uint160{owner, alice} {
+		assert.Nil(t, bc.GetUnspent(txs[i].Hash(), 0), "output of TX %d", i)
+		unspents, err := bc.GetUnspents(hash)
+		assert.Nil(t, err)
+		assert.Empty(t, unspents)
+	}
+	assert.Equal(t, amount, bc.GetUnspent(txs[2].Hash(), 0).Amount)
+	account := bc.GetAccountState(bob)
+	if assert.NotNil(t, account) {
+		assert.Equal(t, amount, account.Balances[neo])
+	}
+	account = bc.GetAccountState(alice)
+	if assert.NotNil(t, account) {
+		assert.Equal(t, util.Fixed8(0), account.Balances[neo])
+	}
+}
@@ -1,6 +1,11 @@
 package core
 
-import "github.com/CityOfZion/neo-go/pkg/util"
+import (
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+)
 
 // Blockchainer is an interface that abstract the implementation
 // of the blockchain.
@@ -15,4 +20,37 @@ type Blockchainer interface {
 	CurrentBlockHash() util.Uint256
 	HasBlock(util.Uint256) bool
 	HasTransaction(util.Uint256) bool
+	GetTransaction(util.Uint256) (*transaction.Transaction, uint32, error)
+	GetTransactionHeight(util.Uint256) (uint32, error)
+	GetAccountState(util.Uint160) *AccountState
+	GetAssetState(util.Uint256) *AssetState
+	GetContractState(util.Uint160) *ContractState
+	GetStorageItem(scriptHash util.Uint160, key []byte) *StorageItem
+	GetUnspent(hash util.Uint256, index uint16) *transaction.Output
+	GetUnspentCoinState(util.Uint256) *UnspentCoinState
+	GetSpentCoinState(util.Uint256) *SpentCoinState
+	GetUnspents(util.Uint160) ([]*UnspentOutput, error)
+	GetAddressHistory(scriptHash util.Uint160, before *AddressTransaction, limit int) ([]*AddressTransaction, error)
+	GetNEP5Balances(util.Uint160) ([]*NEP5Balance, error)
+	GetNEP5Transfers(address util.Uint160, start, end uint32) ([]*NEP5Transfer, error)
+	GetValidators(...*transaction.Transaction) ([]*crypto.PublicKey, error)
+	GetEnrollments() ([]*ValidatorState, error)
+	GetClaimable(util.Uint160) ([]*SpentCoin, error)
+	GetUnavailable(util.Uint160) (util.Fixed8, error)
+	CalculateBonus(*SpentCoin) (generated, sysFee util.Fixed8)
+	SystemFee(*transaction.Transaction) util.Fixed8
+	GetSysFeeAmount(util.Uint256) uint32
+	NetworkFee(*transaction.Transaction) util.Fixed8
+	GetMemPool() *MemPool
+	PoolTx(*transaction.Transaction) error
+	GetAppExecResult(util.Uint256) (*AppExecResult, error)
+	GetTestVM() *vm.VM
+	SubscribeForBlocks(ch chan<- *Block)
+	SubscribeForTransactions(ch chan<- *transaction.Transaction)
+	SubscribeForNotifications(ch chan<- *ContractNotification)
+	SubscribeForExecutions(ch chan<- *AppExecResult)
+	UnsubscribeFromBlocks(ch chan<- *Block)
+	UnsubscribeFromTransactions(ch chan<- *transaction.Transaction)
+	UnsubscribeFromNotifications(ch chan<- *ContractNotification)
+	UnsubscribeFromExecutions(ch chan<- *AppExecResult)
 }
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// Maximum number of restored blocks waiting to be persisted.
+	restoreCacheSize = 2000
+
+	// Maximum time to wait for the next restored block to be persisted.
+	restoreTimeout = 30 * time.Second
+)
+
+// DumpBlocks writes count blocks of the given chain, starting with the one
+// with the given index, to the given writer in the chain.acc format used by
+// the C# node: the number of blocks followed by every block prefixed by its
+// size, both as little-endian uint32. Dumps that don't start with the
+// genesis block are in the chain.{start}.acc format, which begins with the
+// index of their first block. Every block up to the current one is written
+// if count is 0.
+func DumpBlocks(bc Blockchainer, w io.Writer, start, count uint32) error {
+	height := bc.BlockHeight()
+	if start > height {
+		return fmt.Errorf("block %d is above the current height %d", start, height)
+	}
+	if count == 0 {
+		count = height - start + 1
+	}
+	if count > height-start+1 {
+		return fmt.Errorf("block %d is above the current height %d", start+count-1, height)
+	}
+
+	if start > 0 {
+		if err := binary.Write(w, binary.LittleEndian, start); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.LittleEndian, count); err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	for i := start; i < start+count; i++ {
+		block, err := bc.GetBlock(bc.GetHeaderHash(int(i)))
+		if err != nil {
+			return fmt.Errorf("can't get block %d: %s", i, err)
+		}
+		// Stored blocks only hold the hashes of their transactions.
+		for j, tx := range block.Transactions {
+			if block.Transactions[j], _, err = bc.GetTransaction(tx.Hash()); err != nil {
+				return fmt.Errorf("can't get transaction %s of block %d: %s", tx.Hash(), i, err)
+			}
+		}
+		buf.Reset()
+		if err := block.EncodeBinary(buf); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, uint32(buf.Len())); err != nil {
+			return err
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreBlocks adds the blocks read from the given reader in the format
+// written by DumpBlocks to the given chain, starting with the one with the
+// given index and stopping after count of them, or at the end of the dump if
+// it's 0. Whether the dump begins with the index of its first block is
+// detected from its content. Blocks that are already in the chain are
+// skipped. It returns once the last added block is persisted.
+func RestoreBlocks(bc Blockchainer, r io.Reader, start, count uint32) error {
+	br := bufio.NewReader(r)
+	indexed, err := isIndexedDump(br)
+	if err != nil {
+		return err
+	}
+	var first, total uint32
+	if indexed {
+		if err := binary.Read(br, binary.LittleEndian, &first); err != nil {
+			return err
+		}
+	}
+	if err := binary.Read(br, binary.LittleEndian, &total); err != nil {
+		return err
+	}
+
+	persisted := make(chan *Block, restoreCacheSize)
+	bc.SubscribeForBlocks(persisted)
+	defer func() {
+		// The channel has to be read until unsubscribed.
+		done := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case _, ok := <-persisted:
+					if !ok {
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+		bc.UnsubscribeFromBlocks(persisted)
+		close(done)
+	}()
+
+	var (
+		added bool
+		last  uint32
+	)
+	for i := uint32(0); i < total; i++ {
+		block, err := readDumpedBlock(br)
+		if err != nil {
+			return fmt.Errorf("can't read block %d of the dump: %s", first+i, err)
+		}
+		if block.Index != first+i {
+			return fmt.Errorf("block %d of the dump has index %d", first+i, block.Index)
+		}
+		if count != 0 && block.Index >= start+count {
+			break
+		}
+		if block.Index < start || block.Index <= bc.BlockHeight() {
+			continue
+		}
+		if err := bc.AddBlock(block); err != nil {
+			return fmt.Errorf("can't add block %d: %s", block.Index, err)
+		}
+		added, last = true, block.Index
+		if last-bc.BlockHeight() >= restoreCacheSize {
+			if err := waitForBlock(bc, persisted, last-restoreCacheSize/2); err != nil {
+				return err
+			}
+		}
+	}
+	if !added {
+		return nil
+	}
+	return waitForBlock(bc, persisted, last)
+}
+
+// Offset of the index of the first block in a dump beginning with the index,
+// after the index, the number of blocks, the size of the block and the
+// fields of the block preceding its index.
+const indexedDumpBlockIndexOffset = 4 + 4 + 4 + 4 + 32 + 32 + 4
+
+// isIndexedDump returns whether the dump read by the given reader begins
+// with the index of its first block, which is then also the index read from
+// the first block. A dump too short to hold a block is indexed if it holds
+// both the index and the number of blocks.
+func isIndexedDump(r *bufio.Reader) (bool, error) {
+	b, err := r.Peek(indexedDumpBlockIndexOffset + 4)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return len(b) == 8, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	first := binary.LittleEndian.Uint32(b)
+	return first != 0 && binary.LittleEndian.Uint32(b[indexedDumpBlockIndexOffset:]) == first, nil
+}
+
+// readDumpedBlock reads a size-prefixed block.
+func readDumpedBlock(r io.Reader) (*Block, error) {
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	block := &Block{}
+	if err := block.DecodeBinary(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// waitForBlock waits for the block with the given index to be persisted,
+// failing if no block is persisted for restoreTimeout.
+func waitForBlock(bc Blockchainer, persisted <-chan *Block, index uint32) error {
+	for bc.BlockHeight() < index {
+		select {
+		case _, ok := <-persisted:
+			if !ok {
+				return fmt.Errorf("disconnected from the persisted blocks")
+			}
+		case <-time.After(restoreTimeout):
+			return fmt.Errorf("block %d was not persisted", bc.BlockHeight()+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpAndRestoreBlocks(t *testing.T) {
+	bc := newVerifyingTestChain(t)
+	prev, err := bc.GetBlock(bc.CurrentBlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := make([]*Block, 4)
+	for i := range blocks {
+		blocks[i] = newSignedBlock(t, bc, prev.Header())
+		prev = blocks[i]
+		assert.Nil(t, bc.AddBlock(blocks[i]))
+	}
+	assert.Nil(t, bc.persist())
+
+	dump := new(bytes.Buffer)
+	assert.Nil(t, DumpBlocks(bc, dump, 0, 0))
+	assert.Equal(t, uint32(5), binary.LittleEndian.Uint32(dump.Bytes()))
+	assert.NotNil(t, DumpBlocks(bc, new(bytes.Buffer), 5, 0))
+	assert.NotNil(t, DumpBlocks(bc, new(bytes.Buffer), 1, 5))
+
+	restored := newVerifyingTestChain(t)
+	assert.Nil(t, RestoreBlocks(restored, bytes.NewReader(dump.Bytes()), 0, 3))
+	assert.Equal(t, uint32(2), restored.BlockHeight())
+	assert.Nil(t, RestoreBlocks(restored, bytes.NewReader(dump.Bytes()), 0, 0))
+	assert.Equal(t, uint32(4), restored.BlockHeight())
+	assert.Equal(t, blocks[3].Hash(), restored.CurrentBlockHash())
+	tx, _, err := restored.GetTransaction(blocks[3].Transactions[0].Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, blocks[3].Transactions[0].Hash(), tx.Hash())
+
+	// Partial dumps can be restored on top of the missing blocks.
+	partial := new(bytes.Buffer)
+	assert.Nil(t, DumpBlocks(bc, partial, 3, 2))
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(partial.Bytes()))
+	assert.Equal(t, uint32(2), binary.LittleEndian.Uint32(partial.Bytes()[4:]))
+	restored = newVerifyingTestChain(t)
+	assert.Nil(t, RestoreBlocks(restored, bytes.NewReader(dump.Bytes()), 0, 3))
+	// The index of the first block is detected from the content of the dump.
+	assert.Nil(t, RestoreBlocks(restored, bytes.NewReader(partial.Bytes()), 0, 0))
+	assert.Equal(t, uint32(4), restored.BlockHeight())
+	assert.Equal(t, blocks[3].Hash(), restored.CurrentBlockHash())
+
+	// Empty dumps are read in both formats.
+	empty := new(bytes.Buffer)
+	assert.Nil(t, binary.Write(empty, binary.LittleEndian, []uint32{5, 0}))
+	assert.Nil(t, RestoreBlocks(restored, bytes.NewReader(empty.Bytes()), 0, 0))
+	assert.Nil(t, RestoreBlocks(restored, bytes.NewReader(empty.Bytes()[4:]), 0, 0))
+	assert.Equal(t, uint32(4), restored.BlockHeight())
+}
@@ -0,0 +1,351 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// SpentCoin represents a governing token output together with the heights
+// it was created and spent at, the GAS generated between them being the
+// bonus it's entitled to.
+type SpentCoin struct {
+	TxHash      util.Uint256
+	Index       uint16
+	Output      *transaction.Output
+	StartHeight uint32
+	EndHeight   uint32
+}
+
+// makeClaimableKey returns the key of the given spent governing token output
+// in the claimable coins of the given account.
+func makeClaimableKey(scriptHash util.Uint160, hash util.Uint256, index uint16) []byte {
+	suffix := make([]byte, unspentKeySuffixLen)
+	copy(suffix, hash.BytesReverse())
+	binary.BigEndian.PutUint16(suffix[32:], index)
+	return storage.AppendPrefix(storage.IXClaimable, append(scriptHash.Bytes(), suffix...))
+}
+
+// putClaimableCoin adds the given spent coin to the claimable coins of the
+// account it was sent to in the given store.
+func putClaimableCoin(s storage.Store, coin *SpentCoin) error {
+	buf := new(bytes.Buffer)
+	// State version.
+	if err := binary.Write(buf, binary.LittleEndian, uint8(0)); err != nil {
+		return err
+	}
+	if err := binary.Write(buf, binary.LittleEndian, coin.Output.Amount); err != nil {
+		return err
+	}
+	if err := binary.Write(buf, binary.LittleEndian, coin.StartHeight); err != nil {
+		return err
+	}
+	if err := binary.Write(buf, binary.LittleEndian, coin.EndHeight); err != nil {
+		return err
+	}
+	return s.Put(makeClaimableKey(coin.Output.ScriptHash, coin.TxHash, coin.Index), buf.Bytes())
+}
+
+// deleteClaimableCoin removes the output with the given transaction hash and
+// index from the claimable coins of the given account in the given store.
+func deleteClaimableCoin(s storage.Store, scriptHash util.Uint160, hash util.Uint256, index uint16) error {
+	return s.Delete(makeClaimableKey(scriptHash, hash, index))
+}
+
+// decodeClaimableCoin decodes the claimable coin stored with the given key
+// and value in the claimable coins of the given account.
+func decodeClaimableCoin(scriptHash util.Uint160, k, v []byte) (*SpentCoin, error) {
+	prefixLen := 1 + len(scriptHash)
+	if len(k) != prefixLen+unspentKeySuffixLen {
+		return nil, fmt.Errorf("invalid claimable coin key %x", k)
+	}
+	hash, err := util.Uint256DecodeBytes(k[prefixLen : prefixLen+32])
+	if err != nil {
+		return nil, err
+	}
+	coin := &SpentCoin{
+		TxHash: hash,
+		Index:  binary.BigEndian.Uint16(k[prefixLen+32:]),
+		Output: &transaction.Output{
+			AssetID:    governingTokenTX().Hash(),
+			ScriptHash: scriptHash,
+		},
+	}
+	var version uint8
+	r := bytes.NewReader(v)
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &coin.Output.Amount); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &coin.StartHeight); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &coin.EndHeight); err != nil {
+		return nil, err
+	}
+	return coin, nil
+}
+
+// GetSpentCoinState returns the spent coin state of the transaction with the
+// given hash or nil if none of its governing token outputs is spent.
+func (bc *Blockchain) GetSpentCoinState(hash util.Uint256) *SpentCoinState {
+	b, err := bc.Get(storage.AppendPrefix(storage.STSpentCoin, hash.BytesReverse()))
+	if err != nil {
+		return nil
+	}
+	spent := &SpentCoinState{}
+	if err := spent.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil
+	}
+	return spent
+}
+
+// markCoinSpent records in the given store that the given governing token
+// output was spent, making it claimable by the account it was sent to.
+func markCoinSpent(s storage.Store, coin *SpentCoin) error {
+	spent, err := getSpentCoinState(s, coin.TxHash, coin.StartHeight)
+	if err != nil {
+		return err
+	}
+	spent.items[coin.Index] = coin.EndHeight
+	if err := putSpentCoinState(s, spent); err != nil {
+		return err
+	}
+	return putClaimableCoin(s, coin)
+}
+
+// markCoinsClaimed records in the given store that the given spent
+// governing token outputs were claimed, they can't be claimed again.
+func markCoinsClaimed(s storage.Store, claims []*transaction.Input) error {
+	for _, input := range claims {
+		spent, err := getSpentCoinState(s, input.PrevHash, 0)
+		if err != nil {
+			return err
+		}
+		delete(spent.items, input.PrevIndex)
+		if err := putSpentCoinState(s, spent); err != nil {
+			return err
+		}
+		prevTX, _, err := getTransaction(s, input.PrevHash)
+		if err != nil {
+			return fmt.Errorf("could not find claimed TX: %s", input.PrevHash)
+		}
+		if int(input.PrevIndex) >= len(prevTX.Outputs) {
+			return fmt.Errorf("transaction %s has no output %d", input.PrevHash, input.PrevIndex)
+		}
+		scriptHash := prevTX.Outputs[input.PrevIndex].ScriptHash
+		if err := deleteClaimableCoin(s, scriptHash, input.PrevHash, input.PrevIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getUnclaimed returns the spent governing token outputs of the transaction
+// with the given hash that are not claimed yet, indexed by their position.
+func (bc *Blockchain) getUnclaimed(hash util.Uint256) (map[uint16]*SpentCoin, error) {
+	spent := bc.GetSpentCoinState(hash)
+	if spent == nil {
+		return nil, fmt.Errorf("no spent coins for transaction %s", hash)
+	}
+	tx, _, err := bc.GetTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+	coins := make(map[uint16]*SpentCoin, len(spent.items))
+	for index, height := range spent.items {
+		if int(index) >= len(tx.Outputs) {
+			return nil, fmt.Errorf("transaction %s has no output %d", hash, index)
+		}
+		coins[index] = &SpentCoin{
+			TxHash:      hash,
+			Index:       index,
+			Output:      tx.Outputs[index],
+			StartHeight: spent.txHeight,
+			EndHeight:   height,
+		}
+	}
+	return coins, nil
+}
+
+// CalculateBonus returns the GAS generated for the given coin between its
+// start and end heights together with its share of the system fees paid in
+// the meantime.
+func (bc *Blockchain) CalculateBonus(coin *SpentCoin) (generated, sysFee util.Fixed8) {
+	start, end := coin.StartHeight, coin.EndHeight
+	if end <= start {
+		return 0, 0
+	}
+
+	var amount int64
+	interval := uint32(decrementInterval)
+	ustart := start / interval
+	if int(ustart) < len(genAmount) {
+		istart := start % interval
+		uend := end / interval
+		iend := end % interval
+		if int(uend) >= len(genAmount) {
+			uend = uint32(len(genAmount))
+			iend = 0
+		}
+		if iend == 0 {
+			uend--
+			iend = interval
+		}
+		for ; ustart < uend; ustart++ {
+			amount += int64(interval-istart) * int64(genAmount[ustart])
+			istart = 0
+		}
+		amount += int64(iend-istart) * int64(genAmount[ustart])
+	}
+
+	fee := int64(bc.GetSysFeeAmount(bc.GetHeaderHash(int(end - 1))))
+	if start > 0 {
+		fee -= int64(bc.GetSysFeeAmount(bc.GetHeaderHash(int(start - 1))))
+	}
+
+	// Every whole governing token gets 10^-8 of the amounts above.
+	value := coin.Output.Amount.Value()
+	return util.Fixed8(value * amount), util.Fixed8(value * fee)
+}
+
+// calculateClaimBonus returns the total bonus of the given claims, all of
+// them should be claimable.
+func (bc *Blockchain) calculateClaimBonus(claims []*transaction.Input) (util.Fixed8, error) {
+	var bonus util.Fixed8
+	for prevHash, inputs := range transaction.GroupInputsByPrevHash(claims) {
+		coins, err := bc.getUnclaimed(prevHash)
+		if err != nil {
+			return 0, err
+		}
+		for _, input := range inputs {
+			coin, ok := coins[input.PrevIndex]
+			if !ok {
+				return 0, fmt.Errorf("output %d of transaction %s is not claimable", input.PrevIndex, prevHash)
+			}
+			generated, sysFee := bc.CalculateBonus(coin)
+			bonus += generated + sysFee
+		}
+	}
+	return bonus, nil
+}
+
+// getClaimableCoins returns the claimable coins of the given account in the
+// given store, ordered by transaction hash and index.
+func getClaimableCoins(s storage.Store, scriptHash util.Uint160) ([]*SpentCoin, error) {
+	var (
+		claimable []*SpentCoin
+		err       error
+	)
+	prefix := storage.AppendPrefix(storage.IXClaimable, scriptHash.Bytes())
+	iterErr := s.Iterate(storage.PrefixRange(prefix), func(k, v []byte) bool {
+		var coin *SpentCoin
+		if coin, err = decodeClaimableCoin(scriptHash, k, v); err != nil {
+			return false
+		}
+		claimable = append(claimable, coin)
+		return true
+	})
+	if iterErr != nil {
+		return nil, iterErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return claimable, nil
+}
+
+// GetClaimable returns the spent and not yet claimed governing token outputs
+// of the given address, sorted by transaction hash and index.
+func (bc *Blockchain) GetClaimable(scriptHash util.Uint160) ([]*SpentCoin, error) {
+	return getClaimableCoins(bc.Store, scriptHash)
+}
+
+// GetUnavailable returns the bonus the unspent governing token outputs of
+// the given address would get if they were spent in the next block.
+func (bc *Blockchain) GetUnavailable(scriptHash util.Uint160) (util.Fixed8, error) {
+	outputs, err := bc.GetUnspents(scriptHash)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		unavailable util.Fixed8
+		height      = bc.BlockHeight() + 1
+	)
+	for _, output := range outputs {
+		if !output.AssetID.Equals(governingTokenTX().Hash()) {
+			continue
+		}
+		txHeight, err := bc.GetTransactionHeight(output.TxHash)
+		if err != nil {
+			return 0, err
+		}
+		generated, sysFee := bc.CalculateBonus(&SpentCoin{
+			TxHash: output.TxHash,
+			Index:  output.Index,
+			Output: &transaction.Output{
+				AssetID:    output.AssetID,
+				Amount:     output.Amount,
+				ScriptHash: scriptHash,
+			},
+			StartHeight: txHeight,
+			EndHeight:   height,
+		})
+		unavailable += generated + sysFee
+	}
+	return unavailable, nil
+}
+
+// verifyClaims checks that the coins claimed by the given claim transaction
+// are claimable and not claimed twice, either by the transaction itself or by
+// another one of the given block, and that it creates exactly their bonus.
+func (bc *Blockchain) verifyClaims(t *transaction.Transaction, claim *transaction.ClaimTX, block *Block) error {
+	if len(claim.Claims) == 0 {
+		return errors.New("no claims")
+	}
+	claimed := make(map[transaction.Input]bool, len(claim.Claims))
+	for _, input := range claim.Claims {
+		if claimed[*input] {
+			return errors.New("duplicate claims")
+		}
+		claimed[*input] = true
+	}
+	if block != nil {
+		for _, tx := range block.Transactions {
+			other, ok := tx.Data.(*transaction.ClaimTX)
+			if !ok || tx.Hash().Equals(t.Hash()) {
+				continue
+			}
+			for _, input := range other.Claims {
+				if claimed[*input] {
+					return fmt.Errorf("claims conflict with transaction %s", tx.Hash())
+				}
+			}
+		}
+	}
+
+	results, err := bc.GetTransactionResults(t)
+	if err != nil {
+		return err
+	}
+	amount := results[utilityTokenTX().Hash()]
+	if amount >= 0 {
+		return errors.New("claim transaction doesn't create any GAS")
+	}
+	bonus, err := bc.calculateClaimBonus(claim.Claims)
+	if err != nil {
+		return err
+	}
+	if bonus != -amount {
+		return fmt.Errorf("claimed amount %s doesn't match the bonus %s", -amount, bonus)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateBonus(t *testing.T) {
+	bc := newVerifyingTestChain(t)
+	prev := bc.GetHeaderHash(0)
+	for i := 0; i < 4; i++ {
+		header, err := bc.getHeader(prev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block := newSignedBlock(t, bc, header)
+		assert.Nil(t, bc.AddBlock(block))
+		assert.Nil(t, bc.persist())
+		prev = block.Hash()
+	}
+	assert.Equal(t, uint32(4), bc.BlockHeight())
+
+	defer func(interval int) { decrementInterval = interval }(decrementInterval)
+	decrementInterval = 2
+
+	coin := &SpentCoin{
+		Output:      &transaction.Output{Amount: util.NewFixed8(10)},
+		StartHeight: 1,
+		EndHeight:   4,
+	}
+	// 8 for the block 1 and 7 for the blocks 2 and 3.
+	generated, sysFee := bc.CalculateBonus(coin)
+	assert.Equal(t, util.Fixed8(10*22), generated)
+	assert.Equal(t, util.Fixed8(0), sysFee)
+
+	coin.StartHeight = 4
+	generated, sysFee = bc.CalculateBonus(coin)
+	assert.Equal(t, util.Fixed8(0), generated)
+	assert.Equal(t, util.Fixed8(0), sysFee)
+}
+
+func TestClaim(t *testing.T) {
+	bc := newVerifyingTestChain(t)
+	genesis, err := createGenesisBlock(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueTX := genesis.Transactions[3]
+	neo := governingTokenTX().Hash()
+	gas := utilityTokenTX().Hash()
+	amount := issueTX.Outputs[0].Amount
+	owner := issueTX.Outputs[0].ScriptHash
+
+	sign := func(tx *transaction.Transaction) *transaction.Transaction {
+		data, err := tx.GetHashableData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.Scripts = []*transaction.Witness{signWithValidators(t, data)}
+		return tx
+	}
+	newClaimTX := func(bonus util.Fixed8, claims ...*transaction.Input) *transaction.Transaction {
+		return sign(&transaction.Transaction{
+			Type:       transaction.ClaimType,
+			Data:       &transaction.ClaimTX{Claims: claims},
+			Attributes: []*transaction.Attribute{},
+			Inputs:     []*transaction.Input{},
+			Outputs:    []*transaction.Output{transaction.NewOutput(gas, bonus, owner)},
+		})
+	}
+	claim := &transaction.Input{PrevHash: issueTX.Hash(), PrevIndex: 0}
+
+	// Nothing is claimable before the NEO is spent.
+	claimable, err := bc.GetClaimable(owner)
+	assert.Nil(t, err)
+	assert.Empty(t, claimable)
+	assert.NotNil(t, bc.VerifyTx(newClaimTX(util.NewFixed8(8), claim), nil))
+
+	spendTX := sign(&transaction.Transaction{
+		Type:       transaction.ContractType,
+		Data:       &transaction.ContractTX{},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{claim},
+		Outputs:    []*transaction.Output{transaction.NewOutput(neo, amount, owner)},
+	})
+	block := newSignedBlock(t, bc, genesis.Header(), spendTX)
+	assert.Nil(t, bc.AddBlock(block))
+	assert.Nil(t, bc.persist())
+	assert.Equal(t, uint32(1), bc.BlockHeight())
+
+	// The whole NEO generates 8 GAS per block.
+	claimable, err = bc.GetClaimable(owner)
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(claimable)) {
+		coin := claimable[0]
+		assert.Equal(t, issueTX.Hash(), coin.TxHash)
+		assert.Equal(t, uint16(0), coin.Index)
+		assert.Equal(t, uint32(0), coin.StartHeight)
+		assert.Equal(t, uint32(1), coin.EndHeight)
+		generated, sysFee := bc.CalculateBonus(coin)
+		assert.Equal(t, util.NewFixed8(8), generated)
+		assert.Equal(t, util.Fixed8(0), sysFee)
+	}
+	unavailable, err := bc.GetUnavailable(owner)
+	assert.Nil(t, err)
+	assert.Equal(t, util.NewFixed8(8), unavailable)
+	unavailable, err = bc.GetUnavailable(util.Uint160{1, 2, 3})
+	assert.Nil(t, err)
+	assert.Equal(t, util.Fixed8(0), unavailable)
+
+	// The claimed amount should match the bonus exactly.
+	assert.NotNil(t, bc.VerifyTx(newClaimTX(util.NewFixed8(9), claim), nil))
+	assert.NotNil(t, bc.VerifyTx(newClaimTX(util.NewFixed8(8)), nil))
+	assert.NotNil(t, bc.VerifyTx(newClaimTX(util.NewFixed8(16), claim, claim), nil))
+	assert.NotNil(t, bc.VerifyTx(newClaimTX(util.NewFixed8(8), &transaction.Input{PrevHash: issueTX.Hash(), PrevIndex: 1}), nil))
+
+	claimTX := newClaimTX(util.NewFixed8(8), claim)
+	assert.Nil(t, bc.VerifyTx(claimTX, nil))
+
+	// A coin can only be claimed once in the pool and in a block.
+	other := newClaimTX(util.NewFixed8(8), claim)
+	other.Attributes = []*transaction.Attribute{{Usage: transaction.Remark, Data: []byte{1}}}
+	other = sign(other)
+	assert.Nil(t, bc.PoolTx(claimTX))
+	assert.Equal(t, ErrConflict, bc.PoolTx(other))
+	assert.NotNil(t, bc.verifyBlock(newSignedBlock(t, bc, block.Header(), claimTX, other)))
+
+	block = newSignedBlock(t, bc, block.Header(), claimTX)
+	assert.Nil(t, bc.AddBlock(block))
+	assert.Nil(t, bc.persist())
+	assert.Equal(t, uint32(2), bc.BlockHeight())
+	assert.Equal(t, 0, bc.GetMemPool().Count())
+
+	claimable, err = bc.GetClaimable(owner)
+	assert.Nil(t, err)
+	assert.Empty(t, claimable)
+	assert.NotNil(t, bc.VerifyTx(other, nil))
+}
+
+// recordingStore records the keys read from the underlying store.
+type recordingStore struct {
+	storage.Store
+	keys [][]byte
+}
+
+func (s *recordingStore) Get(k []byte) ([]byte, error) {
+	s.keys = append(s.keys, k)
+	return s.Store.Get(k)
+}
+
+func (s *recordingStore) Seek(k []byte, f func(k, v []byte)) {
+	s.Store.Seek(k, func(k, v []byte) {
+		s.keys = append(s.keys, k)
+		f(k, v)
+	})
+}
+
+func (s *recordingStore) Iterate(r storage.Range, f func(k, v []byte) bool) error {
+	return s.Store.Iterate(r, func(k, v []byte) bool {
+		s.keys = append(s.keys, k)
+		return f(k, v)
+	})
+}
+
+func TestClaimableByAddress(t *testing.T) {
+	bc := newTestChain(t)
+	genesis, err := createGenesisBlock(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueTX := genesis.Transactions[3]
+	neo := governingTokenTX().Hash()
+	amount := issueTX.Outputs[0].Amount
+	owner := issueTX.Outputs[0].ScriptHash
+	alice := util.Uint160{1}
+	bob := util.Uint160{2}
+
+	newSpendTX := func(input *transaction.Input, outputs ...*transaction.Output) *transaction.Transaction {
+		return &transaction.Transaction{
+			Type:       transaction.ContractType,
+			Data:       &transaction.ContractTX{},
+			Attributes: []*transaction.Attribute{},
+			Inputs:     []*transaction.Input{input},
+			Outputs:    outputs,
+		}
+	}
+	shareTX := newSpendTX(&transaction.Input{PrevHash: issueTX.Hash(), PrevIndex: 0},
+		transaction.NewOutput(neo, util.NewFixed8(10), alice),
+		transaction.NewOutput(neo, util.NewFixed8(20), bob),
+		transaction.NewOutput(neo, amount-util.NewFixed8(30), owner),
+	)
+	block := newSignedBlock(t, bc, genesis.Header(), shareTX)
+	assert.Nil(t, bc.AddBlock(block))
+	assert.Nil(t, bc.persist())
+
+	aliceTX := newSpendTX(&transaction.Input{PrevHash: shareTX.Hash(), PrevIndex: 0},
+		transaction.NewOutput(neo, util.NewFixed8(10), alice))
+	bobTX := newSpendTX(&transaction.Input{PrevHash: shareTX.Hash(), PrevIndex: 1},
+		transaction.NewOutput(neo, util.NewFixed8(20), bob))
+	block = newSignedBlock(t, bc, block.Header(), aliceTX, bobTX)
+	assert.Nil(t, bc.AddBlock(block))
+	assert.Nil(t, bc.persist())
+	assert.Equal(t, uint32(2), bc.BlockHeight())
+
+	store := &recordingStore{Store: bc.Store}
+	bc.Store = store
+
+	claimable, err := bc.GetClaimable(alice)
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(claimable)) {
+		coin := claimable[0]
+		assert.Equal(t, shareTX.Hash(), coin.TxHash)
+		assert.Equal(t, uint16(0), coin.Index)
+		assert.Equal(t, neo, coin.Output.AssetID)
+		assert.Equal(t, util.NewFixed8(10), coin.Output.Amount)
+		assert.Equal(t, alice, coin.Output.ScriptHash)
+		assert.Equal(t, uint32(1), coin.StartHeight)
+		assert.Equal(t, uint32(2), coin.EndHeight)
+	}
+	// 8 GAS per block for each of the 10 NEO, from the block 2 to the next one.
+	unavailable, err := bc.GetUnavailable(alice)
+	assert.Nil(t, err)
+	assert.Equal(t, util.Fixed8(10*8), unavailable)
+
+	// The coins of bob are never visited.
+	assert.NotEmpty(t, store.keys)
+	for _, k := range store.keys {
+		assert.False(t, bytes.Contains(k, bob.Bytes()), "visited %x", k)
+		assert.False(t, bytes.Contains(k, bobTX.Hash().BytesReverse()), "visited %x", k)
+	}
+
+	claimable, err = bc.GetClaimable(bob)
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(claimable)) {
+		assert.Equal(t, shareTX.Hash(), claimable[0].TxHash)
+		assert.Equal(t, uint16(1), claimable[0].Index)
+		assert.Equal(t, util.NewFixed8(20), claimable[0].Output.Amount)
+	}
+}
@@ -1,10 +1,51 @@
 package core
 
 import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
 	"github.com/CityOfZion/neo-go/pkg/smartcontract"
 	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
+// Contract property flags as they are stored on the chain.
+const (
+	hasStorageFlag       byte = 1 << 0
+	hasDynamicInvokeFlag byte = 1 << 1
+	isPayableFlag        byte = 1 << 2
+)
+
+// getContractState returns the state of the contract with the given script
+// hash in the given store, nil if there is no such contract.
+func getContractState(s storage.Store, hash util.Uint160) *ContractState {
+	b, err := s.Get(storage.AppendPrefix(storage.STContract, hash.BytesReverse()))
+	if err != nil {
+		return nil
+	}
+	contract := &ContractState{}
+	if err := contract.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil
+	}
+	return contract
+}
+
+// putContractState stores the given contract state in the given store.
+func putContractState(s storage.Store, cs *ContractState) error {
+	buf := new(bytes.Buffer)
+	if err := cs.EncodeBinary(buf); err != nil {
+		return err
+	}
+	return s.Put(storage.AppendPrefix(storage.STContract, cs.ScriptHash().BytesReverse()), buf.Bytes())
+}
+
+// deleteContractState removes the state of the contract with the given
+// script hash from the given store.
+func deleteContractState(s storage.Store, hash util.Uint160) error {
+	return s.Delete(storage.AppendPrefix(storage.STContract, hash.BytesReverse()))
+}
+
 // ContractState holds information about a smart contract in the NEO blockchain.
 type ContractState struct {
 	Script           []byte
@@ -18,6 +59,98 @@ type ContractState struct {
 	Description      string
 	HasStorage       bool
 	HasDynamicInvoke bool
+	IsPayable        bool
 
 	scriptHash util.Uint160
 }
+
+// ScriptHash returns the hash of the contract script.
+func (cs *ContractState) ScriptHash() util.Uint160 {
+	if cs.scriptHash.Equals(util.Uint160{}) {
+		cs.scriptHash, _ = util.Uint160FromScript(cs.Script)
+	}
+	return cs.scriptHash
+}
+
+// DecodeBinary implements the Payload interface.
+func (cs *ContractState) DecodeBinary(r io.Reader) error {
+	var err error
+
+	cs.Script, err = util.ReadVarBytes(r)
+	if err != nil {
+		return err
+	}
+	params, err := util.ReadVarBytes(r)
+	if err != nil {
+		return err
+	}
+	cs.ParamList = make([]smartcontract.ParamType, len(params))
+	for i := range params {
+		cs.ParamList[i] = smartcontract.ParamType(params[i])
+	}
+
+	var rtype, props byte
+	if err := binary.Read(r, binary.LittleEndian, &rtype); err != nil {
+		return err
+	}
+	cs.ReturnType = smartcontract.ParamType(rtype)
+	if err := binary.Read(r, binary.LittleEndian, &props); err != nil {
+		return err
+	}
+	cs.HasStorage = props&hasStorageFlag != 0
+	cs.HasDynamicInvoke = props&hasDynamicInvokeFlag != 0
+	cs.IsPayable = props&isPayableFlag != 0
+
+	if cs.Name, err = util.ReadVarString(r); err != nil {
+		return err
+	}
+	if cs.CodeVersion, err = util.ReadVarString(r); err != nil {
+		return err
+	}
+	if cs.Author, err = util.ReadVarString(r); err != nil {
+		return err
+	}
+	if cs.Email, err = util.ReadVarString(r); err != nil {
+		return err
+	}
+	if cs.Description, err = util.ReadVarString(r); err != nil {
+		return err
+	}
+	cs.scriptHash = util.Uint160{}
+	return nil
+}
+
+// EncodeBinary implements the Payload interface.
+func (cs *ContractState) EncodeBinary(w io.Writer) error {
+	if err := util.WriteVarBytes(w, cs.Script); err != nil {
+		return err
+	}
+	params := make([]byte, len(cs.ParamList))
+	for i := range cs.ParamList {
+		params[i] = byte(cs.ParamList[i])
+	}
+	if err := util.WriteVarBytes(w, params); err != nil {
+		return err
+	}
+
+	var props byte
+	if cs.HasStorage {
+		props |= hasStorageFlag
+	}
+	if cs.HasDynamicInvoke {
+		props |= hasDynamicInvokeFlag
+	}
+	if cs.IsPayable {
+		props |= isPayableFlag
+	}
+	if err := binary.Write(w, binary.LittleEndian, []byte{byte(cs.ReturnType), props}); err != nil {
+		return err
+	}
+
+	for _, s := range []string{cs.Name, cs.CodeVersion, cs.Author, cs.Email, cs.Description} {
+		if err := util.WriteVarString(w, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,483 @@
+/*
+Package coretest provides an in-memory implementation of core.Blockchainer
+holding whatever blocks, transactions and states the tests give it, so that
+the packages built on top of the blockchain can be tested without a real
+storage.
+*/
+package coretest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+)
+
+// ErrNotFound is returned by the accessors of the Chain returning an error
+// when there is nothing to return.
+var ErrNotFound = errors.New("not found")
+
+// Chain is a core.Blockchainer whose content is set by the tests. Blocks and
+// headers added to it are not verified, transactions added to its memory
+// pool neither. It's safe for concurrent use as long as its fields are not
+// modified directly while in use. Events are sent to the subscribers by
+// AddBlock itself, so their channels have to be buffered or read
+// concurrently.
+type Chain struct {
+	Accounts       map[util.Uint160]*core.AccountState
+	Assets         map[util.Uint256]*core.AssetState
+	Contracts      map[util.Uint160]*core.ContractState
+	UnspentCoins   map[util.Uint256]*core.UnspentCoinState
+	SpentCoins     map[util.Uint256]*core.SpentCoinState
+	Unspents       map[util.Uint160][]*core.UnspentOutput
+	AddressHistory map[util.Uint160][]*core.AddressTransaction
+	NEP5Balances   map[util.Uint160][]*core.NEP5Balance
+	NEP5Transfers  map[util.Uint160][]*core.NEP5Transfer
+	AppExecResults map[util.Uint256]*core.AppExecResult
+	Validators     []*crypto.PublicKey
+	Enrollments    []*core.ValidatorState
+	MemPool        *core.MemPool
+
+	lock         sync.RWMutex
+	headerHashes []util.Uint256
+	blocks       map[util.Uint256]*core.Block
+	transactions map[util.Uint256]*transaction.Transaction
+	txHeights    map[util.Uint256]uint32
+	storageItems map[string]*core.StorageItem
+
+	blockSubs        map[chan<- *core.Block]bool
+	txSubs           map[chan<- *transaction.Transaction]bool
+	notificationSubs map[chan<- *core.ContractNotification]bool
+	executionSubs    map[chan<- *core.AppExecResult]bool
+}
+
+// NewChain returns a new empty Chain.
+func NewChain() *Chain {
+	return &Chain{
+		Accounts:       make(map[util.Uint160]*core.AccountState),
+		Assets:         make(map[util.Uint256]*core.AssetState),
+		Contracts:      make(map[util.Uint160]*core.ContractState),
+		UnspentCoins:   make(map[util.Uint256]*core.UnspentCoinState),
+		SpentCoins:     make(map[util.Uint256]*core.SpentCoinState),
+		Unspents:       make(map[util.Uint160][]*core.UnspentOutput),
+		AddressHistory: make(map[util.Uint160][]*core.AddressTransaction),
+		NEP5Balances:   make(map[util.Uint160][]*core.NEP5Balance),
+		NEP5Transfers:  make(map[util.Uint160][]*core.NEP5Transfer),
+		AppExecResults: make(map[util.Uint256]*core.AppExecResult),
+		MemPool:        core.NewMemPool(50),
+		blocks:         make(map[util.Uint256]*core.Block),
+		transactions:   make(map[util.Uint256]*transaction.Transaction),
+		txHeights:      make(map[util.Uint256]uint32),
+		storageItems:   make(map[string]*core.StorageItem),
+
+		blockSubs:        make(map[chan<- *core.Block]bool),
+		txSubs:           make(map[chan<- *transaction.Transaction]bool),
+		notificationSubs: make(map[chan<- *core.ContractNotification]bool),
+		executionSubs:    make(map[chan<- *core.AppExecResult]bool),
+	}
+}
+
+// AddHeaders implements the core.Blockchainer interface.
+func (c *Chain) AddHeaders(headers ...*core.Header) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, h := range headers {
+		if int(h.Index) < len(c.headerHashes) {
+			continue
+		}
+		if int(h.Index) != len(c.headerHashes) {
+			return fmt.Errorf("header %d doesn't follow header %d", h.Index, len(c.headerHashes)-1)
+		}
+		c.headerHashes = append(c.headerHashes, h.Hash())
+	}
+	return nil
+}
+
+// AddBlock implements the core.Blockchainer interface. Blocks must be added in
+// order, their transactions are then available with GetTransaction. The
+// block, its transactions and the results of their executions found in
+// AppExecResults are then sent to the subscribers.
+func (c *Chain) AddBlock(block *core.Block) error {
+	c.lock.Lock()
+	if _, ok := c.blocks[block.Hash()]; ok {
+		c.lock.Unlock()
+		return nil
+	}
+	if int(block.Index) != len(c.blocks) {
+		c.lock.Unlock()
+		return fmt.Errorf("block %d doesn't follow block %d", block.Index, len(c.blocks)-1)
+	}
+	if int(block.Index) == len(c.headerHashes) {
+		c.headerHashes = append(c.headerHashes, block.Hash())
+	}
+	c.blocks[block.Hash()] = block
+	for _, tx := range block.Transactions {
+		c.transactions[tx.Hash()] = tx
+		c.txHeights[tx.Hash()] = block.Index
+	}
+	c.lock.Unlock()
+
+	c.notify(block)
+	return nil
+}
+
+// notify sends the given block and what it contains to the subscribers, in
+// the order of core.Blockchain.
+func (c *Chain) notify(block *core.Block) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for ch := range c.blockSubs {
+		ch <- block
+	}
+	for _, tx := range block.Transactions {
+		for ch := range c.txSubs {
+			ch <- tx
+		}
+		aer, ok := c.AppExecResults[tx.Hash()]
+		if !ok {
+			continue
+		}
+		for ch := range c.executionSubs {
+			ch <- aer
+		}
+		for i := range aer.Events {
+			notification := &core.ContractNotification{
+				TxHash:            aer.TxHash,
+				NotificationEvent: aer.Events[i],
+			}
+			for ch := range c.notificationSubs {
+				ch <- notification
+			}
+		}
+	}
+}
+
+// PutStorageItem stores the given item under the given key of the given
+// contract.
+func (c *Chain) PutStorageItem(scriptHash util.Uint160, key []byte, item *core.StorageItem) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.storageItems[string(scriptHash.Bytes())+string(key)] = item
+}
+
+// BlockHeight implements the core.Blockchainer interface.
+func (c *Chain) BlockHeight() uint32 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if len(c.blocks) == 0 {
+		return 0
+	}
+	return uint32(len(c.blocks) - 1)
+}
+
+// HeaderHeight implements the core.Blockchainer interface.
+func (c *Chain) HeaderHeight() uint32 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if len(c.headerHashes) == 0 {
+		return 0
+	}
+	return uint32(len(c.headerHashes) - 1)
+}
+
+// GetBlock implements the core.Blockchainer interface.
+func (c *Chain) GetBlock(hash util.Uint256) (*core.Block, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if block, ok := c.blocks[hash]; ok {
+		return block, nil
+	}
+	return nil, ErrNotFound
+}
+
+// GetHeaderHash implements the core.Blockchainer interface.
+func (c *Chain) GetHeaderHash(i int) util.Uint256 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if i < 0 || i >= len(c.headerHashes) {
+		return util.Uint256{}
+	}
+	return c.headerHashes[i]
+}
+
+// CurrentHeaderHash implements the core.Blockchainer interface.
+func (c *Chain) CurrentHeaderHash() util.Uint256 {
+	return c.GetHeaderHash(int(c.HeaderHeight()))
+}
+
+// CurrentBlockHash implements the core.Blockchainer interface.
+func (c *Chain) CurrentBlockHash() util.Uint256 {
+	return c.GetHeaderHash(int(c.BlockHeight()))
+}
+
+// HasBlock implements the core.Blockchainer interface.
+func (c *Chain) HasBlock(hash util.Uint256) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	_, ok := c.blocks[hash]
+	return ok
+}
+
+// HasTransaction implements the core.Blockchainer interface.
+func (c *Chain) HasTransaction(hash util.Uint256) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	_, ok := c.transactions[hash]
+	return ok || c.MemPool.ContainsKey(hash)
+}
+
+// GetTransaction implements the core.Blockchainer interface.
+func (c *Chain) GetTransaction(hash util.Uint256) (*transaction.Transaction, uint32, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if tx, ok := c.transactions[hash]; ok {
+		return tx, c.txHeights[hash], nil
+	}
+	return nil, 0, ErrNotFound
+}
+
+// GetTransactionHeight implements the core.Blockchainer interface.
+func (c *Chain) GetTransactionHeight(hash util.Uint256) (uint32, error) {
+	_, height, err := c.GetTransaction(hash)
+	return height, err
+}
+
+// GetAccountState implements the core.Blockchainer interface.
+func (c *Chain) GetAccountState(scriptHash util.Uint160) *core.AccountState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.Accounts[scriptHash]
+}
+
+// GetAssetState implements the core.Blockchainer interface.
+func (c *Chain) GetAssetState(assetID util.Uint256) *core.AssetState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.Assets[assetID]
+}
+
+// GetContractState implements the core.Blockchainer interface.
+func (c *Chain) GetContractState(hash util.Uint160) *core.ContractState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.Contracts[hash]
+}
+
+// GetStorageItem implements the core.Blockchainer interface.
+func (c *Chain) GetStorageItem(scriptHash util.Uint160, key []byte) *core.StorageItem {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.storageItems[string(scriptHash.Bytes())+string(key)]
+}
+
+// GetUnspent implements the core.Blockchainer interface.
+func (c *Chain) GetUnspent(hash util.Uint256, index uint16) *transaction.Output {
+	unspent := c.GetUnspentCoinState(hash)
+	if unspent == nil {
+		return nil
+	}
+	states := unspent.States()
+	if int(index) >= len(states) || states[index]&core.CoinStateSpent != 0 {
+		return nil
+	}
+	tx, _, err := c.GetTransaction(hash)
+	if err != nil || int(index) >= len(tx.Outputs) {
+		return nil
+	}
+	return tx.Outputs[index]
+}
+
+// GetUnspentCoinState implements the core.Blockchainer interface.
+func (c *Chain) GetUnspentCoinState(hash util.Uint256) *core.UnspentCoinState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.UnspentCoins[hash]
+}
+
+// GetSpentCoinState implements the core.Blockchainer interface.
+func (c *Chain) GetSpentCoinState(hash util.Uint256) *core.SpentCoinState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.SpentCoins[hash]
+}
+
+// GetUnspents implements the core.Blockchainer interface.
+func (c *Chain) GetUnspents(scriptHash util.Uint160) ([]*core.UnspentOutput, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.Unspents[scriptHash], nil
+}
+
+// GetAddressHistory implements the core.Blockchainer interface, the history
+// of every address being expected newest first.
+func (c *Chain) GetAddressHistory(scriptHash util.Uint160, before *core.AddressTransaction, limit int) ([]*core.AddressTransaction, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	var txs []*core.AddressTransaction
+	for _, tx := range c.AddressHistory[scriptHash] {
+		if len(txs) >= limit {
+			break
+		}
+		if before != nil && (tx.Height > before.Height || (tx.Height == before.Height && tx.Index >= before.Index)) {
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// GetNEP5Balances implements the core.Blockchainer interface.
+func (c *Chain) GetNEP5Balances(address util.Uint160) ([]*core.NEP5Balance, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.NEP5Balances[address], nil
+}
+
+// GetNEP5Transfers implements the core.Blockchainer interface, the transfers
+// of every address being expected newest first.
+func (c *Chain) GetNEP5Transfers(address util.Uint160, start, end uint32) ([]*core.NEP5Transfer, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	var transfers []*core.NEP5Transfer
+	for _, transfer := range c.NEP5Transfers[address] {
+		if transfer.Timestamp >= start && transfer.Timestamp <= end {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers, nil
+}
+
+// GetValidators implements the core.Blockchainer interface, the given
+// transactions are ignored.
+func (c *Chain) GetValidators(...*transaction.Transaction) ([]*crypto.PublicKey, error) {
+	return c.Validators, nil
+}
+
+// GetEnrollments implements the core.Blockchainer interface.
+func (c *Chain) GetEnrollments() ([]*core.ValidatorState, error) {
+	return c.Enrollments, nil
+}
+
+// GetClaimable implements the core.Blockchainer interface, there is never
+// anything to claim.
+func (c *Chain) GetClaimable(util.Uint160) ([]*core.SpentCoin, error) {
+	return nil, nil
+}
+
+// GetUnavailable implements the core.Blockchainer interface, there is never
+// any unavailable GAS.
+func (c *Chain) GetUnavailable(util.Uint160) (util.Fixed8, error) {
+	return 0, nil
+}
+
+// CalculateBonus implements the core.Blockchainer interface, there is never
+// any bonus.
+func (c *Chain) CalculateBonus(*core.SpentCoin) (util.Fixed8, util.Fixed8) {
+	return 0, 0
+}
+
+// SystemFee implements the core.Blockchainer interface, transactions are
+// free.
+func (c *Chain) SystemFee(*transaction.Transaction) util.Fixed8 {
+	return 0
+}
+
+// GetSysFeeAmount implements the core.Blockchainer interface, blocks are
+// free.
+func (c *Chain) GetSysFeeAmount(util.Uint256) uint32 {
+	return 0
+}
+
+// NetworkFee implements the core.Blockchainer interface, transactions are
+// free.
+func (c *Chain) NetworkFee(*transaction.Transaction) util.Fixed8 {
+	return 0
+}
+
+// GetMemPool implements the core.Blockchainer interface.
+func (c *Chain) GetMemPool() *core.MemPool {
+	return c.MemPool
+}
+
+// PoolTx implements the core.Blockchainer interface, the transaction is
+// added to the memory pool without being verified.
+func (c *Chain) PoolTx(tx *transaction.Transaction) error {
+	return c.MemPool.TryAdd(core.NewPoolItem(tx, 0, 0))
+}
+
+// GetAppExecResult implements the core.Blockchainer interface.
+func (c *Chain) GetAppExecResult(hash util.Uint256) (*core.AppExecResult, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if result, ok := c.AppExecResults[hash]; ok {
+		return result, nil
+	}
+	return nil, ErrNotFound
+}
+
+// GetTestVM implements the core.Blockchainer interface, the returned VM has
+// no interop service registered.
+func (c *Chain) GetTestVM() *vm.VM {
+	return vm.New(vm.ModeMute)
+}
+
+// SubscribeForBlocks implements the core.Blockchainer interface.
+func (c *Chain) SubscribeForBlocks(ch chan<- *core.Block) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.blockSubs[ch] = true
+}
+
+// SubscribeForTransactions implements the core.Blockchainer interface.
+func (c *Chain) SubscribeForTransactions(ch chan<- *transaction.Transaction) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.txSubs[ch] = true
+}
+
+// SubscribeForNotifications implements the core.Blockchainer interface.
+func (c *Chain) SubscribeForNotifications(ch chan<- *core.ContractNotification) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.notificationSubs[ch] = true
+}
+
+// SubscribeForExecutions implements the core.Blockchainer interface.
+func (c *Chain) SubscribeForExecutions(ch chan<- *core.AppExecResult) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.executionSubs[ch] = true
+}
+
+// UnsubscribeFromBlocks implements the core.Blockchainer interface.
+func (c *Chain) UnsubscribeFromBlocks(ch chan<- *core.Block) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.blockSubs, ch)
+}
+
+// UnsubscribeFromTransactions implements the core.Blockchainer interface.
+func (c *Chain) UnsubscribeFromTransactions(ch chan<- *transaction.Transaction) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.txSubs, ch)
+}
+
+// UnsubscribeFromNotifications implements the core.Blockchainer interface.
+func (c *Chain) UnsubscribeFromNotifications(ch chan<- *core.ContractNotification) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.notificationSubs, ch)
+}
+
+// UnsubscribeFromExecutions implements the core.Blockchainer interface.
+func (c *Chain) UnsubscribeFromExecutions(ch chan<- *core.AppExecResult) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.executionSubs, ch)
+}
@@ -0,0 +1,140 @@
+package coretest
+
+import (
+	"testing"
+
+	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ core.Blockchainer = (*Chain)(nil)
+
+func newBlock(index uint32, txs ...*transaction.Transaction) *core.Block {
+	return &core.Block{
+		BlockBase: core.BlockBase{
+			Index:     index,
+			Timestamp: index,
+		},
+		Transactions: txs,
+	}
+}
+
+func newContractTX(outputs ...*transaction.Output) *transaction.Transaction {
+	return &transaction.Transaction{
+		Type:       transaction.ContractType,
+		Data:       &transaction.ContractTX{},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{},
+		Outputs:    outputs,
+	}
+}
+
+func TestAddBlock(t *testing.T) {
+	c := NewChain()
+	tx := newContractTX()
+	genesis, block := newBlock(0), newBlock(1, tx)
+
+	assert.NotNil(t, c.AddBlock(block))
+	assert.Nil(t, c.AddBlock(genesis))
+	assert.Nil(t, c.AddBlock(block))
+	assert.Equal(t, uint32(1), c.BlockHeight())
+	assert.Equal(t, uint32(1), c.HeaderHeight())
+	assert.Equal(t, block.Hash(), c.CurrentBlockHash())
+	assert.Equal(t, genesis.Hash(), c.GetHeaderHash(0))
+	assert.True(t, c.HasBlock(block.Hash()))
+
+	b, err := c.GetBlock(block.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, block, b)
+
+	got, height, err := c.GetTransaction(tx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, tx, got)
+	assert.Equal(t, uint32(1), height)
+	height, err = c.GetTransactionHeight(tx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), height)
+	_, err = c.GetTransactionHeight(util.Uint256{1, 2, 3})
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestAddHeaders(t *testing.T) {
+	c := NewChain()
+	genesis, block := newBlock(0), newBlock(1)
+
+	assert.NotNil(t, c.AddHeaders(block.Header()))
+	assert.Nil(t, c.AddHeaders(genesis.Header(), block.Header()))
+	assert.Equal(t, uint32(1), c.HeaderHeight())
+	assert.Equal(t, uint32(0), c.BlockHeight())
+	assert.Equal(t, block.Hash(), c.CurrentHeaderHash())
+	assert.False(t, c.HasBlock(block.Hash()))
+}
+
+func TestGetUnspent(t *testing.T) {
+	c := NewChain()
+	output := transaction.NewOutput(util.Uint256{1}, util.NewFixed8(1), util.Uint160{2})
+	tx := newContractTX(output)
+	assert.Nil(t, c.AddBlock(newBlock(0, tx)))
+
+	assert.Nil(t, c.GetUnspent(tx.Hash(), 0))
+	c.UnspentCoins[tx.Hash()] = core.NewUnspentCoinState(1)
+	assert.Equal(t, output, c.GetUnspent(tx.Hash(), 0))
+	assert.Nil(t, c.GetUnspent(tx.Hash(), 1))
+}
+
+func TestGetStates(t *testing.T) {
+	c := NewChain()
+	hash := util.Uint160{1, 2, 3}
+
+	assert.Nil(t, c.GetAccountState(hash))
+	account := core.NewAccountState(hash)
+	c.Accounts[hash] = account
+	assert.Equal(t, account, c.GetAccountState(hash))
+
+	assert.Nil(t, c.GetStorageItem(hash, []byte{1}))
+	item := &core.StorageItem{Value: []byte{42}}
+	c.PutStorageItem(hash, []byte{1}, item)
+	assert.Equal(t, item, c.GetStorageItem(hash, []byte{1}))
+	assert.Nil(t, c.GetStorageItem(util.Uint160{3, 2, 1}, []byte{1}))
+}
+
+func TestPoolTx(t *testing.T) {
+	c := NewChain()
+	tx := newContractTX()
+
+	assert.Nil(t, c.PoolTx(tx))
+	assert.True(t, c.HasTransaction(tx.Hash()))
+	assert.NotNil(t, c.PoolTx(tx))
+}
+
+func TestSubscriptions(t *testing.T) {
+	c := NewChain()
+	tx := newContractTX()
+	block := newBlock(0, tx)
+	aer := &core.AppExecResult{
+		TxHash: tx.Hash(),
+		Events: []core.NotificationEvent{{ScriptHash: util.Uint160{1, 2, 3}}},
+	}
+	c.AppExecResults[tx.Hash()] = aer
+
+	blockCh := make(chan *core.Block, 1)
+	txCh := make(chan *transaction.Transaction, 1)
+	notificationCh := make(chan *core.ContractNotification, 1)
+	executionCh := make(chan *core.AppExecResult, 1)
+	c.SubscribeForBlocks(blockCh)
+	c.SubscribeForTransactions(txCh)
+	c.SubscribeForNotifications(notificationCh)
+	c.SubscribeForExecutions(executionCh)
+	assert.Nil(t, c.AddBlock(block))
+
+	assert.Equal(t, block, <-blockCh)
+	assert.Equal(t, tx, <-txCh)
+	assert.Equal(t, aer, <-executionCh)
+	assert.Equal(t, &core.ContractNotification{TxHash: tx.Hash(), NotificationEvent: aer.Events[0]}, <-notificationCh)
+
+	c.UnsubscribeFromBlocks(blockCh)
+	assert.Nil(t, c.AddBlock(newBlock(1)))
+	assert.Equal(t, 0, len(blockCh))
+}
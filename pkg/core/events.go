@@ -0,0 +1,370 @@
+package core
+
+import (
+	"sync"
+
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// Maximum number of events waiting to be sent to a subscriber, which is
+// disconnected once it's exceeded.
+var maxQueuedEvents = 10000
+
+// ContractNotification is a notification sent by a contract while executing
+// the transaction with the given hash.
+type ContractNotification struct {
+	TxHash util.Uint256
+	NotificationEvent
+}
+
+// chainEvent holds a persisted block together with the results of the
+// executions of its transactions.
+type chainEvent struct {
+	block   *Block
+	results map[util.Uint256]*AppExecResult
+}
+
+// subscriber queues the events of a subscribed channel, which are sent in
+// order by its own goroutine so that a slow subscriber never delays the
+// other ones.
+type subscriber struct {
+	// Protects the queue, held briefly by the persistence.
+	lock   sync.Mutex
+	queue  []interface{}
+	queued chan struct{}
+
+	// Closed to stop the subscriber, which closes done once stopped.
+	stop chan struct{}
+	done chan struct{}
+
+	// send sends an event to the channel, it returns false if the
+	// subscriber was stopped meanwhile.
+	send func(event interface{}) bool
+	// closeChan closes the channel.
+	closeChan func()
+}
+
+// newSubscriber returns a new subscriber and starts sending its events.
+func newSubscriber(send func(event interface{}, stop <-chan struct{}) bool, closeChan func()) *subscriber {
+	s := &subscriber{
+		queued:    make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		closeChan: closeChan,
+	}
+	s.send = func(event interface{}) bool { return send(event, s.stop) }
+	go s.run()
+	return s
+}
+
+// push queues the given events without blocking, it returns false if the
+// queue is full, in which case none of them is queued.
+func (s *subscriber) push(events ...interface{}) bool {
+	if len(events) == 0 {
+		return true
+	}
+	s.lock.Lock()
+	full := len(s.queue)+len(events) > maxQueuedEvents
+	if !full {
+		s.queue = append(s.queue, events...)
+	}
+	s.lock.Unlock()
+	if full {
+		return false
+	}
+	select {
+	case s.queued <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// run sends the queued events to the channel until the subscriber is
+// stopped.
+func (s *subscriber) run() {
+	defer close(s.done)
+	for {
+		select {
+		case <-s.queued:
+		case <-s.stop:
+			return
+		}
+		s.lock.Lock()
+		events := s.queue
+		s.queue = nil
+		s.lock.Unlock()
+		for _, event := range events {
+			if !s.send(event) {
+				return
+			}
+		}
+	}
+}
+
+// close stops the subscriber and waits for its goroutine to return, the
+// events that were not sent yet are dropped.
+func (s *subscriber) close() {
+	close(s.stop)
+	<-s.done
+}
+
+// disconnect stops the subscriber without waiting and closes its channel
+// once its goroutine returned.
+func (s *subscriber) disconnect() {
+	close(s.stop)
+	go func() {
+		<-s.done
+		s.closeChan()
+	}()
+}
+
+// eventDispatcher sends the persisted blocks, transactions, notifications and
+// execution results to the subscribed channels. Every subscriber has its own
+// queue of at most maxQueuedEvents events, so that slow subscribers never
+// block the persistence nor the other subscribers. A subscriber whose queue
+// is full is disconnected, its channel being closed.
+type eventDispatcher struct {
+	// Protects the subscribers, held briefly by the persistence.
+	subsLock         sync.Mutex
+	blockSubs        map[chan<- *Block]*subscriber
+	txSubs           map[chan<- *transaction.Transaction]*subscriber
+	notificationSubs map[chan<- *ContractNotification]*subscriber
+	executionSubs    map[chan<- *AppExecResult]*subscriber
+
+	// Closed once the dispatcher is closed.
+	done chan struct{}
+}
+
+// newEventDispatcher returns a new eventDispatcher.
+func newEventDispatcher() *eventDispatcher {
+	return &eventDispatcher{
+		blockSubs:        make(map[chan<- *Block]*subscriber),
+		txSubs:           make(map[chan<- *transaction.Transaction]*subscriber),
+		notificationSubs: make(map[chan<- *ContractNotification]*subscriber),
+		executionSubs:    make(map[chan<- *AppExecResult]*subscriber),
+		done:             make(chan struct{}),
+	}
+}
+
+// closed returns whether the dispatcher was closed, subsLock has to be held.
+func (d *eventDispatcher) closed() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// push queues the given block for the block subscribers, then its
+// transactions, their execution results and their notifications for their
+// subscribers. It never blocks, the subscribers whose queue is full are
+// disconnected.
+func (d *eventDispatcher) push(event chainEvent) {
+	var (
+		txs           = make([]interface{}, 0, len(event.block.Transactions))
+		executions    []interface{}
+		notifications []interface{}
+	)
+	for _, tx := range event.block.Transactions {
+		txs = append(txs, tx)
+		aer, ok := event.results[tx.Hash()]
+		if !ok {
+			continue
+		}
+		executions = append(executions, aer)
+		for i := range aer.Events {
+			notifications = append(notifications, &ContractNotification{
+				TxHash:            aer.TxHash,
+				NotificationEvent: aer.Events[i],
+			})
+		}
+	}
+
+	d.subsLock.Lock()
+	defer d.subsLock.Unlock()
+	for ch, s := range d.blockSubs {
+		if !s.push(event.block) {
+			delete(d.blockSubs, ch)
+			d.disconnect(s, event.block)
+		}
+	}
+	for ch, s := range d.txSubs {
+		if !s.push(txs...) {
+			delete(d.txSubs, ch)
+			d.disconnect(s, event.block)
+		}
+	}
+	for ch, s := range d.executionSubs {
+		if !s.push(executions...) {
+			delete(d.executionSubs, ch)
+			d.disconnect(s, event.block)
+		}
+	}
+	for ch, s := range d.notificationSubs {
+		if !s.push(notifications...) {
+			delete(d.notificationSubs, ch)
+			d.disconnect(s, event.block)
+		}
+	}
+}
+
+// disconnect disconnects the given subscriber, whose queue can't hold the
+// events of the given block.
+func (d *eventDispatcher) disconnect(s *subscriber, block *Block) {
+	log.Warnf("subscriber disconnected at block %d, too many events are waiting to be sent to it", block.Index)
+	s.disconnect()
+}
+
+// close stops the subscribers, the events that were not sent yet are
+// dropped.
+func (d *eventDispatcher) close() {
+	d.subsLock.Lock()
+	defer d.subsLock.Unlock()
+	if d.closed() {
+		return
+	}
+	close(d.done)
+	for ch, s := range d.blockSubs {
+		delete(d.blockSubs, ch)
+		s.close()
+	}
+	for ch, s := range d.txSubs {
+		delete(d.txSubs, ch)
+		s.close()
+	}
+	for ch, s := range d.notificationSubs {
+		delete(d.notificationSubs, ch)
+		s.close()
+	}
+	for ch, s := range d.executionSubs {
+		delete(d.executionSubs, ch)
+		s.close()
+	}
+}
+
+// SubscribeForBlocks makes the given channel receive every block once it's
+// persisted. Every channel receives its events in order from its own queue,
+// so a channel that isn't read never delays the persistence nor the other
+// subscribers. A channel letting more
+// than maxQueuedEvents events wait is closed and unsubscribed.
+func (bc *Blockchain) SubscribeForBlocks(ch chan<- *Block) {
+	bc.events.subsLock.Lock()
+	defer bc.events.subsLock.Unlock()
+	if bc.events.closed() || bc.events.blockSubs[ch] != nil {
+		return
+	}
+	bc.events.blockSubs[ch] = newSubscriber(func(event interface{}, stop <-chan struct{}) bool {
+		select {
+		case ch <- event.(*Block):
+			return true
+		case <-stop:
+			return false
+		}
+	}, func() { close(ch) })
+}
+
+// SubscribeForTransactions makes the given channel receive every transaction
+// once the block including it is persisted, see SubscribeForBlocks.
+func (bc *Blockchain) SubscribeForTransactions(ch chan<- *transaction.Transaction) {
+	bc.events.subsLock.Lock()
+	defer bc.events.subsLock.Unlock()
+	if bc.events.closed() || bc.events.txSubs[ch] != nil {
+		return
+	}
+	bc.events.txSubs[ch] = newSubscriber(func(event interface{}, stop <-chan struct{}) bool {
+		select {
+		case ch <- event.(*transaction.Transaction):
+			return true
+		case <-stop:
+			return false
+		}
+	}, func() { close(ch) })
+}
+
+// SubscribeForNotifications makes the given channel receive every
+// notification sent by the contracts invoked by the successful invocation
+// transactions once they're persisted, see SubscribeForBlocks.
+func (bc *Blockchain) SubscribeForNotifications(ch chan<- *ContractNotification) {
+	bc.events.subsLock.Lock()
+	defer bc.events.subsLock.Unlock()
+	if bc.events.closed() || bc.events.notificationSubs[ch] != nil {
+		return
+	}
+	bc.events.notificationSubs[ch] = newSubscriber(func(event interface{}, stop <-chan struct{}) bool {
+		select {
+		case ch <- event.(*ContractNotification):
+			return true
+		case <-stop:
+			return false
+		}
+	}, func() { close(ch) })
+}
+
+// SubscribeForExecutions makes the given channel receive the result of every
+// invocation transaction once it's persisted, see SubscribeForBlocks.
+func (bc *Blockchain) SubscribeForExecutions(ch chan<- *AppExecResult) {
+	bc.events.subsLock.Lock()
+	defer bc.events.subsLock.Unlock()
+	if bc.events.closed() || bc.events.executionSubs[ch] != nil {
+		return
+	}
+	bc.events.executionSubs[ch] = newSubscriber(func(event interface{}, stop <-chan struct{}) bool {
+		select {
+		case ch <- event.(*AppExecResult):
+			return true
+		case <-stop:
+			return false
+		}
+	}, func() { close(ch) })
+}
+
+// UnsubscribeFromBlocks stops sending blocks to the given channel, which
+// isn't closed. The events that were not sent yet are dropped.
+func (bc *Blockchain) UnsubscribeFromBlocks(ch chan<- *Block) {
+	bc.events.subsLock.Lock()
+	s := bc.events.blockSubs[ch]
+	delete(bc.events.blockSubs, ch)
+	bc.events.subsLock.Unlock()
+	if s != nil {
+		s.close()
+	}
+}
+
+// UnsubscribeFromTransactions stops sending transactions to the given
+// channel, see UnsubscribeFromBlocks.
+func (bc *Blockchain) UnsubscribeFromTransactions(ch chan<- *transaction.Transaction) {
+	bc.events.subsLock.Lock()
+	s := bc.events.txSubs[ch]
+	delete(bc.events.txSubs, ch)
+	bc.events.subsLock.Unlock()
+	if s != nil {
+		s.close()
+	}
+}
+
+// UnsubscribeFromNotifications stops sending notifications to the given
+// channel, see UnsubscribeFromBlocks.
+func (bc *Blockchain) UnsubscribeFromNotifications(ch chan<- *ContractNotification) {
+	bc.events.subsLock.Lock()
+	s := bc.events.notificationSubs[ch]
+	delete(bc.events.notificationSubs, ch)
+	bc.events.subsLock.Unlock()
+	if s != nil {
+		s.close()
+	}
+}
+
+// UnsubscribeFromExecutions stops sending execution results to the given
+// channel, see UnsubscribeFromBlocks.
+func (bc *Blockchain) UnsubscribeFromExecutions(ch chan<- *AppExecResult) {
+	bc.events.subsLock.Lock()
+	s := bc.events.executionSubs[ch]
+	delete(bc.events.executionSubs, ch)
+	bc.events.subsLock.Unlock()
+	if s != nil {
+		s.close()
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptions(t *testing.T) {
+	bc := newTestChain(t)
+	token := newTokenScript(t)
+	tokenHash, err := util.Uint160FromScript(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unbuffered channels check that persisting doesn't wait for them to be
+	// read.
+	blockCh := make(chan *Block)
+	txCh := make(chan *transaction.Transaction)
+	notificationCh := make(chan *ContractNotification)
+	executionCh := make(chan *AppExecResult)
+	bc.SubscribeForBlocks(blockCh)
+	bc.SubscribeForTransactions(txCh)
+	bc.SubscribeForNotifications(notificationCh)
+	bc.SubscribeForExecutions(executionCh)
+
+	publish := newPublishTX(token)
+	mint := newTransferInvocationTX(t, tokenHash, util.Uint160{}, util.Uint160{1, 2, 3}, 100)
+	block1 := newBlock(1, publish, mint)
+	assert.Nil(t, bc.persistBlock(block1))
+	block2 := newBlock(2)
+	assert.Nil(t, bc.persistBlock(block2))
+
+	timeout := func() <-chan time.Time { return time.After(time.Second) }
+	for _, expected := range []*Block{block1, block2} {
+		select {
+		case b := <-blockCh:
+			assert.Equal(t, expected, b)
+		case <-timeout():
+			t.Fatalf("block %d not received", expected.Index)
+		}
+	}
+	for _, expected := range []*transaction.Transaction{publish, mint} {
+		select {
+		case tx := <-txCh:
+			assert.Equal(t, expected, tx)
+		case <-timeout():
+			t.Fatalf("transaction %s not received", expected.Hash())
+		}
+	}
+	select {
+	case aer := <-executionCh:
+		assert.Equal(t, mint.Hash(), aer.TxHash)
+	case <-timeout():
+		t.Fatal("execution result not received")
+	}
+	select {
+	case n := <-notificationCh:
+		assert.Equal(t, mint.Hash(), n.TxHash)
+		assert.Equal(t, tokenHash, n.ScriptHash)
+	case <-timeout():
+		t.Fatal("notification not received")
+	}
+
+	bc.UnsubscribeFromBlocks(blockCh)
+	bc.UnsubscribeFromTransactions(txCh)
+	bc.UnsubscribeFromNotifications(notificationCh)
+	bc.UnsubscribeFromExecutions(executionCh)
+	assert.Nil(t, bc.persistBlock(newBlock(3)))
+	select {
+	case b := <-blockCh:
+		t.Fatalf("unexpected block %d", b.Index)
+	case tx := <-txCh:
+		t.Fatalf("unexpected transaction %s", tx.Hash())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSlowSubscriberDisconnected(t *testing.T) {
+	limit := maxQueuedEvents
+	maxQueuedEvents = 3
+	defer func() { maxQueuedEvents = limit }()
+
+	bc := newTestChain(t)
+	slow := make(chan *Block)
+	fast := make(chan *Block, 10)
+	bc.SubscribeForBlocks(slow)
+	bc.SubscribeForBlocks(fast)
+
+	// The slow subscriber isn't read, its queue is filled by the blocks
+	// following the one being sent to it.
+	for i := uint32(1); i <= 5; i++ {
+		assert.Nil(t, bc.persistBlock(newBlock(i)))
+		select {
+		case b := <-fast:
+			assert.Equal(t, i, b.Index)
+		case <-time.After(time.Second):
+			t.Fatalf("block %d not received", i)
+		}
+	}
+
+	// The slow subscriber was disconnected, the blocks queued for it
+	// being dropped, the one being sent may still be received.
+	for received := 0; ; received++ {
+		select {
+		case b, ok := <-slow:
+			if ok {
+				assert.Equal(t, uint32(1), b.Index)
+				assert.Equal(t, 0, received)
+				continue
+			}
+		case <-time.After(time.Second):
+			t.Fatal("slow subscriber not disconnected")
+		}
+		break
+	}
+	bc.events.subsLock.Lock()
+	assert.Equal(t, 1, len(bc.events.blockSubs))
+	bc.events.subsLock.Unlock()
+}
+
+func TestSubscriberQueueLimit(t *testing.T) {
+	// The subscriber isn't started, its queue is only filled.
+	s := &subscriber{queued: make(chan struct{}, 1)}
+	for i := 0; i < maxQueuedEvents-1; i++ {
+		assert.True(t, s.push(i))
+	}
+	assert.False(t, s.push(1, 2))
+	assert.True(t, s.push(1))
+	assert.False(t, s.push(2))
+	assert.True(t, s.push())
+	assert.Equal(t, maxQueuedEvents, len(s.queue))
+}
@@ -7,13 +7,131 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
 	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+	"github.com/CityOfZion/neo-go/pkg/wallet"
 )
 
+// privNetKeys are the WIFs of the privnet standby validators.
+var privNetKeys = []string{
+	"KxyjQ8eUa4FHt3Gvioyt1Wz29cTUrE4eTqX3yFSk1YFCsPL8uNsY",
+	"KzfPUYDC9n2yf4fK5ro4C8KMcdeXtFuEnStycbZgX3GomiUsvX6W",
+	"KzgWE3u3EDp13XPXXuTKZxeJ3Gi8Bsm8f9ijY3ZsCKKRvZUo1Cdn",
+	"L2oEXKRAAMiPEZukwR5ho2S6SMeQLhcK9mF71ZnF7GvT8dU4Kkgz",
+}
+
+// validatorKeys caches the decoded privnet validator keys.
+var validatorKeys []validatorKey
+
+// validatorKey couples a privnet validator private key with its public key.
+type validatorKey struct {
+	priv *wallet.PrivateKey
+	pub  *crypto.PublicKey
+}
+
+// getValidatorKeys returns the privnet validator keys sorted in the order
+// their public keys appear in the multi signature contract.
+func getValidatorKeys(t *testing.T) []validatorKey {
+	if validatorKeys != nil {
+		return validatorKeys
+	}
+	keys := make([]validatorKey, len(privNetKeys))
+	for i, wif := range privNetKeys {
+		priv, err := wallet.NewPrivateKeyFromWIF(wif)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := priv.PublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub := &crypto.PublicKey{}
+		if err := pub.DecodeBytes(b); err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = validatorKey{priv, pub}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return crypto.PublicKeys{keys[i].pub, keys[j].pub}.Less(0, 1)
+	})
+	validatorKeys = keys
+	return keys
+}
+
+// signWithValidators creates a witness for the given data signed by the
+// majority of the privnet validators.
+func signWithValidators(t *testing.T, data []byte) *transaction.Witness {
+	keys := getValidatorKeys(t)
+	pubs := make(crypto.PublicKeys, len(keys))
+	for i := range keys {
+		pubs[i] = keys[i].pub
+	}
+	n := len(keys)
+	verification, err := smartcontract.CreateMultiSigRedeemScript(n-(n-1)/3, pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invocation := new(bytes.Buffer)
+	for _, key := range keys[:n-(n-1)/3] {
+		sig, err := key.priv.Sign(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := vm.EmitBytes(invocation, sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &transaction.Witness{
+		InvocationScript:   invocation.Bytes(),
+		VerificationScript: verification,
+	}
+}
+
+// newSignedBlock returns a valid block following the given one, signed by
+// the privnet validators.
+func newSignedBlock(t *testing.T, bc *Blockchain, prev *Header, txs ...*transaction.Transaction) *Block {
+	minerTX := &transaction.Transaction{
+		Type: transaction.MinerType,
+		Data: &transaction.MinerTX{Nonce: prev.Index + 1},
+	}
+	txs = append([]*transaction.Transaction{minerTX}, txs...)
+	nextConsensus, err := bc.getNextConsensus(txs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Block{
+		BlockBase: BlockBase{
+			Version:       0,
+			PrevHash:      prev.Hash(),
+			Timestamp:     prev.Timestamp + secondsPerBlock,
+			Index:         prev.Index + 1,
+			ConsensusData: 1111,
+			NextConsensus: nextConsensus,
+		},
+		Transactions: txs,
+	}
+	if err := b.rebuildMerkleRoot(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.createHash(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := b.GetHashableData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Script = signWithValidators(t, data)
+	return b
+}
+
 func newBlock(index uint32, txs ...*transaction.Transaction) *Block {
 	b := &Block{
 		BlockBase: BlockBase{
@@ -0,0 +1,298 @@
+package core
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+)
+
+// TriggerType is the type of the trigger of a script execution.
+type TriggerType byte
+
+// Trigger types of the script executions.
+const (
+	TriggerVerification TriggerType = 0x00
+	TriggerApplication  TriggerType = 0x10
+)
+
+// String implements the Stringer interface.
+func (t TriggerType) String() string {
+	switch t {
+	case TriggerVerification:
+		return "Verification"
+	case TriggerApplication:
+		return "Application"
+	default:
+		return "Unknown"
+	}
+}
+
+// StorageContext is the handle to the storage of a contract used by the
+// Storage interop functions.
+type StorageContext struct {
+	ScriptHash util.Uint160
+	IsReadOnly bool
+}
+
+// interopContext is the environment of a script execution. The scripts
+// read the state of the chain, their own changes are kept here and are
+// only visible to the chain once committed.
+type interopContext struct {
+	bc      *Blockchain
+	trigger TriggerType
+	// The block being persisted, nil for the test invocations.
+	block *Block
+	// The script container, nil when the script doesn't run in a transaction.
+	tx *transaction.Transaction
+
+	// The snapshot the script changes the states in.
+	store *storage.MemCachedStore
+	// The contracts created by the script.
+	created map[util.Uint160]bool
+
+	notifications []NotificationEvent
+}
+
+// newInteropContext returns a new interopContext for the scripts running
+// with the given trigger in the given block and transaction.
+func newInteropContext(trigger TriggerType, bc *Blockchain, block *Block, tx *transaction.Transaction) *interopContext {
+	return &interopContext{
+		bc:      bc,
+		trigger: trigger,
+		block:   block,
+		tx:      tx,
+		store:   storage.NewMemCachedStore(bc.Store),
+		created: make(map[util.Uint160]bool),
+	}
+}
+
+// newVM returns a new VM with all the interop functions bound to ic.
+func (ic *interopContext) newVM() *vm.VM {
+	v := vm.New(vm.ModeMute)
+	v.SetScriptGetter(func(hash util.Uint160) []byte {
+		cs := ic.getContract(hash)
+		if cs == nil {
+			return nil
+		}
+		return cs.Script
+	})
+	if ic.tx != nil {
+		if data, err := ic.tx.GetHashableData(); err == nil {
+			h := sha256.Sum256(data)
+			v.SetCheckedHash(h[:])
+		}
+	}
+	for name, f := range ic.getInteropFuncs() {
+		v.RegisterInteropFunc(name, f.f, f.price)
+	}
+	return v
+}
+
+// interopFuncPrice is an interop function along with the price of its calls.
+// The functions with a zero price charge the execution depending on their
+// arguments.
+type interopFuncPrice struct {
+	f     vm.InteropFunc
+	price int
+}
+
+// Prices of the interop functions, in units of 0.001 GAS.
+const (
+	assetCreatePrice           = 5000 * 1000
+	assetRenewPricePerYear     = 5000 * 1000
+	contractCreatePrice        = 100 * 1000
+	contractStoragePrice       = 400 * 1000
+	contractDynamicInvokePrice = 500 * 1000
+	storagePutPricePerKB       = 1000
+)
+
+// getInteropFuncs returns the interop functions bound to ic by their names.
+func (ic *interopContext) getInteropFuncs() map[string]interopFuncPrice {
+	return map[string]interopFuncPrice{
+		"System.ExecutionEngine.GetScriptContainer":     {ic.engineGetScriptContainer, 1},
+		"System.ExecutionEngine.GetExecutingScriptHash": {ic.engineGetExecutingScriptHash, 1},
+		"System.ExecutionEngine.GetCallingScriptHash":   {ic.engineGetCallingScriptHash, 1},
+		"System.ExecutionEngine.GetEntryScriptHash":     {ic.engineGetEntryScriptHash, 1},
+
+		"Neo.Runtime.GetTrigger":      {ic.runtimeGetTrigger, 1},
+		"Neo.Runtime.CheckWitness":    {ic.runtimeCheckWitness, 200},
+		"Neo.Runtime.Notify":          {ic.runtimeNotify, 1},
+		"Neo.Runtime.Log":             {ic.runtimeLog, 1},
+		"Neo.Runtime.GetTime":         {ic.runtimeGetTime, 1},
+		"Neo.Runtime.GetCurrentBlock": {ic.runtimeGetCurrentBlock, 1},
+		"Neo.Runtime.Serialize":       {runtimeSerialize, 1},
+		"Neo.Runtime.Deserialize":     {runtimeDeserialize, 1},
+
+		"Neo.Blockchain.GetHeight":            {ic.bcGetHeight, 1},
+		"Neo.Blockchain.GetHeader":            {ic.bcGetHeader, 100},
+		"Neo.Blockchain.GetBlock":             {ic.bcGetBlock, 200},
+		"Neo.Blockchain.GetTransaction":       {ic.bcGetTransaction, 100},
+		"Neo.Blockchain.GetTransactionHeight": {ic.bcGetTransactionHeight, 100},
+		"Neo.Blockchain.GetAccount":           {ic.bcGetAccount, 100},
+		"Neo.Blockchain.GetAsset":             {ic.bcGetAsset, 100},
+		"Neo.Blockchain.GetContract":          {ic.bcGetContract, 100},
+		"Neo.Blockchain.GetValidators":        {ic.bcGetValidators, 200},
+
+		"Neo.Header.GetIndex":         {headerGetIndex, 1},
+		"Neo.Header.GetHash":          {headerGetHash, 1},
+		"Neo.Header.GetVersion":       {headerGetVersion, 1},
+		"Neo.Header.GetPrevHash":      {headerGetPrevHash, 1},
+		"Neo.Header.GetMerkleRoot":    {headerGetMerkleRoot, 1},
+		"Neo.Header.GetTimestamp":     {headerGetTimestamp, 1},
+		"Neo.Header.GetConsensusData": {headerGetConsensusData, 1},
+		"Neo.Header.GetNextConsensus": {headerGetNextConsensus, 1},
+
+		"Neo.Block.GetTransactionCount": {blockGetTransactionCount, 1},
+		"Neo.Block.GetTransactions":     {blockGetTransactions, 1},
+		"Neo.Block.GetTransaction":      {blockGetTransaction, 1},
+
+		"Neo.Transaction.GetHash":             {txGetHash, 1},
+		"Neo.Transaction.GetType":             {txGetType, 1},
+		"Neo.Transaction.GetAttributes":       {txGetAttributes, 1},
+		"Neo.Transaction.GetInputs":           {txGetInputs, 1},
+		"Neo.Transaction.GetOutputs":          {txGetOutputs, 1},
+		"Neo.Transaction.GetReferences":       {ic.txGetReferences, 200},
+		"Neo.Transaction.GetUnspentCoins":     {ic.txGetUnspentCoins, 200},
+		"Neo.Transaction.GetWitnesses":        {ic.txGetWitnesses, 200},
+		"Neo.Witness.GetVerificationScript":   {witnessGetVerificationScript, 100},
+		"Neo.InvocationTransaction.GetScript": {invocationTxGetScript, 1},
+		"Neo.Attribute.GetUsage":              {attrGetUsage, 1},
+		"Neo.Attribute.GetData":               {attrGetData, 1},
+		"Neo.Input.GetHash":                   {inputGetHash, 1},
+		"Neo.Input.GetIndex":                  {inputGetIndex, 1},
+		"Neo.Output.GetAssetId":               {outputGetAssetID, 1},
+		"Neo.Output.GetValue":                 {outputGetValue, 1},
+		"Neo.Output.GetScriptHash":            {outputGetScriptHash, 1},
+		"Neo.Account.GetScriptHash":           {accountGetScriptHash, 1},
+		"Neo.Account.GetVotes":                {accountGetVotes, 1},
+		"Neo.Account.GetBalance":              {accountGetBalance, 1},
+		"Neo.Asset.Create":                    {ic.assetCreate, assetCreatePrice},
+		"Neo.Asset.Renew":                     {ic.assetRenew, 0},
+		"Neo.Asset.GetAssetId":                {assetGetAssetID, 1},
+		"Neo.Asset.GetAssetType":              {assetGetAssetType, 1},
+		"Neo.Asset.GetAmount":                 {assetGetAmount, 1},
+		"Neo.Asset.GetAvailable":              {assetGetAvailable, 1},
+		"Neo.Asset.GetPrecision":              {assetGetPrecision, 1},
+		"Neo.Asset.GetOwner":                  {assetGetOwner, 1},
+		"Neo.Asset.GetAdmin":                  {assetGetAdmin, 1},
+		"Neo.Asset.GetIssuer":                 {assetGetIssuer, 1},
+		"Neo.Contract.Create":                 {ic.contractCreate, 0},
+		"Neo.Contract.Migrate":                {ic.contractMigrate, 0},
+		"Neo.Contract.Destroy":                {ic.contractDestroy, 1},
+		"Neo.Contract.GetScript":              {contractGetScript, 1},
+		"Neo.Contract.IsPayable":              {contractIsPayable, 1},
+		"Neo.Contract.GetStorageContext":      {ic.contractGetStorageContext, 1},
+		"Neo.Storage.GetContext":              {ic.storageGetContext, 1},
+		"Neo.Storage.GetReadOnlyContext":      {ic.storageGetReadOnlyContext, 1},
+		"Neo.Storage.Get":                     {ic.storageGet, 100},
+		"Neo.Storage.Put":                     {ic.storagePut, 0},
+		"Neo.Storage.Delete":                  {ic.storageDelete, 100},
+		"Neo.Storage.Find":                    {ic.storageFind, 1},
+		"Neo.StorageContext.AsReadOnly":       {storageContextAsReadOnly, 1},
+		"Neo.Enumerator.Create":               {enumeratorCreate, 1},
+		"Neo.Enumerator.Next":                 {enumeratorNext, 1},
+		"Neo.Enumerator.Value":                {enumeratorValue, 1},
+		"Neo.Enumerator.Concat":               {enumeratorConcat, 1},
+		"Neo.Iterator.Create":                 {iteratorCreate, 1},
+		"Neo.Iterator.Next":                   {enumeratorNext, 1},
+		"Neo.Iterator.Value":                  {enumeratorValue, 1},
+		"Neo.Iterator.Key":                    {iteratorKey, 1},
+		"Neo.Iterator.Keys":                   {iteratorKeys, 1},
+		"Neo.Iterator.Values":                 {iteratorValues, 1},
+	}
+}
+
+// getContract returns the state of the contract with the given script hash
+// as seen by the script, or nil if there is no such contract.
+func (ic *interopContext) getContract(hash util.Uint160) *ContractState {
+	return getContractState(ic.store, hash)
+}
+
+// getAsset returns the state of the asset with the given ID as seen by the
+// script, or nil if there is no such asset.
+func (ic *interopContext) getAsset(id util.Uint256) *AssetState {
+	asset, err := getAssetState(ic.store, id)
+	if err != nil {
+		return nil
+	}
+	return asset
+}
+
+// getStorageItem returns the item stored by the given contract under the
+// given key as seen by the script, or nil if there is no such item.
+func (ic *interopContext) getStorageItem(scriptHash util.Uint160, key []byte) *StorageItem {
+	return getStorageItem(ic.store, scriptHash, key)
+}
+
+// checkHashedWitness returns true if the script container is signed by the
+// given script hash.
+func (ic *interopContext) checkHashedWitness(hash util.Uint160) (bool, error) {
+	if ic.tx == nil {
+		return false, errors.New("no script container")
+	}
+	hashes, err := ic.bc.GetScriptHashesForVerifying(ic.tx)
+	if err != nil {
+		return false, err
+	}
+	for _, h := range hashes {
+		if h.Equals(hash) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkKeyedWitness returns true if the script container is signed by the
+// given public key.
+func (ic *interopContext) checkKeyedWitness(key *crypto.PublicKey) (bool, error) {
+	hash, err := signatureContractHash(key)
+	if err != nil {
+		return false, err
+	}
+	return ic.checkHashedWitness(hash)
+}
+
+// popInteropValue pops the top element of the evaluation stack, which has
+// to be an interop item, and returns the value it holds.
+func popInteropValue(v *vm.VM) (interface{}, error) {
+	item, ok := v.Estack().Pop().Item().(*vm.InteropItem)
+	if !ok {
+		return nil, errors.New("not an interop item")
+	}
+	return item.Value(), nil
+}
+
+// popUint160 pops the top element of the evaluation stack as a script hash.
+func popUint160(v *vm.VM) (util.Uint160, error) {
+	return util.Uint160DecodeBytes(v.Estack().Pop().Bytes())
+}
+
+// popUint256 pops the top element of the evaluation stack as a hash. The
+// hashes are pushed in the little endian order.
+func popUint256(v *vm.VM) (util.Uint256, error) {
+	return util.Uint256DecodeBytes(util.ArrayReverse(v.Estack().Pop().Bytes()))
+}
+
+// popPublicKey pops the top element of the evaluation stack as a public key.
+func popPublicKey(v *vm.VM) (*crypto.PublicKey, error) {
+	key := &crypto.PublicKey{}
+	if err := key.DecodeBytes(v.Estack().Pop().Bytes()); err != nil {
+		return nil, fmt.Errorf("invalid public key: %s", err)
+	}
+	return key, nil
+}
+
+// pushInteropArray pushes an array holding the given interop values.
+func pushInteropArray(v *vm.VM, n int, value func(i int) interface{}) {
+	items := make([]vm.StackItem, n)
+	for i := range items {
+		items[i] = vm.NewInteropItem(value(i))
+	}
+	v.Estack().PushVal(items)
+}
@@ -0,0 +1,236 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/CityOfZion/neo-go/pkg/vm"
+)
+
+// enumerator is a cursor over a sequence of values, positioned before the
+// first one until Next is called. Value panics when the enumerator isn't
+// positioned on a value, which faults the VM.
+type enumerator interface {
+	Next() bool
+	Value() vm.StackItem
+}
+
+// iterator is an enumerator over a sequence of keys and values, Key
+// panicking like Value.
+type iterator interface {
+	enumerator
+	Key() vm.StackItem
+}
+
+// arrayWrapper iterates over the items of an array, their keys being their
+// indexes.
+type arrayWrapper struct {
+	index int
+	items []vm.StackItem
+}
+
+func (a *arrayWrapper) Next() bool {
+	if a.index < len(a.items) {
+		a.index++
+	}
+	return a.index < len(a.items)
+}
+
+func (a *arrayWrapper) Value() vm.StackItem {
+	return a.items[a.index]
+}
+
+func (a *arrayWrapper) Key() vm.StackItem {
+	return vm.NewBigIntegerItem(a.index)
+}
+
+// mapWrapper iterates over the elements of a map.
+type mapWrapper struct {
+	index    int
+	elements []vm.MapElement
+}
+
+func (m *mapWrapper) Next() bool {
+	if m.index < len(m.elements) {
+		m.index++
+	}
+	return m.index < len(m.elements)
+}
+
+func (m *mapWrapper) Value() vm.StackItem {
+	return m.elements[m.index].Value
+}
+
+func (m *mapWrapper) Key() vm.StackItem {
+	return m.elements[m.index].Key
+}
+
+// concatEnumerator enumerates the values of an enumerator, then the ones of
+// another one.
+type concatEnumerator struct {
+	current enumerator
+	second  enumerator
+}
+
+func (c *concatEnumerator) Next() bool {
+	if c.current.Next() {
+		return true
+	}
+	c.current = c.second
+	return c.current.Next()
+}
+
+func (c *concatEnumerator) Value() vm.StackItem {
+	return c.current.Value()
+}
+
+// keysWrapper enumerates the keys of an iterator.
+type keysWrapper struct {
+	it iterator
+}
+
+func (k keysWrapper) Next() bool {
+	return k.it.Next()
+}
+
+func (k keysWrapper) Value() vm.StackItem {
+	return k.it.Key()
+}
+
+// valuesWrapper enumerates the values of an iterator.
+type valuesWrapper struct {
+	it iterator
+}
+
+func (w valuesWrapper) Next() bool {
+	return w.it.Next()
+}
+
+func (w valuesWrapper) Value() vm.StackItem {
+	return w.it.Value()
+}
+
+// newIterator returns an iterator over the given array or map, positioned
+// before its first element.
+func newIterator(item vm.StackItem) (iterator, error) {
+	switch t := item.Value().(type) {
+	case []vm.StackItem:
+		return &arrayWrapper{index: -1, items: t}, nil
+	case []vm.MapElement:
+		return &mapWrapper{index: -1, elements: t}, nil
+	default:
+		return nil, errors.New("not an array nor a map")
+	}
+}
+
+// popEnumerator pops the top element of the evaluation stack as an
+// enumerator, iterators being enumerators too.
+func popEnumerator(v *vm.VM) (enumerator, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	e, ok := value.(enumerator)
+	if !ok {
+		return nil, errors.New("not an enumerator")
+	}
+	return e, nil
+}
+
+// popIterator pops the top element of the evaluation stack as an iterator.
+func popIterator(v *vm.VM) (iterator, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	it, ok := value.(iterator)
+	if !ok {
+		return nil, errors.New("not an iterator")
+	}
+	return it, nil
+}
+
+// enumeratorCreate pushes an enumerator over the items of the given array.
+func enumeratorCreate(v *vm.VM) error {
+	items, ok := v.Estack().Pop().Item().Value().([]vm.StackItem)
+	if !ok {
+		return errors.New("not an array")
+	}
+	v.Estack().PushVal(vm.NewInteropItem(&arrayWrapper{index: -1, items: items}))
+	return nil
+}
+
+// enumeratorNext moves the enumerator to its next value and pushes whether
+// there is one.
+func enumeratorNext(v *vm.VM) error {
+	e, err := popEnumerator(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(e.Next())
+	return nil
+}
+
+// enumeratorValue pushes the current value of the enumerator.
+func enumeratorValue(v *vm.VM) error {
+	e, err := popEnumerator(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(e.Value())
+	return nil
+}
+
+// enumeratorConcat pushes an enumerator over the values of the two given
+// ones.
+func enumeratorConcat(v *vm.VM) error {
+	first, err := popEnumerator(v)
+	if err != nil {
+		return err
+	}
+	second, err := popEnumerator(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(vm.NewInteropItem(&concatEnumerator{current: first, second: second}))
+	return nil
+}
+
+// iteratorCreate pushes an iterator over the given array or map.
+func iteratorCreate(v *vm.VM) error {
+	it, err := newIterator(v.Estack().Pop().Item())
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(vm.NewInteropItem(it))
+	return nil
+}
+
+// iteratorKey pushes the current key of the iterator.
+func iteratorKey(v *vm.VM) error {
+	it, err := popIterator(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(it.Key())
+	return nil
+}
+
+// iteratorKeys pushes an enumerator over the keys of the iterator.
+func iteratorKeys(v *vm.VM) error {
+	it, err := popIterator(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(vm.NewInteropItem(keysWrapper{it}))
+	return nil
+}
+
+// iteratorValues pushes an enumerator over the values of the iterator.
+func iteratorValues(v *vm.VM) error {
+	it, err := popIterator(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(vm.NewInteropItem(valuesWrapper{it}))
+	return nil
+}
@@ -0,0 +1,586 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+)
+
+// Limits and constants of the contracts and assets registered by the
+// scripts, as in the reference implementation.
+const (
+	maxContractScriptSize = 1024 * 1024
+	maxContractParamsNum  = 252
+	maxContractStringLen  = 252
+	maxContractDescLen    = 65536
+	maxAssetNameLen       = 1024
+	maxAssetPrecision     = 8
+	blocksPerYear         = 2000000
+)
+
+// attrGetUsage pushes the usage of the attribute.
+func attrGetUsage(v *vm.VM) error {
+	attr, err := popAttribute(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(int(attr.Usage))
+	return nil
+}
+
+// attrGetData pushes the data of the attribute.
+func attrGetData(v *vm.VM) error {
+	attr, err := popAttribute(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(attr.Data)
+	return nil
+}
+
+func popAttribute(v *vm.VM) (*transaction.Attribute, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	attr, ok := value.(*transaction.Attribute)
+	if !ok {
+		return nil, errors.New("not an attribute")
+	}
+	return attr, nil
+}
+
+// inputGetHash pushes the hash of the transaction referenced by the input.
+func inputGetHash(v *vm.VM) error {
+	input, err := popInput(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(input.PrevHash.BytesReverse())
+	return nil
+}
+
+// inputGetIndex pushes the index of the output referenced by the input.
+func inputGetIndex(v *vm.VM) error {
+	input, err := popInput(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(int(input.PrevIndex))
+	return nil
+}
+
+func popInput(v *vm.VM) (*transaction.Input, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	input, ok := value.(*transaction.Input)
+	if !ok {
+		return nil, errors.New("not an input")
+	}
+	return input, nil
+}
+
+// outputGetAssetID pushes the ID of the asset of the output.
+func outputGetAssetID(v *vm.VM) error {
+	output, err := popOutput(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(output.AssetID.BytesReverse())
+	return nil
+}
+
+// outputGetValue pushes the amount of the output.
+func outputGetValue(v *vm.VM) error {
+	output, err := popOutput(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(big.NewInt(int64(output.Amount)))
+	return nil
+}
+
+// outputGetScriptHash pushes the script hash of the receiver of the output.
+func outputGetScriptHash(v *vm.VM) error {
+	output, err := popOutput(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(output.ScriptHash.Bytes())
+	return nil
+}
+
+func popOutput(v *vm.VM) (*transaction.Output, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	output, ok := value.(*transaction.Output)
+	if !ok {
+		return nil, errors.New("not an output")
+	}
+	return output, nil
+}
+
+// accountGetScriptHash pushes the script hash of the account.
+func accountGetScriptHash(v *vm.VM) error {
+	account, err := popAccount(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(account.ScriptHash.Bytes())
+	return nil
+}
+
+// accountGetVotes pushes the public keys the account votes for.
+func accountGetVotes(v *vm.VM) error {
+	account, err := popAccount(v)
+	if err != nil {
+		return err
+	}
+	votes := make([]vm.StackItem, len(account.Votes))
+	for i, key := range account.Votes {
+		votes[i] = vm.NewByteArrayItem(key.Bytes())
+	}
+	v.Estack().PushVal(votes)
+	return nil
+}
+
+// accountGetBalance pushes the balance of the account in the given asset.
+func accountGetBalance(v *vm.VM) error {
+	account, err := popAccount(v)
+	if err != nil {
+		return err
+	}
+	id, err := popUint256(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(big.NewInt(int64(account.Balances[id])))
+	return nil
+}
+
+func popAccount(v *vm.VM) (*AccountState, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	account, ok := value.(*AccountState)
+	if !ok {
+		return nil, errors.New("not an account")
+	}
+	return account, nil
+}
+
+// assetGetAssetID pushes the ID of the asset.
+func assetGetAssetID(v *vm.VM) error {
+	asset, err := popAsset(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(asset.ID.BytesReverse())
+	return nil
+}
+
+// assetGetAssetType pushes the type of the asset.
+func assetGetAssetType(v *vm.VM) error {
+	asset, err := popAsset(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(int(asset.AssetType))
+	return nil
+}
+
+// assetGetAmount pushes the total amount of the asset.
+func assetGetAmount(v *vm.VM) error {
+	asset, err := popAsset(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(big.NewInt(int64(asset.Amount)))
+	return nil
+}
+
+// assetGetAvailable pushes the issued amount of the asset.
+func assetGetAvailable(v *vm.VM) error {
+	asset, err := popAsset(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(big.NewInt(int64(asset.Available)))
+	return nil
+}
+
+// assetGetPrecision pushes the precision of the asset.
+func assetGetPrecision(v *vm.VM) error {
+	asset, err := popAsset(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(int(asset.Precision))
+	return nil
+}
+
+// assetGetOwner pushes the public key of the owner of the asset.
+func assetGetOwner(v *vm.VM) error {
+	asset, err := popAsset(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(asset.Owner.Bytes())
+	return nil
+}
+
+// assetGetAdmin pushes the script hash of the admin of the asset.
+func assetGetAdmin(v *vm.VM) error {
+	asset, err := popAsset(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(asset.Admin.Bytes())
+	return nil
+}
+
+// assetGetIssuer pushes the script hash of the issuer of the asset.
+func assetGetIssuer(v *vm.VM) error {
+	asset, err := popAsset(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(asset.Issuer.Bytes())
+	return nil
+}
+
+func popAsset(v *vm.VM) (*AssetState, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	asset, ok := value.(*AssetState)
+	if !ok {
+		return nil, errors.New("not an asset")
+	}
+	return asset, nil
+}
+
+// assetCreate registers a new asset owned by the invocation transaction.
+func (ic *interopContext) assetCreate(v *vm.VM) error {
+	if ic.trigger != TriggerApplication {
+		return errors.New("assets can only be created by the application trigger")
+	}
+	if ic.tx == nil || ic.tx.Type != transaction.InvocationType {
+		return errors.New("assets can only be created by invocation transactions")
+	}
+	assetType := transaction.AssetType(v.Estack().Pop().BigInt().Int64())
+	switch assetType {
+	case transaction.CreditFlag, transaction.DutyFlag, transaction.GoverningToken, transaction.UtilityToken:
+		return fmt.Errorf("invalid asset type %s", assetType)
+	}
+	name := v.Estack().Pop().Bytes()
+	if len(name) > maxAssetNameLen {
+		return fmt.Errorf("asset name is too big: %d", len(name))
+	}
+	amount := util.Fixed8(v.Estack().Pop().BigInt().Int64())
+	if amount == 0 || amount < -1 {
+		return fmt.Errorf("invalid asset amount %s", amount)
+	}
+	if assetType == transaction.Invoice && amount != -1 {
+		return errors.New("invoices can't have a limited amount")
+	}
+	precision := v.Estack().Pop().BigInt().Int64()
+	if precision < 0 || precision > maxAssetPrecision {
+		return fmt.Errorf("invalid asset precision %d", precision)
+	}
+	if assetType == transaction.Share && precision != 0 {
+		return errors.New("shares can't be divisible")
+	}
+	if amount > 0 && int64(amount)%int64(math.Pow10(maxAssetPrecision-int(precision))) != 0 {
+		return errors.New("asset amount doesn't match its precision")
+	}
+	owner, err := popPublicKey(v)
+	if err != nil {
+		return err
+	}
+	ok, err := ic.checkKeyedWitness(owner)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("asset owner didn't sign the transaction")
+	}
+	admin, err := popUint160(v)
+	if err != nil {
+		return err
+	}
+	issuer, err := popUint160(v)
+	if err != nil {
+		return err
+	}
+
+	asset := &AssetState{
+		ID:         ic.tx.Hash(),
+		AssetType:  assetType,
+		Name:       string(name),
+		Amount:     amount,
+		Precision:  uint8(precision),
+		Owner:      owner,
+		Admin:      admin,
+		Issuer:     issuer,
+		Expiration: ic.bc.BlockHeight() + 1 + blocksPerYear,
+	}
+	if err := putAssetState(ic.store, asset); err != nil {
+		return err
+	}
+	v.Estack().PushVal(vm.NewInteropItem(asset))
+	return nil
+}
+
+// assetRenew extends the registration of the asset by the given number of
+// years and pushes its new expiration height.
+func (ic *interopContext) assetRenew(v *vm.VM) error {
+	if ic.trigger != TriggerApplication {
+		return errors.New("assets can only be renewed by the application trigger")
+	}
+	asset, err := popAsset(v)
+	if err != nil {
+		return err
+	}
+	years := v.Estack().Pop().BigInt().Int64()
+	if years < 0 || years > math.MaxUint8 {
+		return fmt.Errorf("invalid number of years %d", years)
+	}
+	if err := v.AddGas(int(years) * assetRenewPricePerYear); err != nil {
+		return err
+	}
+
+	// Keep the original asset untouched until the changes are committed.
+	renewed := *asset
+	if height := ic.bc.BlockHeight() + 1; renewed.Expiration < height {
+		renewed.Expiration = height
+	}
+	expiration := uint64(renewed.Expiration) + uint64(years)*blocksPerYear
+	if expiration > math.MaxUint32 {
+		expiration = math.MaxUint32
+	}
+	renewed.Expiration = uint32(expiration)
+	if err := putAssetState(ic.store, &renewed); err != nil {
+		return err
+	}
+	v.Estack().PushVal(int(renewed.Expiration))
+	return nil
+}
+
+// contractGetScript pushes the script of the contract.
+func contractGetScript(v *vm.VM) error {
+	cs, err := popContract(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(cs.Script)
+	return nil
+}
+
+func popContract(v *vm.VM) (*ContractState, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	cs, ok := value.(*ContractState)
+	if !ok {
+		return nil, errors.New("not a contract")
+	}
+	return cs, nil
+}
+
+// popLimitedBytes pops the top element of the evaluation stack as a byte
+// array of at most max bytes.
+func popLimitedBytes(v *vm.VM, max int) ([]byte, error) {
+	b := v.Estack().Pop().Bytes()
+	if len(b) > max {
+		return nil, fmt.Errorf("too big item: %d > %d", len(b), max)
+	}
+	return b, nil
+}
+
+// popContractState pops the fields of a contract created or migrated to,
+// charging the price of its properties.
+func popContractState(v *vm.VM) (*ContractState, error) {
+	script, err := popLimitedBytes(v, maxContractScriptSize)
+	if err != nil {
+		return nil, err
+	}
+	params, err := popLimitedBytes(v, maxContractParamsNum)
+	if err != nil {
+		return nil, err
+	}
+	paramList := make([]smartcontract.ParamType, len(params))
+	for i := range params {
+		paramList[i] = smartcontract.ParamType(params[i])
+	}
+	returnType := smartcontract.ParamType(v.Estack().Pop().BigInt().Int64())
+	properties := byte(v.Estack().Pop().BigInt().Int64())
+	price := contractCreatePrice
+	if properties&hasStorageFlag != 0 {
+		price += contractStoragePrice
+	}
+	if properties&hasDynamicInvokeFlag != 0 {
+		price += contractDynamicInvokePrice
+	}
+	if err := v.AddGas(price); err != nil {
+		return nil, err
+	}
+
+	var fields [4]string
+	for i := range fields {
+		b, err := popLimitedBytes(v, maxContractStringLen)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = string(b)
+	}
+	desc, err := popLimitedBytes(v, maxContractDescLen)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContractState{
+		Script:           script,
+		ParamList:        paramList,
+		ReturnType:       returnType,
+		Name:             fields[0],
+		CodeVersion:      fields[1],
+		Author:           fields[2],
+		Email:            fields[3],
+		Description:      string(desc),
+		HasStorage:       properties&hasStorageFlag != 0,
+		HasDynamicInvoke: properties&hasDynamicInvokeFlag != 0,
+		IsPayable:        properties&isPayableFlag != 0,
+	}, nil
+}
+
+// contractCreate registers a new contract and pushes its state. Registering
+// an existing contract pushes the existing state.
+func (ic *interopContext) contractCreate(v *vm.VM) error {
+	if ic.trigger != TriggerApplication {
+		return errors.New("contracts can only be created by the application trigger")
+	}
+	cs, err := popContractState(v)
+	if err != nil {
+		return err
+	}
+	if existing := ic.getContract(cs.ScriptHash()); existing != nil {
+		cs = existing
+	} else {
+		if err := putContractState(ic.store, cs); err != nil {
+			return err
+		}
+		ic.created[cs.ScriptHash()] = true
+	}
+	v.Estack().PushVal(vm.NewInteropItem(cs))
+	return nil
+}
+
+// contractMigrate registers the new version of the contract of the running
+// script, moving its storage items to it, pushes its state and destroys the
+// running one. Migrating to an existing contract only destroys the running
+// one.
+func (ic *interopContext) contractMigrate(v *vm.VM) error {
+	if ic.trigger != TriggerApplication {
+		return errors.New("contracts can only be migrated by the application trigger")
+	}
+	cs, err := popContractState(v)
+	if err != nil {
+		return err
+	}
+	if existing := ic.getContract(cs.ScriptHash()); existing != nil {
+		cs = existing
+	} else {
+		if err := putContractState(ic.store, cs); err != nil {
+			return err
+		}
+		ic.created[cs.ScriptHash()] = true
+		if cs.HasStorage {
+			hash := v.GetContextScriptHash(0)
+			var items []*StorageItem
+			var keys [][]byte
+			err := findStorageItems(ic.store, hash, nil, func(key []byte, item *StorageItem) {
+				keys = append(keys, key)
+				items = append(items, item)
+			})
+			if err != nil {
+				return err
+			}
+			for i := range items {
+				if err := putStorageItem(ic.store, cs.ScriptHash(), keys[i], items[i]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	v.Estack().PushVal(vm.NewInteropItem(cs))
+	return ic.contractDestroy(v)
+}
+
+// contractIsPayable pushes whether the contract accepts assets.
+func contractIsPayable(v *vm.VM) error {
+	cs, err := popContract(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(cs.IsPayable)
+	return nil
+}
+
+// contractDestroy removes the contract of the running script and its
+// storage items.
+func (ic *interopContext) contractDestroy(v *vm.VM) error {
+	if ic.trigger != TriggerApplication {
+		return errors.New("contracts can only be destroyed by the application trigger")
+	}
+	hash := v.GetContextScriptHash(0)
+	cs := ic.getContract(hash)
+	if cs == nil {
+		return nil
+	}
+	if cs.HasStorage {
+		var keys [][]byte
+		err := findStorageItems(ic.store, hash, nil, func(key []byte, item *StorageItem) {
+			keys = append(keys, key)
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := ic.store.Delete(makeStorageItemKey(hash, key)); err != nil {
+				return err
+			}
+		}
+	}
+	delete(ic.created, hash)
+	return deleteContractState(ic.store, hash)
+}
+
+// contractGetStorageContext pushes the storage context of a contract
+// created by the current execution.
+func (ic *interopContext) contractGetStorageContext(v *vm.VM) error {
+	cs, err := popContract(v)
+	if err != nil {
+		return err
+	}
+	if !ic.created[cs.ScriptHash()] {
+		return errors.New("contract wasn't created by this execution")
+	}
+	v.Estack().PushVal(vm.NewInteropItem(&StorageContext{
+		ScriptHash: cs.ScriptHash(),
+	}))
+	return nil
+}
@@ -0,0 +1,766 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+	log "github.com/sirupsen/logrus"
+)
+
+// Limits of the storage items, as in the reference implementation.
+const maxStorageKeyLen = 1024
+
+// engineGetScriptContainer pushes the transaction the script runs in.
+func (ic *interopContext) engineGetScriptContainer(v *vm.VM) error {
+	if ic.tx == nil {
+		return errors.New("no script container")
+	}
+	v.Estack().PushVal(vm.NewInteropItem(ic.tx))
+	return nil
+}
+
+// engineGetExecutingScriptHash pushes the hash of the running script.
+func (ic *interopContext) engineGetExecutingScriptHash(v *vm.VM) error {
+	v.Estack().PushVal(v.GetContextScriptHash(0).Bytes())
+	return nil
+}
+
+// engineGetCallingScriptHash pushes the hash of the script that called the
+// running one, or an empty array if it's the entry script.
+func (ic *interopContext) engineGetCallingScriptHash(v *vm.VM) error {
+	if v.Istack().Len() < 2 {
+		v.Estack().PushVal([]byte{})
+		return nil
+	}
+	v.Estack().PushVal(v.GetContextScriptHash(1).Bytes())
+	return nil
+}
+
+// engineGetEntryScriptHash pushes the hash of the first script loaded.
+func (ic *interopContext) engineGetEntryScriptHash(v *vm.VM) error {
+	v.Estack().PushVal(v.GetContextScriptHash(v.Istack().Len() - 1).Bytes())
+	return nil
+}
+
+// runtimeGetTrigger pushes the trigger of the execution.
+func (ic *interopContext) runtimeGetTrigger(v *vm.VM) error {
+	v.Estack().PushVal(int(ic.trigger))
+	return nil
+}
+
+// runtimeCheckWitness checks that the script container is signed by the
+// given script hash or public key.
+func (ic *interopContext) runtimeCheckWitness(v *vm.VM) error {
+	var (
+		ok   bool
+		err  error
+		data = v.Estack().Pop().Bytes()
+	)
+	switch len(data) {
+	case 20:
+		var hash util.Uint160
+		if hash, err = util.Uint160DecodeBytes(data); err == nil {
+			ok, err = ic.checkHashedWitness(hash)
+		}
+	case 33:
+		v.Estack().PushVal(data)
+		key, perr := popPublicKey(v)
+		if perr != nil {
+			return perr
+		}
+		ok, err = ic.checkKeyedWitness(key)
+	default:
+		return fmt.Errorf("invalid witness data length %d", len(data))
+	}
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(ok)
+	return nil
+}
+
+// runtimeNotify records the event sent by the running script.
+func (ic *interopContext) runtimeNotify(v *vm.VM) error {
+	item := v.Estack().Pop().Item()
+	ic.notifications = append(ic.notifications, NotificationEvent{
+		ScriptHash: v.GetContextScriptHash(0),
+		Item:       smartcontract.NewParameterFromStackItem(item),
+	})
+	return nil
+}
+
+// runtimeLog logs the message of the running script.
+func (ic *interopContext) runtimeLog(v *vm.VM) error {
+	msg := string(v.Estack().Pop().Bytes())
+	log.WithFields(log.Fields{
+		"script": v.GetContextScriptHash(0),
+	}).Infof("runtime log: %s", msg)
+	return nil
+}
+
+// runtimeGetTime pushes the timestamp of the block being persisted or, for
+// the test invocations, the expected timestamp of the next block.
+func (ic *interopContext) runtimeGetTime(v *vm.VM) error {
+	header, err := ic.currentHeader()
+	if err != nil {
+		return err
+	}
+	timestamp := header.Timestamp
+	if ic.block == nil {
+		timestamp += secondsPerBlock
+	}
+	v.Estack().PushVal(int(timestamp))
+	return nil
+}
+
+// runtimeGetCurrentBlock pushes the header of the block being persisted or
+// the one of the last block for the test invocations.
+func (ic *interopContext) runtimeGetCurrentBlock(v *vm.VM) error {
+	header, err := ic.currentHeader()
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(vm.NewInteropItem(header))
+	return nil
+}
+
+// runtimeSerialize pushes the binary representation of the given item.
+func runtimeSerialize(v *vm.VM) error {
+	b, err := vm.SerializeItem(v.Estack().Pop().Item())
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(b)
+	return nil
+}
+
+// runtimeDeserialize pushes the item serialized by runtimeSerialize.
+func runtimeDeserialize(v *vm.VM) error {
+	item, err := vm.DeserializeItem(v.Estack().Pop().Bytes())
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(item)
+	return nil
+}
+
+func (ic *interopContext) currentHeader() (*Header, error) {
+	if ic.block != nil {
+		return ic.block.Header(), nil
+	}
+	return ic.bc.getHeader(ic.bc.CurrentBlockHash())
+}
+
+// bcGetHeight pushes the height of the chain.
+func (ic *interopContext) bcGetHeight(v *vm.VM) error {
+	v.Estack().PushVal(int(ic.bc.BlockHeight()))
+	return nil
+}
+
+// getBlockHashFromElement returns the hash of the block referenced by the
+// given element, either by its index or by its hash.
+func (ic *interopContext) getBlockHashFromElement(e *vm.Element) (util.Uint256, error) {
+	b := e.Bytes()
+	if len(b) <= 5 {
+		index := e.BigInt().Int64()
+		if index < 0 || index > int64(ic.bc.BlockHeight()) {
+			return util.Uint256{}, fmt.Errorf("invalid block index %d", index)
+		}
+		return ic.bc.GetHeaderHash(int(index)), nil
+	}
+	return util.Uint256DecodeBytes(util.ArrayReverse(b))
+}
+
+// bcGetHeader pushes the header of the block with the given index or hash.
+func (ic *interopContext) bcGetHeader(v *vm.VM) error {
+	hash, err := ic.getBlockHashFromElement(v.Estack().Pop())
+	if err != nil {
+		return err
+	}
+	header, err := ic.bc.getHeader(hash)
+	if err != nil {
+		v.Estack().PushVal([]byte{})
+		return nil
+	}
+	v.Estack().PushVal(vm.NewInteropItem(header))
+	return nil
+}
+
+// bcGetBlock pushes the block with the given index or hash.
+func (ic *interopContext) bcGetBlock(v *vm.VM) error {
+	hash, err := ic.getBlockHashFromElement(v.Estack().Pop())
+	if err != nil {
+		return err
+	}
+	block, err := ic.bc.GetBlock(hash)
+	if err != nil {
+		v.Estack().PushVal([]byte{})
+		return nil
+	}
+	// The stored blocks only hold the hashes of their transactions.
+	for i, t := range block.Transactions {
+		tx, _, err := ic.bc.GetTransaction(t.Hash())
+		if err != nil {
+			return err
+		}
+		block.Transactions[i] = tx
+	}
+	block.Trimmed = false
+	v.Estack().PushVal(vm.NewInteropItem(block))
+	return nil
+}
+
+// bcGetTransaction pushes the transaction with the given hash.
+func (ic *interopContext) bcGetTransaction(v *vm.VM) error {
+	hash, err := popUint256(v)
+	if err != nil {
+		return err
+	}
+	tx, _, err := getTransaction(ic.store, hash)
+	if err != nil {
+		v.Estack().PushVal([]byte{})
+		return nil
+	}
+	v.Estack().PushVal(vm.NewInteropItem(tx))
+	return nil
+}
+
+// bcGetTransactionHeight pushes the index of the block including the
+// transaction with the given hash, or -1 if there is no such transaction.
+func (ic *interopContext) bcGetTransactionHeight(v *vm.VM) error {
+	hash, err := popUint256(v)
+	if err != nil {
+		return err
+	}
+	height, err := getTransactionHeight(ic.store, hash)
+	if err != nil {
+		v.Estack().PushVal(-1)
+		return nil
+	}
+	v.Estack().PushVal(int(height))
+	return nil
+}
+
+// bcGetAccount pushes the account with the given script hash.
+func (ic *interopContext) bcGetAccount(v *vm.VM) error {
+	hash, err := popUint160(v)
+	if err != nil {
+		return err
+	}
+	account := ic.bc.GetAccountState(hash)
+	if account == nil {
+		account = NewAccountState(hash)
+	}
+	v.Estack().PushVal(vm.NewInteropItem(account))
+	return nil
+}
+
+// bcGetValidators pushes the public keys of the validators of the next block.
+func (ic *interopContext) bcGetValidators(v *vm.VM) error {
+	validators, err := ic.bc.GetValidators()
+	if err != nil {
+		return err
+	}
+	keys := make([]vm.StackItem, len(validators))
+	for i, key := range validators {
+		keys[i] = vm.NewByteArrayItem(key.Bytes())
+	}
+	v.Estack().PushVal(keys)
+	return nil
+}
+
+// bcGetAsset pushes the asset with the given ID.
+func (ic *interopContext) bcGetAsset(v *vm.VM) error {
+	id, err := popUint256(v)
+	if err != nil {
+		return err
+	}
+	asset := ic.getAsset(id)
+	if asset == nil {
+		return fmt.Errorf("unknown asset %s", id)
+	}
+	v.Estack().PushVal(vm.NewInteropItem(asset))
+	return nil
+}
+
+// bcGetContract pushes the contract with the given script hash.
+func (ic *interopContext) bcGetContract(v *vm.VM) error {
+	hash, err := popUint160(v)
+	if err != nil {
+		return err
+	}
+	cs := ic.getContract(hash)
+	if cs == nil {
+		v.Estack().PushVal([]byte{})
+		return nil
+	}
+	v.Estack().PushVal(vm.NewInteropItem(cs))
+	return nil
+}
+
+// popBlockBase pops the top element of the evaluation stack, which has to
+// be a block or a header.
+func popBlockBase(v *vm.VM) (*BlockBase, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	switch t := value.(type) {
+	case *Block:
+		return &t.BlockBase, nil
+	case *Header:
+		return &t.BlockBase, nil
+	default:
+		return nil, errors.New("not a block or a header")
+	}
+}
+
+// headerGetIndex pushes the index of the block.
+func headerGetIndex(v *vm.VM) error {
+	b, err := popBlockBase(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(int(b.Index))
+	return nil
+}
+
+// headerGetHash pushes the hash of the block.
+func headerGetHash(v *vm.VM) error {
+	b, err := popBlockBase(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(b.Hash().BytesReverse())
+	return nil
+}
+
+// headerGetVersion pushes the version of the block.
+func headerGetVersion(v *vm.VM) error {
+	b, err := popBlockBase(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(int(b.Version))
+	return nil
+}
+
+// headerGetPrevHash pushes the hash of the previous block.
+func headerGetPrevHash(v *vm.VM) error {
+	b, err := popBlockBase(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(b.PrevHash.BytesReverse())
+	return nil
+}
+
+// headerGetMerkleRoot pushes the merkle root of the transactions of the
+// block.
+func headerGetMerkleRoot(v *vm.VM) error {
+	b, err := popBlockBase(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(b.MerkleRoot.BytesReverse())
+	return nil
+}
+
+// headerGetTimestamp pushes the timestamp of the block.
+func headerGetTimestamp(v *vm.VM) error {
+	b, err := popBlockBase(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(int(b.Timestamp))
+	return nil
+}
+
+// headerGetConsensusData pushes the nonce of the block.
+func headerGetConsensusData(v *vm.VM) error {
+	b, err := popBlockBase(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(new(big.Int).SetUint64(b.ConsensusData))
+	return nil
+}
+
+// headerGetNextConsensus pushes the script hash of the validators of the
+// next block.
+func headerGetNextConsensus(v *vm.VM) error {
+	b, err := popBlockBase(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(b.NextConsensus.Bytes())
+	return nil
+}
+
+// popBlock pops the top element of the evaluation stack as a block.
+func popBlock(v *vm.VM) (*Block, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	block, ok := value.(*Block)
+	if !ok {
+		return nil, errors.New("not a block")
+	}
+	return block, nil
+}
+
+// blockGetTransactionCount pushes the number of transactions of the block.
+func blockGetTransactionCount(v *vm.VM) error {
+	block, err := popBlock(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(len(block.Transactions))
+	return nil
+}
+
+// blockGetTransactions pushes the transactions of the block.
+func blockGetTransactions(v *vm.VM) error {
+	block, err := popBlock(v)
+	if err != nil {
+		return err
+	}
+	pushInteropArray(v, len(block.Transactions), func(i int) interface{} {
+		return block.Transactions[i]
+	})
+	return nil
+}
+
+// blockGetTransaction pushes the transaction of the block at the given index.
+func blockGetTransaction(v *vm.VM) error {
+	block, err := popBlock(v)
+	if err != nil {
+		return err
+	}
+	index := int(v.Estack().Pop().BigInt().Int64())
+	if index < 0 || index >= len(block.Transactions) {
+		return fmt.Errorf("invalid transaction index %d", index)
+	}
+	v.Estack().PushVal(vm.NewInteropItem(block.Transactions[index]))
+	return nil
+}
+
+// popTransaction pops the top element of the evaluation stack as a
+// transaction.
+func popTransaction(v *vm.VM) (*transaction.Transaction, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	tx, ok := value.(*transaction.Transaction)
+	if !ok {
+		return nil, errors.New("not a transaction")
+	}
+	return tx, nil
+}
+
+// txGetHash pushes the hash of the transaction.
+func txGetHash(v *vm.VM) error {
+	tx, err := popTransaction(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(tx.Hash().BytesReverse())
+	return nil
+}
+
+// txGetType pushes the type of the transaction.
+func txGetType(v *vm.VM) error {
+	tx, err := popTransaction(v)
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(int(tx.Type))
+	return nil
+}
+
+// txGetAttributes pushes the attributes of the transaction.
+func txGetAttributes(v *vm.VM) error {
+	tx, err := popTransaction(v)
+	if err != nil {
+		return err
+	}
+	pushInteropArray(v, len(tx.Attributes), func(i int) interface{} {
+		return tx.Attributes[i]
+	})
+	return nil
+}
+
+// txGetInputs pushes the inputs of the transaction.
+func txGetInputs(v *vm.VM) error {
+	tx, err := popTransaction(v)
+	if err != nil {
+		return err
+	}
+	pushInteropArray(v, len(tx.Inputs), func(i int) interface{} {
+		return tx.Inputs[i]
+	})
+	return nil
+}
+
+// txGetOutputs pushes the outputs of the transaction.
+func txGetOutputs(v *vm.VM) error {
+	tx, err := popTransaction(v)
+	if err != nil {
+		return err
+	}
+	pushInteropArray(v, len(tx.Outputs), func(i int) interface{} {
+		return tx.Outputs[i]
+	})
+	return nil
+}
+
+// txGetReferences pushes the outputs referenced by the inputs of the
+// transaction, in the order of the inputs.
+func (ic *interopContext) txGetReferences(v *vm.VM) error {
+	tx, err := popTransaction(v)
+	if err != nil {
+		return err
+	}
+	references, err := getReferences(ic.store, tx)
+	if err != nil {
+		return err
+	}
+	pushInteropArray(v, len(tx.Inputs), func(i int) interface{} {
+		return references[*tx.Inputs[i]]
+	})
+	return nil
+}
+
+// txGetUnspentCoins pushes the outputs of the transaction that are not
+// spent yet.
+func (ic *interopContext) txGetUnspentCoins(v *vm.VM) error {
+	tx, err := popTransaction(v)
+	if err != nil {
+		return err
+	}
+	var outputs []*transaction.Output
+	if unspent := ic.bc.GetUnspentCoinState(tx.Hash()); unspent != nil {
+		for i, state := range unspent.states {
+			if state&CoinStateSpent == 0 && i < len(tx.Outputs) {
+				outputs = append(outputs, tx.Outputs[i])
+			}
+		}
+	}
+	pushInteropArray(v, len(outputs), func(i int) interface{} {
+		return outputs[i]
+	})
+	return nil
+}
+
+// txGetWitnesses pushes the witnesses of the transaction, an empty
+// verification script being replaced by the script of the contract it
+// refers to.
+func (ic *interopContext) txGetWitnesses(v *vm.VM) error {
+	tx, err := popTransaction(v)
+	if err != nil {
+		return err
+	}
+	if len(tx.Scripts) > vm.MaxArraySize {
+		return fmt.Errorf("too many witnesses: %d", len(tx.Scripts))
+	}
+	hashes, err := ic.bc.GetScriptHashesForVerifying(tx)
+	if err != nil {
+		return err
+	}
+	if len(hashes) != len(tx.Scripts) {
+		return fmt.Errorf("expected %d witnesses, got %d", len(hashes), len(tx.Scripts))
+	}
+	witnesses := make([]*transaction.Witness, len(tx.Scripts))
+	for i, w := range tx.Scripts {
+		script := w.VerificationScript
+		if len(script) == 0 {
+			cs := ic.getContract(hashes[i])
+			if cs == nil {
+				return fmt.Errorf("unknown contract %s", hashes[i])
+			}
+			script = cs.Script
+		}
+		witnesses[i] = &transaction.Witness{
+			InvocationScript:   w.InvocationScript,
+			VerificationScript: script,
+		}
+	}
+	pushInteropArray(v, len(witnesses), func(i int) interface{} {
+		return witnesses[i]
+	})
+	return nil
+}
+
+// witnessGetVerificationScript pushes the verification script of the
+// witness.
+func witnessGetVerificationScript(v *vm.VM) error {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return err
+	}
+	w, ok := value.(*transaction.Witness)
+	if !ok {
+		return errors.New("not a witness")
+	}
+	v.Estack().PushVal(w.VerificationScript)
+	return nil
+}
+
+// invocationTxGetScript pushes the script of the invocation transaction.
+func invocationTxGetScript(v *vm.VM) error {
+	tx, err := popTransaction(v)
+	if err != nil {
+		return err
+	}
+	inv, ok := tx.Data.(*transaction.InvocationTX)
+	if !ok {
+		return errors.New("not an invocation transaction")
+	}
+	v.Estack().PushVal(inv.Script)
+	return nil
+}
+
+// popStorageContext pops the top element of the evaluation stack as a
+// storage context and checks that the contract it refers to has storage.
+func (ic *interopContext) popStorageContext(v *vm.VM) (*StorageContext, error) {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return nil, err
+	}
+	stc, ok := value.(*StorageContext)
+	if !ok {
+		return nil, errors.New("not a storage context")
+	}
+	cs := ic.getContract(stc.ScriptHash)
+	if cs == nil {
+		return nil, fmt.Errorf("unknown contract %s", stc.ScriptHash)
+	}
+	if !cs.HasStorage {
+		return nil, fmt.Errorf("contract %s has no storage", stc.ScriptHash)
+	}
+	return stc, nil
+}
+
+// storageGetContext pushes the storage context of the running script.
+func (ic *interopContext) storageGetContext(v *vm.VM) error {
+	v.Estack().PushVal(vm.NewInteropItem(&StorageContext{
+		ScriptHash: v.GetContextScriptHash(0),
+	}))
+	return nil
+}
+
+// storageGetReadOnlyContext pushes the storage context of the running
+// script, which can only be read.
+func (ic *interopContext) storageGetReadOnlyContext(v *vm.VM) error {
+	v.Estack().PushVal(vm.NewInteropItem(&StorageContext{
+		ScriptHash: v.GetContextScriptHash(0),
+		IsReadOnly: true,
+	}))
+	return nil
+}
+
+// storageContextAsReadOnly pushes a read-only copy of the storage context.
+func storageContextAsReadOnly(v *vm.VM) error {
+	value, err := popInteropValue(v)
+	if err != nil {
+		return err
+	}
+	stc, ok := value.(*StorageContext)
+	if !ok {
+		return errors.New("not a storage context")
+	}
+	if !stc.IsReadOnly {
+		stc = &StorageContext{
+			ScriptHash: stc.ScriptHash,
+			IsReadOnly: true,
+		}
+	}
+	v.Estack().PushVal(vm.NewInteropItem(stc))
+	return nil
+}
+
+// storageGet pushes the value stored under the given key, or an empty
+// array if there is no such value.
+func (ic *interopContext) storageGet(v *vm.VM) error {
+	stc, err := ic.popStorageContext(v)
+	if err != nil {
+		return err
+	}
+	key := v.Estack().Pop().Bytes()
+	item := ic.getStorageItem(stc.ScriptHash, key)
+	if item == nil {
+		v.Estack().PushVal([]byte{})
+		return nil
+	}
+	v.Estack().PushVal(item.Value)
+	return nil
+}
+
+// storagePut stores the given value under the given key.
+func (ic *interopContext) storagePut(v *vm.VM) error {
+	if ic.trigger != TriggerApplication {
+		return errors.New("storage can only be changed by the application trigger")
+	}
+	stc, err := ic.popStorageContext(v)
+	if err != nil {
+		return err
+	}
+	if stc.IsReadOnly {
+		return errors.New("storage context is read-only")
+	}
+	key := v.Estack().Pop().Bytes()
+	if len(key) > maxStorageKeyLen {
+		return fmt.Errorf("key is too big: %d", len(key))
+	}
+	value := v.Estack().Pop().Bytes()
+	if err := v.AddGas(((len(key)+len(value)-1)/1024 + 1) * storagePutPricePerKB); err != nil {
+		return err
+	}
+	return putStorageItem(ic.store, stc.ScriptHash, key, &StorageItem{Value: value})
+}
+
+// storageDelete deletes the value stored under the given key.
+func (ic *interopContext) storageDelete(v *vm.VM) error {
+	if ic.trigger != TriggerApplication {
+		return errors.New("storage can only be changed by the application trigger")
+	}
+	stc, err := ic.popStorageContext(v)
+	if err != nil {
+		return err
+	}
+	if stc.IsReadOnly {
+		return errors.New("storage context is read-only")
+	}
+	key := v.Estack().Pop().Bytes()
+	return ic.store.Delete(makeStorageItemKey(stc.ScriptHash, key))
+}
+
+// storageFind pushes an iterator over the items stored under the keys
+// starting with the given prefix, in the order of their keys. The items are
+// read when the iterator is created.
+func (ic *interopContext) storageFind(v *vm.VM) error {
+	stc, err := ic.popStorageContext(v)
+	if err != nil {
+		return err
+	}
+	prefix := v.Estack().Pop().Bytes()
+	var elements []vm.MapElement
+	err = findStorageItems(ic.store, stc.ScriptHash, prefix, func(key []byte, item *StorageItem) {
+		elements = append(elements, vm.MapElement{
+			Key:   vm.NewByteArrayItem(key),
+			Value: vm.NewByteArrayItem(item.Value),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	v.Estack().PushVal(vm.NewInteropItem(&mapWrapper{index: -1, elements: elements}))
+	return nil
+}
@@ -0,0 +1,416 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+// runHalting runs the given script and fails the test if the VM doesn't halt.
+func runHalting(t *testing.T, v *vm.VM, script []byte) {
+	v.LoadScript(script)
+	v.Run()
+	if !v.HasHalted() {
+		t.Fatalf("VM ended in %s state", v.State())
+	}
+}
+
+func newStorageContract(emit func(buf *bytes.Buffer)) *ContractState {
+	buf := new(bytes.Buffer)
+	emit(buf)
+	return &ContractState{
+		Script:     buf.Bytes(),
+		HasStorage: true,
+	}
+}
+
+func emitPutAndGet(buf *bytes.Buffer, key, value string) {
+	vm.EmitString(buf, value)
+	vm.EmitString(buf, key)
+	vm.EmitSyscall(buf, "Neo.Storage.GetContext")
+	vm.EmitSyscall(buf, "Neo.Storage.Put")
+	vm.EmitString(buf, key)
+	vm.EmitSyscall(buf, "Neo.Storage.GetContext")
+	vm.EmitSyscall(buf, "Neo.Storage.Get")
+}
+
+func emitPut(buf *bytes.Buffer, key, value string) {
+	vm.EmitString(buf, value)
+	vm.EmitString(buf, key)
+	vm.EmitSyscall(buf, "Neo.Storage.GetContext")
+	vm.EmitSyscall(buf, "Neo.Storage.Put")
+}
+
+// emitNext emits a call of the given Next service on the enumerator on top
+// of the stack, failing if it has no next value.
+func emitNext(buf *bytes.Buffer, api string) {
+	vm.EmitOpcode(buf, vm.Odup)
+	vm.EmitSyscall(buf, api)
+	vm.EmitOpcode(buf, vm.Othrowifnot)
+}
+
+func TestInteropGetHeader(t *testing.T) {
+	bc := newTestChain(t)
+	buf := new(bytes.Buffer)
+	vm.EmitInt(buf, 0)
+	vm.EmitSyscall(buf, "Neo.Blockchain.GetHeader")
+	vm.EmitSyscall(buf, "Neo.Header.GetHash")
+
+	v := bc.GetTestVM()
+	runHalting(t, v, buf.Bytes())
+	assert.Equal(t, bc.GetHeaderHash(0).BytesReverse(), v.Estack().Pop().Bytes())
+}
+
+func TestInteropGetTransactionHeight(t *testing.T) {
+	bc := newTestChain(t)
+	buf := new(bytes.Buffer)
+	vm.EmitBytes(buf, util.Uint256{1, 2, 3}.BytesReverse())
+	vm.EmitSyscall(buf, "Neo.Blockchain.GetTransactionHeight")
+
+	v := bc.GetTestVM()
+	runHalting(t, v, buf.Bytes())
+	assert.Equal(t, int64(-1), v.Estack().Pop().BigInt().Int64())
+}
+
+func TestInteropStoragePutGet(t *testing.T) {
+	bc := newTestChain(t)
+	cs := newStorageContract(func(buf *bytes.Buffer) {
+		emitPutAndGet(buf, "key", "value")
+	})
+	ic := newInteropContext(TriggerApplication, bc, nil, nil)
+	assert.Nil(t, putContractState(bc.Store, cs))
+
+	v := ic.newVM()
+	runHalting(t, v, cs.Script)
+	assert.Equal(t, []byte("value"), v.Estack().Pop().Bytes())
+
+	// The changes are only kept in the context.
+	item := ic.getStorageItem(cs.ScriptHash(), []byte("key"))
+	if item == nil {
+		t.Fatal("no item stored")
+	}
+	assert.Equal(t, []byte("value"), item.Value)
+	assert.Nil(t, bc.GetStorageItem(cs.ScriptHash(), []byte("key")))
+}
+
+func TestInteropStoragePutPrice(t *testing.T) {
+	bc := newTestChain(t)
+	cs := newStorageContract(func(buf *bytes.Buffer) {
+		emitPutAndGet(buf, "key", "value")
+	})
+	ic := newInteropContext(TriggerApplication, bc, nil, nil)
+	assert.Nil(t, putContractState(bc.Store, cs))
+
+	v := ic.newVM()
+	runHalting(t, v, cs.Script)
+	// Two GetContext, a Put of less than 1KB and a Get.
+	assert.Equal(t, util.Fixed8((1+storagePutPricePerKB+1+100)*100000), v.GasConsumed())
+}
+
+func TestTestVMGasLimit(t *testing.T) {
+	bc := newTestChain(t)
+	v := bc.GetTestVM()
+	// An endless loop of NOP and a JMP back to it.
+	v.LoadScript([]byte{byte(vm.Onop), byte(vm.Ojmp), 0x00, 0x00})
+	v.Run()
+	assert.True(t, v.HasFailed())
+}
+
+func TestInteropStoragePutVerification(t *testing.T) {
+	bc := newTestChain(t)
+	cs := newStorageContract(func(buf *bytes.Buffer) {
+		emitPutAndGet(buf, "key", "value")
+	})
+	ic := newInteropContext(TriggerVerification, bc, nil, nil)
+	assert.Nil(t, putContractState(bc.Store, cs))
+
+	v := ic.newVM()
+	v.LoadScript(cs.Script)
+	v.Run()
+	assert.True(t, v.HasFailed())
+	assert.Equal(t, 0, len(ic.store.Changes()))
+}
+
+func TestInteropStorageWithoutStorage(t *testing.T) {
+	bc := newTestChain(t)
+	cs := newStorageContract(func(buf *bytes.Buffer) {
+		emitPutAndGet(buf, "key", "value")
+	})
+	cs.HasStorage = false
+	ic := newInteropContext(TriggerApplication, bc, nil, nil)
+	assert.Nil(t, putContractState(bc.Store, cs))
+
+	v := ic.newVM()
+	v.LoadScript(cs.Script)
+	v.Run()
+	assert.True(t, v.HasFailed())
+}
+
+func TestInteropContractCreate(t *testing.T) {
+	bc := newTestChain(t)
+	script := []byte{byte(vm.Opush1)}
+	buf := new(bytes.Buffer)
+	vm.EmitString(buf, "description")
+	vm.EmitString(buf, "email")
+	vm.EmitString(buf, "author")
+	vm.EmitString(buf, "1.0")
+	vm.EmitString(buf, "name")
+	vm.EmitInt(buf, int64(hasStorageFlag))
+	vm.EmitInt(buf, 5)
+	vm.EmitBytes(buf, []byte{})
+	vm.EmitBytes(buf, script)
+	vm.EmitSyscall(buf, "Neo.Contract.Create")
+	vm.EmitSyscall(buf, "Neo.Contract.GetScript")
+
+	ic := newInteropContext(TriggerApplication, bc, nil, nil)
+	v := ic.newVM()
+	runHalting(t, v, buf.Bytes())
+	assert.Equal(t, script, v.Estack().Pop().Bytes())
+
+	hash, err := util.Uint160FromScript(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := getContractState(ic.store, hash)
+	if cs == nil {
+		t.Fatal("no contract created")
+	}
+	assert.Equal(t, "name", cs.Name)
+	assert.Equal(t, "description", cs.Description)
+	assert.True(t, cs.HasStorage)
+	assert.False(t, cs.HasDynamicInvoke)
+}
+
+func TestInteropRuntimeNotify(t *testing.T) {
+	bc := newTestChain(t)
+	buf := new(bytes.Buffer)
+	vm.EmitString(buf, "event")
+	vm.EmitSyscall(buf, "Neo.Runtime.Notify")
+
+	ic := newInteropContext(TriggerApplication, bc, nil, nil)
+	v := ic.newVM()
+	runHalting(t, v, buf.Bytes())
+	if len(ic.notifications) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(ic.notifications))
+	}
+	hash, err := util.Uint160FromScript(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, hash, ic.notifications[0].ScriptHash)
+	assert.Equal(t, smartcontract.ByteArrayType, ic.notifications[0].Item.Type)
+	assert.Equal(t, hex.EncodeToString([]byte("event")), ic.notifications[0].Item.Value)
+}
+
+func TestInteropStorageFind(t *testing.T) {
+	bc := newTestChain(t)
+	cs := newStorageContract(func(buf *bytes.Buffer) {
+		emitPut(buf, "b", "z")
+		emitPut(buf, "a2", "y")
+		emitPut(buf, "a1", "x")
+		vm.EmitString(buf, "a")
+		vm.EmitSyscall(buf, "Neo.Storage.GetContext")
+		vm.EmitSyscall(buf, "Neo.Storage.Find")
+		emitNext(buf, "Neo.Iterator.Next")
+		vm.EmitOpcode(buf, vm.Odup)
+		vm.EmitSyscall(buf, "Neo.Iterator.Key")
+		vm.EmitOpcode(buf, vm.Oswap)
+		emitNext(buf, "Neo.Iterator.Next")
+		vm.EmitOpcode(buf, vm.Odup)
+		vm.EmitSyscall(buf, "Neo.Iterator.Value")
+		vm.EmitOpcode(buf, vm.Oswap)
+		vm.EmitSyscall(buf, "Neo.Iterator.Next")
+	})
+	ic := newInteropContext(TriggerApplication, bc, nil, nil)
+	assert.Nil(t, putContractState(bc.Store, cs))
+
+	v := ic.newVM()
+	runHalting(t, v, cs.Script)
+	assert.False(t, v.Estack().Pop().Bool())
+	assert.Equal(t, []byte("y"), v.Estack().Pop().Bytes())
+	assert.Equal(t, []byte("a1"), v.Estack().Pop().Bytes())
+}
+
+func TestInteropEnumeratorConcat(t *testing.T) {
+	bc := newTestChain(t)
+	buf := new(bytes.Buffer)
+	for _, i := range []int64{5, 6} {
+		vm.EmitInt(buf, i)
+		vm.EmitInt(buf, 1)
+		vm.EmitOpcode(buf, vm.Opack)
+		vm.EmitSyscall(buf, "Neo.Enumerator.Create")
+	}
+	vm.EmitSyscall(buf, "Neo.Enumerator.Concat")
+	for i := 0; i < 2; i++ {
+		emitNext(buf, "Neo.Enumerator.Next")
+		vm.EmitOpcode(buf, vm.Odup)
+		vm.EmitSyscall(buf, "Neo.Enumerator.Value")
+		vm.EmitOpcode(buf, vm.Oswap)
+	}
+	vm.EmitSyscall(buf, "Neo.Enumerator.Next")
+
+	v := newInteropContext(TriggerApplication, bc, nil, nil).newVM()
+	runHalting(t, v, buf.Bytes())
+	assert.False(t, v.Estack().Pop().Bool())
+	assert.Equal(t, int64(5), v.Estack().Pop().BigInt().Int64())
+	assert.Equal(t, int64(6), v.Estack().Pop().BigInt().Int64())
+}
+
+func TestInteropIteratorKeys(t *testing.T) {
+	bc := newTestChain(t)
+	buf := new(bytes.Buffer)
+	vm.EmitString(buf, "a")
+	vm.EmitString(buf, "b")
+	vm.EmitInt(buf, 2)
+	vm.EmitOpcode(buf, vm.Opack)
+	vm.EmitSyscall(buf, "Neo.Iterator.Create")
+	vm.EmitSyscall(buf, "Neo.Iterator.Keys")
+	emitNext(buf, "Neo.Enumerator.Next")
+	emitNext(buf, "Neo.Enumerator.Next")
+	vm.EmitSyscall(buf, "Neo.Enumerator.Value")
+
+	v := newInteropContext(TriggerApplication, bc, nil, nil).newVM()
+	runHalting(t, v, buf.Bytes())
+	assert.Equal(t, int64(1), v.Estack().Pop().BigInt().Int64())
+}
+
+func TestInteropRuntimeSerialize(t *testing.T) {
+	bc := newTestChain(t)
+	buf := new(bytes.Buffer)
+	vm.EmitString(buf, "ab")
+	vm.EmitInt(buf, 1)
+	vm.EmitInt(buf, 2)
+	vm.EmitOpcode(buf, vm.Opack)
+	vm.EmitSyscall(buf, "Neo.Runtime.Serialize")
+	vm.EmitOpcode(buf, vm.Odup)
+	vm.EmitSyscall(buf, "Neo.Runtime.Deserialize")
+	vm.EmitInt(buf, 1)
+	vm.EmitOpcode(buf, vm.Opickitem)
+
+	v := newInteropContext(TriggerApplication, bc, nil, nil).newVM()
+	runHalting(t, v, buf.Bytes())
+	assert.Equal(t, []byte("ab"), v.Estack().Pop().Bytes())
+	assert.Equal(t, []byte{0x80, 2, 0x02, 1, 1, 0x00, 2, 'a', 'b'}, v.Estack().Pop().Bytes())
+}
+
+func TestInteropStorageReadOnly(t *testing.T) {
+	bc := newTestChain(t)
+	for _, emitContext := range []func(buf *bytes.Buffer){
+		func(buf *bytes.Buffer) {
+			vm.EmitSyscall(buf, "Neo.Storage.GetReadOnlyContext")
+		},
+		func(buf *bytes.Buffer) {
+			vm.EmitSyscall(buf, "Neo.Storage.GetContext")
+			vm.EmitSyscall(buf, "Neo.StorageContext.AsReadOnly")
+		},
+	} {
+		cs := newStorageContract(func(buf *bytes.Buffer) {
+			vm.EmitString(buf, "value")
+			vm.EmitString(buf, "key")
+			emitContext(buf)
+			vm.EmitSyscall(buf, "Neo.Storage.Put")
+		})
+		ic := newInteropContext(TriggerApplication, bc, nil, nil)
+		assert.Nil(t, putContractState(ic.store, cs))
+
+		v := ic.newVM()
+		v.LoadScript(cs.Script)
+		v.Run()
+		assert.True(t, v.HasFailed())
+		assert.Nil(t, ic.getStorageItem(cs.ScriptHash(), []byte("key")))
+	}
+}
+
+func emitContractFields(buf *bytes.Buffer, script []byte, properties byte) {
+	vm.EmitString(buf, "description")
+	vm.EmitString(buf, "email")
+	vm.EmitString(buf, "author")
+	vm.EmitString(buf, "2.0")
+	vm.EmitString(buf, "name")
+	vm.EmitInt(buf, int64(properties))
+	vm.EmitInt(buf, 5)
+	vm.EmitBytes(buf, []byte{})
+	vm.EmitBytes(buf, script)
+}
+
+func TestInteropContractMigrate(t *testing.T) {
+	bc := newTestChain(t)
+	script := []byte{byte(vm.Opush2)}
+	cs := newStorageContract(func(buf *bytes.Buffer) {
+		emitPut(buf, "key", "value")
+		emitContractFields(buf, script, hasStorageFlag|isPayableFlag)
+		vm.EmitSyscall(buf, "Neo.Contract.Migrate")
+		vm.EmitSyscall(buf, "Neo.Contract.IsPayable")
+	})
+	ic := newInteropContext(TriggerApplication, bc, nil, nil)
+	assert.Nil(t, putContractState(ic.store, cs))
+
+	v := ic.newVM()
+	runHalting(t, v, cs.Script)
+	assert.True(t, v.Estack().Pop().Bool())
+
+	hash, err := util.Uint160FromScript(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrated := getContractState(ic.store, hash)
+	if migrated == nil {
+		t.Fatal("no contract created")
+	}
+	assert.True(t, migrated.HasStorage)
+	assert.True(t, migrated.IsPayable)
+	item := ic.getStorageItem(hash, []byte("key"))
+	if item == nil {
+		t.Fatal("storage not migrated")
+	}
+	assert.Equal(t, []byte("value"), item.Value)
+	assert.Nil(t, ic.getContract(cs.ScriptHash()))
+	assert.Nil(t, ic.getStorageItem(cs.ScriptHash(), []byte("key")))
+}
+
+func TestInteropContractDestroy(t *testing.T) {
+	bc := newTestChain(t)
+	cs := newStorageContract(func(buf *bytes.Buffer) {
+		emitPut(buf, "key", "value")
+		vm.EmitSyscall(buf, "Neo.Contract.Destroy")
+	})
+	ic := newInteropContext(TriggerApplication, bc, nil, nil)
+	assert.Nil(t, putContractState(ic.store, cs))
+	assert.Nil(t, putStorageItem(ic.store, cs.ScriptHash(), []byte("other"), &StorageItem{Value: []byte{1}}))
+
+	v := ic.newVM()
+	runHalting(t, v, cs.Script)
+	assert.Nil(t, ic.getContract(cs.ScriptHash()))
+	assert.Nil(t, ic.getStorageItem(cs.ScriptHash(), []byte("key")))
+	assert.Nil(t, ic.getStorageItem(cs.ScriptHash(), []byte("other")))
+}
+
+func TestInteropTxGetWitnesses(t *testing.T) {
+	bc := newTestChain(t)
+	cs := &ContractState{Script: []byte{byte(vm.Opush1)}}
+	assert.Nil(t, putContractState(bc.Store, cs))
+
+	tx := newInvocationTX([]byte{byte(vm.Opush1)})
+	tx.Attributes = []*transaction.Attribute{{
+		Usage: transaction.Script,
+		Data:  cs.ScriptHash().Bytes(),
+	}}
+	tx.Scripts = []*transaction.Witness{{InvocationScript: []byte{}, VerificationScript: []byte{}}}
+
+	buf := new(bytes.Buffer)
+	vm.EmitSyscall(buf, "System.ExecutionEngine.GetScriptContainer")
+	vm.EmitSyscall(buf, "Neo.Transaction.GetWitnesses")
+	vm.EmitInt(buf, 0)
+	vm.EmitOpcode(buf, vm.Opickitem)
+	vm.EmitSyscall(buf, "Neo.Witness.GetVerificationScript")
+
+	v := newInteropContext(TriggerApplication, bc, nil, tx).newVM()
+	runHalting(t, v, buf.Bytes())
+	assert.Equal(t, cs.Script, v.Estack().Pop().Bytes())
+}
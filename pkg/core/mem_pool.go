@@ -0,0 +1,217 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// Default capacity of the memory pool.
+const memPoolCapacity = 50000
+
+var (
+	// ErrAlreadyExists is returned when trying to add a transaction that
+	// is already in the memory pool or in the chain.
+	ErrAlreadyExists = errors.New("transaction already exists")
+	// ErrConflict is returned when a transaction spends or claims an input
+	// that is already spent or claimed by a transaction in the memory pool.
+	ErrConflict = errors.New("transaction conflicts with the memory pool")
+	// ErrOOM is returned when the memory pool is full and the transaction
+	// doesn't pay enough to replace any of the pooled transactions.
+	ErrOOM = errors.New("memory pool is full")
+)
+
+// PoolItem represents a transaction in the memory pool.
+type PoolItem struct {
+	txn       *transaction.Transaction
+	timeStamp time.Time
+	netFee    util.Fixed8
+	size      int
+}
+
+// NewPoolItem returns a new PoolItem for the given transaction, which pays
+// the given network fee and has the given size in bytes.
+func NewPoolItem(t *transaction.Transaction, netFee util.Fixed8, size int) *PoolItem {
+	return &PoolItem{
+		txn:       t,
+		timeStamp: time.Now().UTC(),
+		netFee:    netFee,
+		size:      size,
+	}
+}
+
+// lessThan returns true if p has a lower priority than other. Transactions
+// with a higher fee per byte are preferred, then transactions with a higher
+// fee and then the older ones.
+func (p *PoolItem) lessThan(other *PoolItem) bool {
+	// Compare netFee/size with other.netFee/other.size without dividing.
+	pFee := int64(p.netFee) * int64(other.size)
+	otherFee := int64(other.netFee) * int64(p.size)
+	if pFee != otherFee {
+		return pFee < otherFee
+	}
+	if p.netFee != other.netFee {
+		return p.netFee < other.netFee
+	}
+	return p.timeStamp.After(other.timeStamp)
+}
+
+// MemPool stores the verified transactions that are not yet included
+// in a block.
+type MemPool struct {
+	lock     sync.RWMutex
+	verified map[util.Uint256]*PoolItem
+	// The inputs spent by the pooled transactions, used to detect conflicts.
+	inputs map[transaction.Input]util.Uint256
+	// The coins claimed by the pooled claim transactions.
+	claims   map[transaction.Input]util.Uint256
+	capacity int
+}
+
+// NewMemPool returns a new MemPool that holds at most capacity transactions.
+func NewMemPool(capacity int) *MemPool {
+	return &MemPool{
+		verified: make(map[util.Uint256]*PoolItem),
+		inputs:   make(map[transaction.Input]util.Uint256),
+		claims:   make(map[transaction.Input]util.Uint256),
+		capacity: capacity,
+	}
+}
+
+// Count returns the number of transactions in the pool.
+func (mp *MemPool) Count() int {
+	mp.lock.RLock()
+	defer mp.lock.RUnlock()
+	return len(mp.verified)
+}
+
+// ContainsKey returns true if the transaction with the given hash is in
+// the pool.
+func (mp *MemPool) ContainsKey(hash util.Uint256) bool {
+	mp.lock.RLock()
+	defer mp.lock.RUnlock()
+	_, ok := mp.verified[hash]
+	return ok
+}
+
+// TryGetValue returns the transaction with the given hash if it's in
+// the pool.
+func (mp *MemPool) TryGetValue(hash util.Uint256) (*transaction.Transaction, bool) {
+	mp.lock.RLock()
+	defer mp.lock.RUnlock()
+	if item, ok := mp.verified[hash]; ok {
+		return item.txn, true
+	}
+	return nil, false
+}
+
+// Verify returns true if none of the inputs of the given transaction is
+// spent by a transaction in the pool and none of its claims is claimed by
+// a transaction in the pool.
+func (mp *MemPool) Verify(t *transaction.Transaction) bool {
+	mp.lock.RLock()
+	defer mp.lock.RUnlock()
+	return mp.verify(t)
+}
+
+func (mp *MemPool) verify(t *transaction.Transaction) bool {
+	for _, input := range t.Inputs {
+		if _, ok := mp.inputs[*input]; ok {
+			return false
+		}
+	}
+	if claim, ok := t.Data.(*transaction.ClaimTX); ok {
+		for _, input := range claim.Claims {
+			if _, ok := mp.claims[*input]; ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// TryAdd adds the given item to the pool. When the pool is full, the item
+// with the lowest priority is evicted if the given one has a higher priority.
+func (mp *MemPool) TryAdd(item *PoolItem) error {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+
+	hash := item.txn.Hash()
+	if _, ok := mp.verified[hash]; ok {
+		return ErrAlreadyExists
+	}
+	if !mp.verify(item.txn) {
+		return ErrConflict
+	}
+	if len(mp.verified) >= mp.capacity {
+		var lowest *PoolItem
+		for _, pooled := range mp.verified {
+			if lowest == nil || pooled.lessThan(lowest) {
+				lowest = pooled
+			}
+		}
+		if !lowest.lessThan(item) {
+			return ErrOOM
+		}
+		mp.remove(lowest.txn.Hash())
+	}
+
+	mp.verified[hash] = item
+	for _, input := range item.txn.Inputs {
+		mp.inputs[*input] = hash
+	}
+	if claim, ok := item.txn.Data.(*transaction.ClaimTX); ok {
+		for _, input := range claim.Claims {
+			mp.claims[*input] = hash
+		}
+	}
+	return nil
+}
+
+// Remove removes the transaction with the given hash from the pool.
+func (mp *MemPool) Remove(hash util.Uint256) {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+	mp.remove(hash)
+}
+
+func (mp *MemPool) remove(hash util.Uint256) {
+	item, ok := mp.verified[hash]
+	if !ok {
+		return
+	}
+	for _, input := range item.txn.Inputs {
+		delete(mp.inputs, *input)
+	}
+	if claim, ok := item.txn.Data.(*transaction.ClaimTX); ok {
+		for _, input := range claim.Claims {
+			delete(mp.claims, *input)
+		}
+	}
+	delete(mp.verified, hash)
+}
+
+// RemoveStale removes all the transactions for which isOK returns false.
+func (mp *MemPool) RemoveStale(isOK func(*transaction.Transaction) bool) {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+	for hash, item := range mp.verified {
+		if !isOK(item.txn) {
+			mp.remove(hash)
+		}
+	}
+}
+
+// GetVerifiedTransactions returns all the transactions in the pool.
+func (mp *MemPool) GetVerifiedTransactions() []*transaction.Transaction {
+	mp.lock.RLock()
+	defer mp.lock.RUnlock()
+	txs := make([]*transaction.Transaction, 0, len(mp.verified))
+	for _, item := range mp.verified {
+		txs = append(txs, item.txn)
+	}
+	return txs
+}
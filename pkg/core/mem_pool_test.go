@@ -0,0 +1,86 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPoolTX(prevIndex uint16) *transaction.Transaction {
+	tx := newTX(transaction.ContractType)
+	tx.Data = &transaction.ContractTX{}
+	tx.Inputs = []*transaction.Input{{PrevHash: util.Uint256{1, 2, 3}, PrevIndex: prevIndex}}
+	return tx
+}
+
+func TestMemPoolAddRemove(t *testing.T) {
+	mp := NewMemPool(10)
+	tx := newPoolTX(0)
+
+	assert.Nil(t, mp.TryAdd(NewPoolItem(tx, 0, 100)))
+	assert.Equal(t, ErrAlreadyExists, mp.TryAdd(NewPoolItem(tx, 0, 100)))
+	assert.Equal(t, 1, mp.Count())
+	assert.True(t, mp.ContainsKey(tx.Hash()))
+
+	pooled, ok := mp.TryGetValue(tx.Hash())
+	assert.True(t, ok)
+	assert.Equal(t, tx, pooled)
+	assert.Equal(t, []*transaction.Transaction{tx}, mp.GetVerifiedTransactions())
+
+	mp.Remove(tx.Hash())
+	assert.Equal(t, 0, mp.Count())
+	_, ok = mp.TryGetValue(tx.Hash())
+	assert.False(t, ok)
+	// The inputs are released as well.
+	assert.True(t, mp.Verify(tx))
+}
+
+func TestMemPoolConflicts(t *testing.T) {
+	mp := NewMemPool(10)
+	tx1 := newPoolTX(0)
+	assert.Nil(t, mp.TryAdd(NewPoolItem(tx1, 0, 100)))
+
+	tx2 := newPoolTX(0)
+	tx2.Outputs = []*transaction.Output{transaction.NewOutput(util.Uint256{}, 1, util.Uint160{})}
+	assert.False(t, mp.Verify(tx2))
+	assert.Equal(t, ErrConflict, mp.TryAdd(NewPoolItem(tx2, 0, 100)))
+
+	assert.True(t, mp.Verify(newPoolTX(1)))
+}
+
+func TestMemPoolEviction(t *testing.T) {
+	mp := NewMemPool(2)
+	cheap := newPoolTX(0)
+	expensive := newPoolTX(1)
+	assert.Nil(t, mp.TryAdd(NewPoolItem(cheap, util.NewFixed8(1), 100)))
+	assert.Nil(t, mp.TryAdd(NewPoolItem(expensive, util.NewFixed8(2), 100)))
+
+	// Same fee as the cheapest one, but newer, nothing to evict.
+	assert.Equal(t, ErrOOM, mp.TryAdd(NewPoolItem(newPoolTX(2), util.NewFixed8(1), 100)))
+
+	// Higher fee per byte evicts the cheapest one.
+	tx := newPoolTX(3)
+	assert.Nil(t, mp.TryAdd(NewPoolItem(tx, util.NewFixed8(1), 50)))
+	assert.Equal(t, 2, mp.Count())
+	assert.False(t, mp.ContainsKey(cheap.Hash()))
+	assert.True(t, mp.ContainsKey(expensive.Hash()))
+	assert.True(t, mp.ContainsKey(tx.Hash()))
+}
+
+func TestMemPoolRemoveStale(t *testing.T) {
+	mp := NewMemPool(10)
+	tx1 := newPoolTX(0)
+	tx2 := newPoolTX(1)
+	assert.Nil(t, mp.TryAdd(NewPoolItem(tx1, 0, 100)))
+	assert.Nil(t, mp.TryAdd(NewPoolItem(tx2, 0, 100)))
+
+	mp.RemoveStale(func(t *transaction.Transaction) bool {
+		return t.Inputs[0].PrevIndex != 0
+	})
+	assert.Equal(t, 1, mp.Count())
+	assert.False(t, mp.ContainsKey(tx1.Hash()))
+	assert.True(t, mp.ContainsKey(tx2.Hash()))
+	assert.True(t, mp.Verify(tx1))
+}
@@ -0,0 +1,303 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// Number of keys changed by a migration step between two writes.
+var migrationBatchSize = 10000
+
+// migration is a step upgrading the storage schema from a version to the
+// next one. As an interrupted step is run again the next time the node
+// starts, steps must be able to run on partially migrated data.
+type migration struct {
+	from        string
+	to          string
+	description string
+	run         func(bc *Blockchain) error
+}
+
+// migrations are the registered migration steps, a storage with a version
+// that isn't the one of a step nor the current one can't be migrated.
+var migrations = []migration{{
+	from:        "0.0.1",
+	to:          "0.1.0",
+	description: "persist the stored blocks again, storing their system fees, the states and the indexes",
+	run:         migrateTo010,
+}}
+
+// migrate runs the migration steps upgrading the store of the chain from the
+// given version to the other one, storing the version reached after every
+// step.
+func (bc *Blockchain) migrate(from, to string) error {
+	for ver := from; ver != to; {
+		var step *migration
+		for i := range migrations {
+			if migrations[i].from == ver {
+				step = &migrations[i]
+				break
+			}
+		}
+		if step == nil {
+			return fmt.Errorf("storage version %s can't be migrated to %s, the chain has to be synchronized again", ver, to)
+		}
+
+		log.WithFields(log.Fields{
+			"from": step.from,
+			"to":   step.to,
+		}).Infof("migrating storage: %s", step.description)
+		start := time.Now()
+		if err := step.run(bc); err != nil {
+			return fmt.Errorf("storage migration from %s to %s failed: %s", step.from, step.to, err)
+		}
+		if err := storage.PutVersion(bc.Store, step.to); err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{
+			"version": step.to,
+			"took":    time.Since(start),
+		}).Info("storage migrated")
+		ver = step.to
+	}
+	return nil
+}
+
+// migrateKeys calls f on every key with the given prefix, letting it put
+// the changes of the key in the given store. They are written every
+// migrationBatchSize keys, while the store isn't being read, and the
+// progress is logged.
+func migrateKeys(s storage.Store, prefix storage.KeyPrefix, f func(k, v []byte, s storage.Store) error) error {
+	var (
+		r    = storage.PrefixRange(prefix.Bytes())
+		done int
+	)
+	for {
+		var (
+			cache = storage.NewMemCachedStore(s)
+			count int
+			last  []byte
+			ferr  error
+		)
+		err := s.Iterate(r, func(k, v []byte) bool {
+			if ferr = f(k, v, cache); ferr != nil {
+				return false
+			}
+			last = append(last[:0], k...)
+			count++
+			return count < migrationBatchSize
+		})
+		if err != nil {
+			return err
+		}
+		if ferr != nil {
+			return ferr
+		}
+		if count == 0 {
+			return nil
+		}
+		if _, err := cache.Persist(); err != nil {
+			return err
+		}
+		done += count
+		log.WithFields(log.Fields{
+			"prefix": fmt.Sprintf("%#x", byte(prefix)),
+			"keys":   done,
+		}).Info("migrating storage")
+
+		// The next keys follow the last one visited.
+		r.Start = append(last, 0)
+	}
+}
+
+// Length of the fields of a block its hash is computed from.
+const blockHashableSize = 4 + 32 + 32 + 4 + 4 + 8 + 20
+
+// blockDataOffset returns the position of the trimmed block with the given
+// hash in the given stored value, 0 if it was stored by the version 0.0.1,
+// which didn't prefix it with the system fee.
+func blockDataOffset(hash util.Uint256, v []byte) (int, error) {
+	for _, offset := range []int{4, 0} {
+		if len(v) < offset+blockHashableSize {
+			continue
+		}
+		var computed util.Uint256
+		computed = sha256.Sum256(v[offset : offset+blockHashableSize])
+		computed = sha256.Sum256(computed.Bytes())
+		if computed.Equals(hash) {
+			return offset, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid block data for %s", hash)
+}
+
+// headerHashes returns the hashes of the stored headers by height, walking
+// the chain back from the current header. Blocks and headers stored with or
+// without a system fee are both read.
+func headerHashes(s storage.Store) ([]util.Uint256, error) {
+	b, err := s.Get(storage.SYSCurrentHeader.Bytes())
+	if err == storage.ErrKeyNotFound {
+		b, err = s.Get(storage.SYSCurrentBlock.Bytes())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 36 {
+		return nil, fmt.Errorf("invalid current header data")
+	}
+	hash, err := util.Uint256DecodeBytes(b[:32])
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]util.Uint256, binary.LittleEndian.Uint32(b[32:36])+1)
+	for i := len(hashes) - 1; ; i-- {
+		hashes[i] = hash
+		if i == 0 {
+			return hashes, nil
+		}
+		v, err := s.Get(storage.AppendPrefix(storage.DataBlock, hash.BytesReverse()))
+		if err != nil {
+			return nil, fmt.Errorf("could not get header %s: %s", hash, err)
+		}
+		offset, err := blockDataOffset(hash, v)
+		if err != nil {
+			return nil, err
+		}
+		copy(hash[:], v[offset+4:offset+36])
+	}
+}
+
+// storedBlock returns the block or the header stored with the given hash and
+// the offset of its data, see blockDataOffset. The transactions of a block
+// are read, headers having none.
+func storedBlock(s storage.Store, hash util.Uint256) (*Block, int, error) {
+	v, err := s.Get(storage.AppendPrefix(storage.DataBlock, hash.BytesReverse()))
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not get block %s: %s", hash, err)
+	}
+	offset, err := blockDataOffset(hash, v)
+	if err != nil {
+		return nil, 0, err
+	}
+	block, err := NewBlockFromTrimmedBytes(v[offset:])
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(block.Transactions) == 0 {
+		return block, offset, nil
+	}
+	for i, tx := range block.Transactions {
+		if block.Transactions[i], _, err = getTransaction(s, tx.Hash()); err != nil {
+			return nil, 0, fmt.Errorf("could not get transaction %s: %s", tx.Hash(), err)
+		}
+	}
+	block.Trimmed = false
+	return block, offset, nil
+}
+
+// migrateTo010 upgrades the storage from the version 0.0.1, which only
+// stored the blocks, the headers, the transactions and some of the states of
+// the accounts, the assets and the coins, without running the invocations.
+// Every state and index is dropped and the stored blocks are persisted
+// again, from the genesis one, while the headers following them are stored
+// again prefixed with a system fee like the blocks. The version 0.0.1 didn't
+// store the data of the publish transactions, a chain including some can't
+// be migrated.
+func migrateTo010(bc *Blockchain) error {
+	hashes, err := headerHashes(bc.Store)
+	if err != nil {
+		return err
+	}
+
+	// The blocks are checked before anything is dropped, their
+	// transactions having to be the ones they were received with.
+	for _, hash := range hashes {
+		block, _, err := storedBlock(bc.Store, hash)
+		if err != nil {
+			return err
+		}
+		if len(block.Transactions) == 0 {
+			continue
+		}
+		root, err := block.computeMerkleRoot()
+		if err != nil {
+			return err
+		}
+		if !root.Equals(block.MerkleRoot) {
+			return fmt.Errorf("the transactions of the block %d were not stored entirely, the chain has to be synchronized again", block.Index)
+		}
+	}
+
+	// The undo data of the blocks persisted again would restore the data
+	// stored by the version 0.0.1, it's dropped once they are.
+	prefixes := []storage.KeyPrefix{
+		storage.DataUndo,
+		storage.STAccount,
+		storage.STCoin,
+		storage.STSpentCoin,
+		storage.STValidator,
+		storage.STAsset,
+		storage.STNotification,
+		storage.STContract,
+		storage.STStorage,
+		storage.IXValidatorsCount,
+		storage.IXUnspent,
+		storage.IXAddressHistory,
+		storage.IXNEP5Balance,
+		storage.IXNEP5Transfer,
+		storage.IXClaimable,
+	}
+	for _, prefix := range prefixes {
+		if err := deleteKeys(bc.Store, prefix); err != nil {
+			return err
+		}
+	}
+
+	// The blocks are persisted with the same headers as when they were
+	// first, the list being restored by init afterwards.
+	headerList := bc.headerList
+	defer func() { bc.headerList = headerList }()
+	bc.headerList = NewHeaderHashList(hashes...)
+
+	for i, hash := range hashes {
+		block, offset, err := storedBlock(bc.Store, hash)
+		if err != nil {
+			return err
+		}
+		if len(block.Transactions) != 0 {
+			if err := bc.persistBlock(block); err != nil {
+				return fmt.Errorf("could not persist block %d: %s", block.Index, err)
+			}
+		} else if offset == 0 {
+			batch := bc.Batch()
+			if err := storeAsBlock(batch, block, 0); err != nil {
+				return err
+			}
+			if err := bc.PutBatch(batch); err != nil {
+				return err
+			}
+		}
+
+		if (i+1)%migrationBatchSize == 0 || i+1 == len(hashes) {
+			log.WithFields(log.Fields{
+				"headers": i + 1,
+				"total":   len(hashes),
+			}).Info("migrating storage")
+		}
+	}
+	return deleteKeys(bc.Store, storage.DataUndo)
+}
+
+// deleteKeys deletes every key with the given prefix.
+func deleteKeys(s storage.Store, prefix storage.KeyPrefix) error {
+	return migrateKeys(s, prefix, func(k, v []byte, s storage.Store) error {
+		return s.Delete(k)
+	})
+}
@@ -0,0 +1,378 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/CityOfZion/neo-go/config"
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+// withMigrations runs f with the given migrations registered instead of the
+// actual ones.
+func withMigrations(steps []migration, f func()) {
+	registered := migrations
+	migrations = steps
+	defer func() { migrations = registered }()
+	f()
+}
+
+func TestMigrate(t *testing.T) {
+	batchSize := migrationBatchSize
+	migrationBatchSize = 10
+	defer func() { migrationBatchSize = batchSize }()
+
+	s := storage.NewMemoryStore()
+	for i := 0; i < 25; i++ {
+		if err := s.Put(storage.AppendPrefixInt(storage.STStorage, i), []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := storage.PutVersion(s, "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []migration{{
+		from:        "2",
+		to:          "3",
+		description: "index the values",
+		run: func(bc *Blockchain) error {
+			return migrateKeys(bc.Store, storage.STStorage, func(k, v []byte, s storage.Store) error {
+				return s.Put(storage.AppendPrefix(storage.IXNEP5Balance, v), k[1:])
+			})
+		},
+	}, {
+		from:        "1",
+		to:          "2",
+		description: "double the values",
+		run: func(bc *Blockchain) error {
+			return migrateKeys(bc.Store, storage.STStorage, func(k, v []byte, s storage.Store) error {
+				return s.Put(k, []byte{v[0] * 2})
+			})
+		},
+	}}
+	withMigrations(steps, func() {
+		assert.Nil(t, (&Blockchain{Store: s}).migrate("1", "3"))
+	})
+
+	ver, err := storage.Version(s)
+	assert.Nil(t, err)
+	assert.Equal(t, "3", ver)
+	for i := 0; i < 25; i++ {
+		value, err := s.Get(storage.AppendPrefixInt(storage.STStorage, i))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte{byte(i * 2)}, value)
+		_, err = s.Get(storage.AppendPrefix(storage.IXNEP5Balance, []byte{byte(i * 2)}))
+		assert.Nil(t, err)
+	}
+}
+
+func TestMigrateFailure(t *testing.T) {
+	bc := &Blockchain{Store: storage.NewMemoryStore()}
+	steps := []migration{{
+		from: "1",
+		to:   "2",
+		run:  func(*Blockchain) error { return nil },
+	}, {
+		from: "2",
+		to:   "3",
+		run:  func(*Blockchain) error { return errors.New("failed") },
+	}}
+	withMigrations(steps, func() {
+		// The version of the last successful step is kept.
+		assert.NotNil(t, bc.migrate("1", "3"))
+		ver, err := storage.Version(bc.Store)
+		assert.Nil(t, err)
+		assert.Equal(t, "2", ver)
+
+		assert.NotNil(t, bc.migrate("0", "3"))
+		assert.NotNil(t, bc.migrate("3", "2"))
+	})
+}
+
+func TestNewBlockchainOldVersion(t *testing.T) {
+	cfg, err := config.Load("../../config", config.ModePrivNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := storage.NewMemoryStore()
+	if _, err := NewBlockchain(store, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := storage.PutVersion(store, "0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	withMigrations(nil, func() {
+		_, err = NewBlockchain(store, cfg)
+	})
+	assert.NotNil(t, err)
+
+	withMigrations([]migration{{from: "0.0.1", to: version, run: func(*Blockchain) error { return nil }}}, func() {
+		_, err = NewBlockchain(store, cfg)
+	})
+	assert.Nil(t, err)
+	ver, err := storage.Version(store)
+	assert.Nil(t, err)
+	assert.Equal(t, version, ver)
+}
+
+// newOldStore returns a store holding what the version 0.0.1 stored for the
+// given chain: the blocks and the headers without their system fee, the
+// transactions, the publish ones without their data, the list of the
+// header hashes and the states of the accounts, the coins and the assets,
+// which differ from the ones it stored but are dropped anyway.
+func newOldStore(t *testing.T, bc *Blockchain) storage.Store {
+	old := storage.NewMemoryStore()
+	kept := map[storage.KeyPrefix]int{
+		storage.DataBlock:        0,
+		storage.DataTransaction:  0,
+		storage.STAccount:        0,
+		storage.STCoin:           0,
+		storage.STAsset:          0,
+		storage.SYSCurrentBlock:  0,
+		storage.SYSCurrentHeader: 0,
+	}
+	batch := old.Batch()
+	for k, v := range storeContent(bc) {
+		prefix := storage.KeyPrefix(k[0])
+		if _, ok := kept[prefix]; !ok {
+			continue
+		}
+		kept[prefix]++
+		switch prefix {
+		case storage.DataBlock:
+			v = v[4:]
+		case storage.DataTransaction:
+			tx := &transaction.Transaction{}
+			if err := tx.DecodeBinary(bytes.NewReader(v[4:])); err != nil {
+				t.Fatal(err)
+			}
+			if tx.Type != transaction.PublishType {
+				break
+			}
+			tx.Data = &transaction.ContractTX{}
+			buf := bytes.NewBuffer(append([]byte{}, v[:4]...))
+			if err := tx.EncodeBinary(buf); err != nil {
+				t.Fatal(err)
+			}
+			v = buf.Bytes()
+		case storage.STCoin:
+			v = []byte{byte(CoinStateSpent)}
+		}
+		batch.Put([]byte(k), v)
+	}
+	assert.Nil(t, old.PutBatch(batch))
+	for prefix, count := range kept {
+		assert.NotEqual(t, 0, count, "prefix %#x", byte(prefix))
+	}
+	if err := storage.PutVersion(old, "0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	return old
+}
+
+// newMigrationTestChain returns a new chain with its blocks not verified and
+// the history of the addresses enabled, and its configuration.
+func newMigrationTestChain(t *testing.T) (*Blockchain, config.Config) {
+	cfg, err := config.Load("../../config", config.ModePrivNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Test blocks are not signed, so we can't verify them.
+	cfg.ProtocolConfiguration.VerifyBlocks = false
+	cfg.ApplicationConfiguration.AddressHistory = true
+	bc, err := NewBlockchain(storage.NewMemoryStore(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bc, cfg
+}
+
+// migratedContent returns the content of the store of the given chain
+// without the undo data, the states of the accounts being decoded as their
+// balances are encoded in any order.
+func migratedContent(t *testing.T, bc *Blockchain) (map[string][]byte, map[string]*AccountState) {
+	content := storeContent(bc)
+	accounts := make(map[string]*AccountState)
+	for k, v := range content {
+		switch storage.KeyPrefix(k[0]) {
+		case storage.DataUndo:
+			delete(content, k)
+		case storage.STAccount:
+			account := &AccountState{}
+			if err := account.DecodeBinary(bytes.NewReader(v)); err != nil {
+				t.Fatal(err)
+			}
+			accounts[k] = account
+			delete(content, k)
+		}
+	}
+	return content, accounts
+}
+
+// contentWithPrefix returns the keys of the given store content with the
+// given prefix.
+func contentWithPrefix(content map[string][]byte, prefix storage.KeyPrefix) []string {
+	var keys []string
+	for k := range content {
+		if storage.KeyPrefix(k[0]) == prefix {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func TestMigrateTo010(t *testing.T) {
+	batchSize := migrationBatchSize
+	migrationBatchSize = 2
+	defer func() { migrationBatchSize = batchSize }()
+
+	bc, cfg := newMigrationTestChain(t)
+	genesis, err := createGenesisBlock(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueTX := genesis.Transactions[3]
+	neo := governingTokenTX().Hash()
+	gas := utilityTokenTX().Hash()
+	amount := issueTX.Outputs[0].Amount
+	owner := issueTX.Outputs[0].ScriptHash
+	alice, bob := util.Uint160{1, 2, 3}, util.Uint160{3, 2, 1}
+	newContractTX := func(inputs []*transaction.Input, outputs ...*transaction.Output) *transaction.Transaction {
+		return &transaction.Transaction{
+			Type:       transaction.ContractType,
+			Data:       &transaction.ContractTX{},
+			Attributes: []*transaction.Attribute{},
+			Inputs:     inputs,
+			Outputs:    outputs,
+		}
+	}
+	// The token isn't published, the invocation notifies the transfers
+	// itself.
+	newTransferTX := func(from, to util.Uint160, amount int64) *transaction.Transaction {
+		script := new(bytes.Buffer)
+		for _, err := range []error{
+			vm.EmitInt(script, amount),
+			vm.EmitBytes(script, addressBytes(to)),
+			vm.EmitBytes(script, addressBytes(from)),
+		} {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		script.Write(newTokenScript(t))
+		return newInvocationTX(script.Bytes())
+	}
+
+	shareTX := newContractTX([]*transaction.Input{{PrevHash: issueTX.Hash(), PrevIndex: 0}},
+		transaction.NewOutput(neo, util.NewFixed8(10), alice),
+		transaction.NewOutput(neo, util.NewFixed8(20), bob),
+		transaction.NewOutput(neo, amount-util.NewFixed8(30), owner),
+	)
+	prev := genesis.Header()
+	addBlock := func(txs ...*transaction.Transaction) *Block {
+		block := newSignedBlock(t, bc, prev, txs...)
+		assert.Nil(t, bc.AddBlock(block))
+		assert.Nil(t, bc.persist())
+		prev = block.Header()
+		return block
+	}
+	addBlock(shareTX, newTransferTX(util.Uint160{}, alice, 100))
+	addBlock(
+		newContractTX([]*transaction.Input{{PrevHash: shareTX.Hash(), PrevIndex: 0}},
+			transaction.NewOutput(neo, util.NewFixed8(10), alice)),
+		newContractTX([]*transaction.Input{{PrevHash: shareTX.Hash(), PrevIndex: 1}},
+			transaction.NewOutput(neo, util.NewFixed8(20), bob)),
+		newTransferTX(alice, bob, 30))
+	addBlock(&transaction.Transaction{
+		Type:       transaction.ClaimType,
+		Data:       &transaction.ClaimTX{Claims: []*transaction.Input{{PrevHash: shareTX.Hash(), PrevIndex: 0}}},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{},
+		Outputs:    []*transaction.Output{transaction.NewOutput(gas, util.Fixed8(80), alice)},
+	})
+	candidate := newCandidateKey(t)
+	block4 := addBlock(newStateTX(newRegisterDescriptor(candidate), newVoteDescriptor(t, bob, candidate)))
+	assert.Equal(t, uint32(4), bc.BlockHeight())
+	sysFee := bc.GetSysFeeAmount(block4.Hash())
+	assert.NotEqual(t, uint32(0), sysFee)
+
+	// The headers following the persisted blocks are stored too.
+	block5 := newSignedBlock(t, bc, prev)
+	block6 := newSignedBlock(t, bc, block5.Header())
+	assert.Nil(t, bc.AddHeaders(block5.Header(), block6.Header()))
+	assert.Equal(t, uint32(6), bc.HeaderHeight())
+
+	// The undo data of the blocks persisted before the migration is
+	// dropped.
+	snapshot, accounts := migratedContent(t, bc)
+	store := newOldStore(t, bc)
+	for _, prefix := range []storage.KeyPrefix{storage.STNotification, storage.STValidator,
+		storage.IXValidatorsCount, storage.IXNEP5Balance, storage.IXClaimable} {
+		assert.NotEmpty(t, contentWithPrefix(snapshot, prefix), "prefix %#x", byte(prefix))
+		assert.Empty(t, contentWithPrefix(storeContent(&Blockchain{Store: store}), prefix), "prefix %#x", byte(prefix))
+	}
+
+	bc, err = NewBlockchain(store, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(4), bc.BlockHeight())
+	assert.Equal(t, uint32(6), bc.HeaderHeight())
+	assert.Empty(t, contentWithPrefix(storeContent(bc), storage.DataUndo))
+	content, migratedAccounts := migratedContent(t, bc)
+	assert.Equal(t, snapshot, content)
+	assert.Equal(t, accounts, migratedAccounts)
+	header, err := bc.getHeader(block6.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, block6.Header(), header)
+
+	// The migration can be run again on migrated data.
+	assert.Nil(t, migrateTo010(bc))
+	content, migratedAccounts = migratedContent(t, bc)
+	assert.Equal(t, snapshot, content)
+	assert.Equal(t, accounts, migratedAccounts)
+
+	claimable, err := bc.GetClaimable(bob)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(claimable))
+	claimable, err = bc.GetClaimable(alice)
+	assert.Nil(t, err)
+	assert.Empty(t, claimable)
+	assert.Equal(t, sysFee, bc.GetSysFeeAmount(block4.Hash()))
+	validators, err := bc.GetValidators()
+	assert.Nil(t, err)
+	assert.True(t, containsKey(validators, candidate))
+
+	// The following blocks are persisted on top of the migrated ones.
+	assert.Nil(t, bc.AddBlock(block5))
+	assert.Nil(t, bc.persist())
+	assert.Equal(t, uint32(5), bc.BlockHeight())
+}
+
+func TestMigrateTo010PublishTX(t *testing.T) {
+	bc, cfg := newMigrationTestChain(t)
+	genesis, err := createGenesisBlock(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := newSignedBlock(t, bc, genesis.Header(), newPublishTX(newTokenScript(t)))
+	assert.Nil(t, bc.AddBlock(block))
+	assert.Nil(t, bc.persist())
+
+	// The contract can't be stored again, the data of the publish
+	// transaction being lost, nothing is changed.
+	store := newOldStore(t, bc)
+	content := storeContent(&Blockchain{Store: store})
+	_, err = NewBlockchain(store, cfg)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "synchronized again")
+	}
+	assert.Equal(t, content, storeContent(&Blockchain{Store: store}))
+}
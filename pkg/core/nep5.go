@@ -0,0 +1,329 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+)
+
+// nep5TransferEvent is the name of the event NEP-5 contracts send through
+// Runtime.Notify for every transfer, followed by the sender, the receiver
+// and the amount.
+const nep5TransferEvent = "transfer"
+
+// getNEP5Balance returns the balance of the given account in the given
+// token in the given store, a zero one if the account never received the
+// token.
+func getNEP5Balance(s storage.Store, address, asset util.Uint160) (*NEP5Balance, error) {
+	b, err := s.Get(makeNEP5BalanceKey(address, asset))
+	if err == storage.ErrKeyNotFound {
+		return &NEP5Balance{Asset: asset, Amount: new(big.Int)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	balance := &NEP5Balance{}
+	if err := balance.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("failed to decode (NEP5Balance): %s", err)
+	}
+	return balance, nil
+}
+
+// addNEP5Balance adds the given amount to the balance of the given account
+// in the given token, changed in the block with the given index.
+func addNEP5Balance(s storage.Store, address, asset util.Uint160, amount *big.Int, index uint32) error {
+	balance, err := getNEP5Balance(s, address, asset)
+	if err != nil {
+		return err
+	}
+	balance.Amount.Add(balance.Amount, amount)
+	balance.LastUpdatedBlock = index
+	buf := new(bytes.Buffer)
+	if err := balance.EncodeBinary(buf); err != nil {
+		return err
+	}
+	return s.Put(makeNEP5BalanceKey(address, asset), buf.Bytes())
+}
+
+// makeNEP5BalanceKey returns the key of the balance of the given account in
+// the given token.
+func makeNEP5BalanceKey(address, asset util.Uint160) []byte {
+	return storage.AppendPrefix(storage.IXNEP5Balance, append(address.Bytes(), asset.Bytes()...))
+}
+
+// makeNEP5TransferKey returns the key of the given transfer in the log of
+// the given account. The position of the transfer is stored inverted so that
+// the newest transfers come first.
+func makeNEP5TransferKey(address util.Uint160, transfer *NEP5Transfer) []byte {
+	position := make([]byte, 8)
+	binary.BigEndian.PutUint32(position, ^transfer.Block)
+	binary.BigEndian.PutUint16(position[4:], ^transfer.TxIndex)
+	binary.BigEndian.PutUint16(position[6:], ^transfer.NotifyIndex)
+	return storage.AppendPrefix(storage.IXNEP5Transfer, append(address.Bytes(), position...))
+}
+
+// NEP5Balance is the balance of an account in a NEP-5 token together with
+// the index of the block it was last changed in.
+type NEP5Balance struct {
+	Asset            util.Uint160
+	Amount           *big.Int
+	LastUpdatedBlock uint32
+}
+
+// DecodeBinary decodes NEP5Balance from the given io.Reader.
+func (nb *NEP5Balance) DecodeBinary(r io.Reader) error {
+	if err := binary.Read(r, binary.LittleEndian, &nb.Asset); err != nil {
+		return err
+	}
+	amount, err := util.ReadVarBytes(r)
+	if err != nil {
+		return err
+	}
+	nb.Amount = vm.BigIntFromBytes(amount)
+	return binary.Read(r, binary.LittleEndian, &nb.LastUpdatedBlock)
+}
+
+// EncodeBinary encodes NEP5Balance to the given io.Writer.
+func (nb *NEP5Balance) EncodeBinary(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, nb.Asset); err != nil {
+		return err
+	}
+	if err := util.WriteVarBytes(w, vm.BigIntToBytes(nb.Amount)); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, nb.LastUpdatedBlock)
+}
+
+// NEP5Transfer is a transfer of NEP-5 tokens. From is empty when tokens are
+// minted and To when they are burnt.
+type NEP5Transfer struct {
+	Asset       util.Uint160
+	From        util.Uint160
+	To          util.Uint160
+	Amount      *big.Int
+	Block       uint32
+	Timestamp   uint32
+	TxHash      util.Uint256
+	TxIndex     uint16
+	NotifyIndex uint16
+}
+
+// DecodeBinary decodes NEP5Transfer from the given io.Reader.
+func (nt *NEP5Transfer) DecodeBinary(r io.Reader) error {
+	if err := binary.Read(r, binary.LittleEndian, &nt.Asset); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &nt.From); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &nt.To); err != nil {
+		return err
+	}
+	amount, err := util.ReadVarBytes(r)
+	if err != nil {
+		return err
+	}
+	nt.Amount = vm.BigIntFromBytes(amount)
+	if err := binary.Read(r, binary.LittleEndian, &nt.Block); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &nt.Timestamp); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &nt.TxHash); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &nt.TxIndex); err != nil {
+		return err
+	}
+	return binary.Read(r, binary.LittleEndian, &nt.NotifyIndex)
+}
+
+// EncodeBinary encodes NEP5Transfer to the given io.Writer.
+func (nt *NEP5Transfer) EncodeBinary(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, nt.Asset); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, nt.From); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, nt.To); err != nil {
+		return err
+	}
+	if err := util.WriteVarBytes(w, vm.BigIntToBytes(nt.Amount)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, nt.Block); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, nt.Timestamp); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, nt.TxHash); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, nt.TxIndex); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, nt.NotifyIndex)
+}
+
+// decodeNEP5Transfer decodes the sender, the receiver and the amount of the
+// given notification, ok being false if it's not a NEP-5 transfer.
+func decodeNEP5Transfer(event NotificationEvent) (from, to util.Uint160, amount *big.Int, ok bool) {
+	items, isArray := event.Item.Value.([]smartcontract.Parameter)
+	if event.Item.Type != smartcontract.ArrayType || !isArray || len(items) != 4 {
+		return from, to, nil, false
+	}
+	name, err := parameterBytes(items[0])
+	if err != nil || string(name) != nep5TransferEvent {
+		return from, to, nil, false
+	}
+	if from, err = decodeAddressParameter(items[1]); err != nil {
+		return from, to, nil, false
+	}
+	if to, err = decodeAddressParameter(items[2]); err != nil {
+		return from, to, nil, false
+	}
+
+	b, err := parameterBytes(items[3])
+	if err != nil {
+		return from, to, nil, false
+	}
+	amount = vm.BigIntFromBytes(b)
+	if amount.Sign() < 0 {
+		return from, to, nil, false
+	}
+	return from, to, amount, true
+}
+
+// parameterBytes returns the value of the given byte array or integer
+// parameter as a byte array, the way the VM converts stack items.
+func parameterBytes(p smartcontract.Parameter) ([]byte, error) {
+	s, ok := p.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s parameter value", p.Type)
+	}
+	switch p.Type {
+	case smartcontract.ByteArrayType:
+		return hex.DecodeString(s)
+	case smartcontract.IntegerType:
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		return vm.BigIntToBytes(n), nil
+	default:
+		return nil, fmt.Errorf("%s parameter can't be converted to bytes", p.Type)
+	}
+}
+
+// decodeAddressParameter returns the script hash in the given parameter,
+// empty if the parameter is.
+func decodeAddressParameter(p smartcontract.Parameter) (util.Uint160, error) {
+	b, err := parameterBytes(p)
+	if err != nil || len(b) == 0 {
+		return util.Uint160{}, err
+	}
+	return util.Uint160DecodeBytes(b)
+}
+
+// processNEP5Transfers updates, in the given store, the balances and the
+// transfer logs of the accounts involved in the NEP-5 transfers notified by
+// the given invocation result, the transaction being found at the given
+// index of the given block.
+func processNEP5Transfers(s storage.Store, block *Block, index uint16, aer *AppExecResult) error {
+	buf := new(bytes.Buffer)
+	for i, event := range aer.Events {
+		from, to, amount, ok := decodeNEP5Transfer(event)
+		if !ok {
+			continue
+		}
+		transfer := &NEP5Transfer{
+			Asset:       event.ScriptHash,
+			From:        from,
+			To:          to,
+			Amount:      amount,
+			Block:       block.Index,
+			Timestamp:   block.Timestamp,
+			TxHash:      aer.TxHash,
+			TxIndex:     index,
+			NotifyIndex: uint16(i),
+		}
+		if err := transfer.EncodeBinary(buf); err != nil {
+			return err
+		}
+
+		if !from.Equals(util.Uint160{}) {
+			if err := addNEP5Balance(s, from, event.ScriptHash, new(big.Int).Neg(amount), block.Index); err != nil {
+				return err
+			}
+			if err := s.Put(makeNEP5TransferKey(from, transfer), buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		if !to.Equals(util.Uint160{}) {
+			if err := addNEP5Balance(s, to, event.ScriptHash, amount, block.Index); err != nil {
+				return err
+			}
+			if err := s.Put(makeNEP5TransferKey(to, transfer), buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		buf.Reset()
+	}
+	return nil
+}
+
+// GetNEP5Balances returns the balances of the account with the given script
+// hash in all the NEP-5 tokens it ever received.
+func (bc *Blockchain) GetNEP5Balances(address util.Uint160) ([]*NEP5Balance, error) {
+	var (
+		balances []*NEP5Balance
+		err      error
+	)
+	bc.Seek(storage.AppendPrefix(storage.IXNEP5Balance, address.Bytes()), func(k, v []byte) {
+		balance := &NEP5Balance{}
+		if decodeErr := balance.DecodeBinary(bytes.NewReader(v)); decodeErr != nil {
+			err = fmt.Errorf("failed to decode (NEP5Balance): %s", decodeErr)
+			return
+		}
+		balances = append(balances, balance)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// GetNEP5Transfers returns, newest first, the NEP-5 transfers sent or
+// received by the account with the given script hash in the blocks with
+// timestamps between the given ones, included.
+func (bc *Blockchain) GetNEP5Transfers(address util.Uint160, start, end uint32) ([]*NEP5Transfer, error) {
+	var (
+		transfers []*NEP5Transfer
+		err       error
+	)
+	bc.Seek(storage.AppendPrefix(storage.IXNEP5Transfer, address.Bytes()), func(k, v []byte) {
+		transfer := &NEP5Transfer{}
+		if decodeErr := transfer.DecodeBinary(bytes.NewReader(v)); decodeErr != nil {
+			err = fmt.Errorf("failed to decode (NEP5Transfer): %s", decodeErr)
+			return
+		}
+		if transfer.Timestamp >= start && transfer.Timestamp <= end {
+			transfers = append(transfers, transfer)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
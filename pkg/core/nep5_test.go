@@ -0,0 +1,165 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTransferInvocationTX returns an invocation calling the given token to
+// notify a transfer of the given amount, the sender or the receiver being
+// left empty if they're zero.
+func newTransferInvocationTX(t *testing.T, token, from, to util.Uint160, amount int64) *transaction.Transaction {
+	script := new(bytes.Buffer)
+	for _, err := range []error{
+		vm.EmitInt(script, amount),
+		vm.EmitBytes(script, addressBytes(to)),
+		vm.EmitBytes(script, addressBytes(from)),
+		vm.EmitAppCall(script, token, false),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return newInvocationTX(script.Bytes())
+}
+
+func addressBytes(address util.Uint160) []byte {
+	if address.Equals(util.Uint160{}) {
+		return []byte{}
+	}
+	return address.Bytes()
+}
+
+// newTokenScript returns a contract notifying the transfers it's given.
+func newTokenScript(t *testing.T) []byte {
+	script := new(bytes.Buffer)
+	for _, err := range []error{
+		vm.EmitString(script, "transfer"),
+		vm.EmitInt(script, 4),
+		vm.EmitOpcode(script, vm.Opack),
+		vm.EmitSyscall(script, "Neo.Runtime.Notify"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return script.Bytes()
+}
+
+func TestDecodeNEP5Transfer(t *testing.T) {
+	from, to := util.Uint160{1}, util.Uint160{2}
+	newEvent := func(items ...smartcontract.Parameter) NotificationEvent {
+		return NotificationEvent{Item: smartcontract.Parameter{Type: smartcontract.ArrayType, Value: items}}
+	}
+	byteArray := func(b []byte) smartcontract.Parameter {
+		return smartcontract.Parameter{Type: smartcontract.ByteArrayType, Value: hex.EncodeToString(b)}
+	}
+	name := byteArray([]byte("transfer"))
+
+	f, tt, amount, ok := decodeNEP5Transfer(newEvent(name, byteArray(from.Bytes()), byteArray(to.Bytes()),
+		smartcontract.Parameter{Type: smartcontract.IntegerType, Value: "42"}))
+	assert.True(t, ok)
+	assert.Equal(t, from, f)
+	assert.Equal(t, to, tt)
+	assert.Equal(t, big.NewInt(42), amount)
+
+	// Amounts can be byte arrays and mints have no sender, pushed as an
+	// empty byte array or as zero.
+	for _, empty := range []smartcontract.Parameter{byteArray(nil), {Type: smartcontract.IntegerType, Value: "0"}} {
+		f, _, amount, ok = decodeNEP5Transfer(newEvent(name, empty, byteArray(to.Bytes()), byteArray([]byte{1, 1})))
+		assert.True(t, ok)
+		assert.Equal(t, util.Uint160{}, f)
+		assert.Equal(t, big.NewInt(257), amount)
+	}
+
+	for _, event := range []NotificationEvent{
+		newEvent(byteArray([]byte("approve")), byteArray(from.Bytes()), byteArray(to.Bytes()), byteArray([]byte{1})),
+		newEvent(name, byteArray([]byte{1, 2, 3}), byteArray(to.Bytes()), byteArray([]byte{1})),
+		newEvent(name, byteArray(from.Bytes()), byteArray(to.Bytes())),
+		newEvent(name, byteArray(from.Bytes()), byteArray(to.Bytes()), smartcontract.Parameter{Type: smartcontract.IntegerType, Value: "-1"}),
+		{Item: name},
+	} {
+		_, _, _, ok := decodeNEP5Transfer(event)
+		assert.False(t, ok)
+	}
+}
+
+func TestNEP5Tracking(t *testing.T) {
+	bc := newTestChain(t)
+	token := newTokenScript(t)
+	tokenHash, err := util.Uint160FromScript(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := util.Uint160{1, 2, 3}, util.Uint160{3, 2, 1}
+
+	mint := newTransferInvocationTX(t, tokenHash, util.Uint160{}, alice, 100)
+	block1 := newBlock(1, newPublishTX(token), mint)
+	assert.Nil(t, bc.persistBlock(block1))
+	send := newTransferInvocationTX(t, tokenHash, alice, bob, 30)
+	block2 := newBlock(2, send)
+	assert.Nil(t, bc.persistBlock(block2))
+
+	balances, err := bc.GetNEP5Balances(alice)
+	assert.Nil(t, err)
+	assert.Equal(t, []*NEP5Balance{{Asset: tokenHash, Amount: big.NewInt(70), LastUpdatedBlock: 2}}, balances)
+	balances, err = bc.GetNEP5Balances(bob)
+	assert.Nil(t, err)
+	assert.Equal(t, []*NEP5Balance{{Asset: tokenHash, Amount: big.NewInt(30), LastUpdatedBlock: 2}}, balances)
+
+	transfers, err := bc.GetNEP5Transfers(alice, 0, block2.Timestamp)
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(transfers)) {
+		assert.Equal(t, send.Hash(), transfers[0].TxHash)
+		assert.Equal(t, alice, transfers[0].From)
+		assert.Equal(t, bob, transfers[0].To)
+		assert.Equal(t, big.NewInt(30), transfers[0].Amount)
+		assert.Equal(t, uint32(2), transfers[0].Block)
+		assert.Equal(t, mint.Hash(), transfers[1].TxHash)
+		assert.Equal(t, util.Uint160{}, transfers[1].From)
+		assert.Equal(t, uint16(1), transfers[1].TxIndex)
+	}
+	transfers, err = bc.GetNEP5Transfers(bob, 0, block2.Timestamp-1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(transfers))
+}
+
+func TestNEP5BalanceEncodeDecode(t *testing.T) {
+	balance := &NEP5Balance{
+		Asset:            util.Uint160{1, 2, 3},
+		Amount:           big.NewInt(-12345),
+		LastUpdatedBlock: 42,
+	}
+	buf := new(bytes.Buffer)
+	assert.Nil(t, balance.EncodeBinary(buf))
+	decoded := &NEP5Balance{}
+	assert.Nil(t, decoded.DecodeBinary(buf))
+	assert.Equal(t, balance, decoded)
+}
+
+func TestNEP5TransferEncodeDecode(t *testing.T) {
+	transfer := &NEP5Transfer{
+		Asset:       util.Uint160{1, 2, 3},
+		From:        util.Uint160{4, 5, 6},
+		To:          util.Uint160{7, 8, 9},
+		Amount:      big.NewInt(12345),
+		Block:       42,
+		Timestamp:   1234567,
+		TxHash:      util.RandomUint256(),
+		TxIndex:     3,
+		NotifyIndex: 1,
+	}
+	buf := new(bytes.Buffer)
+	assert.Nil(t, transfer.EncodeBinary(buf))
+	decoded := &NEP5Transfer{}
+	assert.Nil(t, decoded.DecodeBinary(buf))
+	assert.Equal(t, transfer, decoded)
+}
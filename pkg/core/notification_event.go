@@ -0,0 +1,188 @@
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// NotificationEvent is an event sent by a contract through Runtime.Notify.
+type NotificationEvent struct {
+	ScriptHash util.Uint160
+	Item       smartcontract.Parameter
+}
+
+// AppExecResult is the result of the execution of the script of a
+// transaction, as recorded when the transaction is persisted.
+type AppExecResult struct {
+	TxHash      util.Uint256
+	Trigger     TriggerType
+	VMState     string
+	GasConsumed util.Fixed8
+	Stack       []smartcontract.Parameter
+	Events      []NotificationEvent
+}
+
+// DecodeBinary implements the Payload interface.
+func (ne *NotificationEvent) DecodeBinary(r io.Reader) error {
+	if err := binary.Read(r, binary.LittleEndian, &ne.ScriptHash); err != nil {
+		return err
+	}
+	return decodeParameter(r, &ne.Item)
+}
+
+// EncodeBinary implements the Payload interface.
+func (ne *NotificationEvent) EncodeBinary(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, ne.ScriptHash); err != nil {
+		return err
+	}
+	return encodeParameter(w, ne.Item)
+}
+
+// DecodeBinary implements the Payload interface.
+func (aer *AppExecResult) DecodeBinary(r io.Reader) error {
+	if err := binary.Read(r, binary.LittleEndian, &aer.TxHash); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &aer.Trigger); err != nil {
+		return err
+	}
+	var err error
+	if aer.VMState, err = util.ReadVarString(r); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &aer.GasConsumed); err != nil {
+		return err
+	}
+
+	lenStack := util.ReadVarUint(r)
+	aer.Stack = make([]smartcontract.Parameter, lenStack)
+	for i := range aer.Stack {
+		if err := decodeParameter(r, &aer.Stack[i]); err != nil {
+			return err
+		}
+	}
+
+	lenEvents := util.ReadVarUint(r)
+	aer.Events = make([]NotificationEvent, lenEvents)
+	for i := range aer.Events {
+		if err := aer.Events[i].DecodeBinary(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeBinary implements the Payload interface.
+func (aer *AppExecResult) EncodeBinary(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, aer.TxHash); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, aer.Trigger); err != nil {
+		return err
+	}
+	if err := util.WriteVarString(w, aer.VMState); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, aer.GasConsumed); err != nil {
+		return err
+	}
+
+	if err := util.WriteVarUint(w, uint64(len(aer.Stack))); err != nil {
+		return err
+	}
+	for _, p := range aer.Stack {
+		if err := encodeParameter(w, p); err != nil {
+			return err
+		}
+	}
+
+	if err := util.WriteVarUint(w, uint64(len(aer.Events))); err != nil {
+		return err
+	}
+	for i := range aer.Events {
+		if err := aer.Events[i].EncodeBinary(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeParameter writes the given parameter as produced from the VM stack,
+// see smartcontract.NewParameterFromStackItem.
+func encodeParameter(w io.Writer, p smartcontract.Parameter) error {
+	if err := binary.Write(w, binary.LittleEndian, byte(p.Type)); err != nil {
+		return err
+	}
+	switch p.Type {
+	case smartcontract.IntegerType, smartcontract.ByteArrayType:
+		s, ok := p.Value.(string)
+		if !ok {
+			return fmt.Errorf("invalid %s parameter value", p.Type)
+		}
+		return util.WriteVarString(w, s)
+	case smartcontract.BoolType:
+		b, ok := p.Value.(bool)
+		if !ok {
+			return fmt.Errorf("invalid %s parameter value", p.Type)
+		}
+		return binary.Write(w, binary.LittleEndian, b)
+	case smartcontract.ArrayType:
+		items, ok := p.Value.([]smartcontract.Parameter)
+		if !ok {
+			return fmt.Errorf("invalid %s parameter value", p.Type)
+		}
+		if err := util.WriteVarUint(w, uint64(len(items))); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := encodeParameter(w, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case smartcontract.InteropInterfaceType:
+		return nil
+	default:
+		return fmt.Errorf("unsupported parameter type %s", p.Type)
+	}
+}
+
+// decodeParameter reads a parameter written by encodeParameter.
+func decodeParameter(r io.Reader, p *smartcontract.Parameter) error {
+	var t byte
+	if err := binary.Read(r, binary.LittleEndian, &t); err != nil {
+		return err
+	}
+	p.Type = smartcontract.ParamType(t)
+	switch p.Type {
+	case smartcontract.IntegerType, smartcontract.ByteArrayType:
+		s, err := util.ReadVarString(r)
+		if err != nil {
+			return err
+		}
+		p.Value = s
+	case smartcontract.BoolType:
+		var b bool
+		if err := binary.Read(r, binary.LittleEndian, &b); err != nil {
+			return err
+		}
+		p.Value = b
+	case smartcontract.ArrayType:
+		items := make([]smartcontract.Parameter, util.ReadVarUint(r))
+		for i := range items {
+			if err := decodeParameter(r, &items[i]); err != nil {
+				return err
+			}
+		}
+		p.Value = items
+	case smartcontract.InteropInterfaceType:
+		p.Value = nil
+	default:
+		return fmt.Errorf("unsupported parameter type %s", p.Type)
+	}
+	return nil
+}
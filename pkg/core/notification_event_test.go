@@ -0,0 +1,43 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeAppExecResult(t *testing.T) {
+	aer := &AppExecResult{
+		TxHash:      util.Uint256{1, 2, 3},
+		Trigger:     TriggerApplication,
+		VMState:     "HALT",
+		GasConsumed: util.Fixed8(1230000),
+		Stack: []smartcontract.Parameter{
+			{Type: smartcontract.IntegerType, Value: "42"},
+			{Type: smartcontract.BoolType, Value: true},
+		},
+		Events: []NotificationEvent{{
+			ScriptHash: util.Uint160{4, 5, 6},
+			Item: smartcontract.Parameter{
+				Type: smartcontract.ArrayType,
+				Value: []smartcontract.Parameter{
+					{Type: smartcontract.ByteArrayType, Value: "7472616e73666572"},
+					{Type: smartcontract.InteropInterfaceType},
+				},
+			},
+		}},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := aer.EncodeBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+	aerDecode := &AppExecResult{}
+	if err := aerDecode.DecodeBinary(buf); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, aer, aerDecode)
+}
@@ -10,42 +10,36 @@ import (
 	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
-// SpentCoins is mapping between transactions and their spent
-// coin state.
-type SpentCoins map[util.Uint256]*SpentCoinState
-
-func (s SpentCoins) getAndUpdate(store storage.Store, hash util.Uint256) (*SpentCoinState, error) {
-	if spent, ok := s[hash]; ok {
-		return spent, nil
+// getSpentCoinState returns the spent governing token outputs of the
+// transaction with the given hash in the given store, a new state if none
+// of them is known.
+func getSpentCoinState(s storage.Store, hash util.Uint256, height uint32) (*SpentCoinState, error) {
+	b, err := s.Get(storage.AppendPrefix(storage.STSpentCoin, hash.BytesReverse()))
+	if err == storage.ErrKeyNotFound {
+		return NewSpentCoinState(hash, height), nil
+	}
+	if err != nil {
+		return nil, err
 	}
-
 	spent := &SpentCoinState{}
-	key := storage.AppendPrefix(storage.STSpentCoin, hash.BytesReverse())
-	if b, err := store.Get(key); err == nil {
-		if err := spent.DecodeBinary(bytes.NewReader(b)); err != nil {
-			return nil, fmt.Errorf("failed to decode (UnspentCoinState): %s", err)
-		}
-	} else {
-		spent = &SpentCoinState{
-			items: make(map[uint16]uint32),
-		}
+	if err := spent.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("failed to decode (SpentCoinState): %s", err)
 	}
-
-	s[hash] = spent
 	return spent, nil
 }
 
-func (s SpentCoins) commit(b storage.Batch) error {
+// putSpentCoinState stores the given spent coin state in the given store.
+// Coins are forgotten once they're all claimed.
+func putSpentCoinState(s storage.Store, spent *SpentCoinState) error {
+	key := storage.AppendPrefix(storage.STSpentCoin, spent.txHash.BytesReverse())
+	if len(spent.items) == 0 {
+		return s.Delete(key)
+	}
 	buf := new(bytes.Buffer)
-	for hash, state := range s {
-		if err := state.EncodeBinary(buf); err != nil {
-			return err
-		}
-		key := storage.AppendPrefix(storage.STSpentCoin, hash.BytesReverse())
-		b.Put(key, buf.Bytes())
-		buf.Reset()
+	if err := spent.EncodeBinary(buf); err != nil {
+		return err
 	}
-	return nil
+	return s.Put(key, buf.Bytes())
 }
 
 // SpentCoinState represents the state of a spent coin.
@@ -66,6 +60,22 @@ func NewSpentCoinState(hash util.Uint256, height uint32) *SpentCoinState {
 	}
 }
 
+// TxHash returns the hash of the transaction whose outputs are spent.
+func (s *SpentCoinState) TxHash() util.Uint256 {
+	return s.txHash
+}
+
+// TxHeight returns the height of the block including the transaction.
+func (s *SpentCoinState) TxHeight() uint32 {
+	return s.txHeight
+}
+
+// Items returns the heights the outputs were spent at, indexed by their
+// position.
+func (s *SpentCoinState) Items() map[uint16]uint32 {
+	return s.items
+}
+
 // DecodeBinary implements the Payload interface.
 func (s *SpentCoinState) DecodeBinary(r io.Reader) error {
 	if err := binary.Read(r, binary.LittleEndian, &s.txHash); err != nil {
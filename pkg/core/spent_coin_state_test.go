@@ -27,25 +27,36 @@ func TestEncodeDecodeSpentCoinState(t *testing.T) {
 	assert.Equal(t, spent, spentDecode)
 }
 
-func TestCommitSpentCoins(t *testing.T) {
-	var (
-		store      = storage.NewMemoryStore()
-		batch      = store.Batch()
-		spentCoins = make(SpentCoins)
-	)
-
-	txx := []util.Uint256{
-		util.RandomUint256(),
-		util.RandomUint256(),
-		util.RandomUint256(),
-	}
+func TestPutGetSpentCoinState(t *testing.T) {
+	store := storage.NewMemoryStore()
+	hash := util.RandomUint256()
 
-	for i := 0; i < len(txx); i++ {
-		spentCoins[txx[i]] = &SpentCoinState{
-			txHash:   txx[i],
-			txHeight: 1,
-		}
+	spent, err := getSpentCoinState(store, hash, 1)
+	if err != nil {
+		t.Fatal(err)
 	}
-	assert.Nil(t, spentCoins.commit(batch))
-	assert.Nil(t, store.PutBatch(batch))
+	assert.Equal(t, NewSpentCoinState(hash, 1), spent)
+
+	spent.items[0] = 2
+	assert.Nil(t, putSpentCoinState(store, spent))
+	stored, err := getSpentCoinState(store, hash, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, spent, stored)
+}
+
+func TestPutClaimedSpentCoinState(t *testing.T) {
+	store := storage.NewMemoryStore()
+	hash := util.RandomUint256()
+	key := storage.AppendPrefix(storage.STSpentCoin, hash.BytesReverse())
+
+	spent := NewSpentCoinState(hash, 1)
+	spent.items[0] = 2
+	assert.Nil(t, putSpentCoinState(store, spent))
+	_, err := store.Get(key)
+	assert.Nil(t, err)
+
+	delete(spent.items, 0)
+	assert.Nil(t, putSpentCoinState(store, spent))
+	_, err = store.Get(key)
+	assert.Equal(t, storage.ErrKeyNotFound, err)
 }
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"go.etcd.io/bbolt"
+)
+
+// bucket is the name of the BoltDB bucket all the data is stored in.
+var bucket = []byte("DB")
+
+// BoltDBStore is a Store keeping the blockchain data in a single BoltDB
+// file, every change being made in an ACID transaction.
+type BoltDBStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltDBStore returns a new BoltDBStore using the configured database
+// file, which is created together with its directory if needed.
+func NewBoltDBStore(cfg BoltDBOptions) (*BoltDBStore, error) {
+	if err := os.MkdirAll(filepath.Dir(cfg.FilePath), os.ModePerm); err != nil {
+		return nil, err
+	}
+	db, err := bbolt.Open(cfg.FilePath, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltDBStore{db: db}, nil
+}
+
+// Put implements the Store interface.
+func (s *BoltDBStore) Put(key, value []byte) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucket).Put(key, value)
+	})
+}
+
+// Get implements the Store interface.
+func (s *BoltDBStore) Get(key []byte) (value []byte, err error) {
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(bucket).Get(key)
+		if v == nil {
+			return ErrKeyNotFound
+		}
+		// The value is only valid during the transaction.
+		value = append([]byte{}, v...)
+		return nil
+	})
+	return value, err
+}
+
+// Delete implements the Store interface.
+func (s *BoltDBStore) Delete(key []byte) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucket).Delete(key)
+	})
+}
+
+// Batch implements the Store interface and returns a MemoryBatch.
+func (s *BoltDBStore) Batch() Batch {
+	return newMemoryBatch()
+}
+
+// PutBatch implements the Store interface, the batch is written in a single
+// transaction.
+func (s *BoltDBStore) PutBatch(batch Batch) error {
+	b := batch.(*MemoryBatch)
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bkt := tx.Bucket(bucket)
+		for k, v := range b.m {
+			if err := bkt.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+		for k := range b.del {
+			if err := bkt.Delete([]byte(k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Seek implements the Store interface.
+func (s *BoltDBStore) Seek(key []byte, f func(k, v []byte)) {
+	s.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		for k, v := c.Seek(key); k != nil && bytes.HasPrefix(k, key); k, v = c.Next() {
+			f(k, v)
+		}
+		return nil
+	})
+}
+
+// Iterate implements the Store interface.
+func (s *BoltDBStore) Iterate(r Range, f func(k, v []byte) bool) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		if r.Reverse {
+			k, v := c.Last()
+			if r.End != nil {
+				if k, v = c.Seek(r.End); k != nil {
+					k, v = c.Prev()
+				} else {
+					k, v = c.Last()
+				}
+			}
+			for ; k != nil && (r.Start == nil || bytes.Compare(k, r.Start) >= 0); k, v = c.Prev() {
+				if !f(k, v) {
+					break
+				}
+			}
+			return nil
+		}
+
+		k, v := c.First()
+		if r.Start != nil {
+			k, v = c.Seek(r.Start)
+		}
+		for ; k != nil && (r.End == nil || bytes.Compare(k, r.End) < 0); k, v = c.Next() {
+			if !f(k, v) {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// Close implements the Store interface.
+func (s *BoltDBStore) Close() error {
+	return s.db.Close()
+}
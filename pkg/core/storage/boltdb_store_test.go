@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBoltDBStoreSuite(t *testing.T) {
+	testStoreSuite(t, func(t *testing.T) (Store, func()) {
+		dir, err := ioutil.TempDir("", "boltdb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := NewBoltDBStore(BoltDBOptions{FilePath: filepath.Join(dir, "chain.db")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s, func() {
+			if err := s.Close(); err != nil {
+				t.Error(err)
+			}
+			os.RemoveAll(dir)
+		}
+	})
+}
@@ -2,7 +2,6 @@ package storage
 
 import (
 	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
@@ -14,14 +13,14 @@ type LevelDBStore struct {
 }
 
 // NewLevelDBStore return a new LevelDBStore object that will
-// initialize the database found at the given path.
-func NewLevelDBStore(path string, opts *opt.Options) (*LevelDBStore, error) {
-	db, err := leveldb.OpenFile(path, opts)
+// initialize the database found at the configured path.
+func NewLevelDBStore(cfg LevelDBOptions) (*LevelDBStore, error) {
+	db, err := leveldb.OpenFile(cfg.DataDirectoryPath, nil)
 	if err != nil {
 		return nil, err
 	}
 	return &LevelDBStore{
-		path: path,
+		path: cfg.DataDirectoryPath,
 		db:   db,
 	}, nil
 }
@@ -33,7 +32,16 @@ func (s *LevelDBStore) Put(key, value []byte) error {
 
 // Get implements the Store interface.
 func (s *LevelDBStore) Get(key []byte) ([]byte, error) {
-	return s.db.Get(key, nil)
+	value, err := s.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		err = ErrKeyNotFound
+	}
+	return value, err
+}
+
+// Delete implements the Store interface.
+func (s *LevelDBStore) Delete(key []byte) error {
+	return s.db.Delete(key, nil)
 }
 
 // PutBatch implements the Store interface.
@@ -51,6 +59,27 @@ func (s *LevelDBStore) Seek(key []byte, f func(k, v []byte)) {
 	iter.Release()
 }
 
+// Iterate implements the Store interface.
+func (s *LevelDBStore) Iterate(r Range, f func(k, v []byte) bool) error {
+	iter := s.db.NewIterator(&util.Range{Start: r.Start, Limit: r.End}, nil)
+	defer iter.Release()
+	move, ok := iter.Next, iter.First()
+	if r.Reverse {
+		move, ok = iter.Prev, iter.Last()
+	}
+	for ; ok; ok = move() {
+		if !f(iter.Key(), iter.Value()) {
+			break
+		}
+	}
+	return iter.Error()
+}
+
+// Close implements the Store interface.
+func (s *LevelDBStore) Close() error {
+	return s.db.Close()
+}
+
 // Batch implements the Batch interface and returns a leveldb
 // compatible Batch.
 func (s *LevelDBStore) Batch() Batch {
@@ -1 +1,26 @@
 package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLevelDBStoreSuite(t *testing.T) {
+	testStoreSuite(t, func(t *testing.T) (Store, func()) {
+		dir, err := ioutil.TempDir("", "leveldb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := NewLevelDBStore(LevelDBOptions{DataDirectoryPath: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s, func() {
+			if err := s.Close(); err != nil {
+				t.Error(err)
+			}
+			os.RemoveAll(dir)
+		}
+	})
+}
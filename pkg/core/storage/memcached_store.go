@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// MemCachedStore is a Store keeping the changes made to it in memory, on top
+// of the content of its parent Store, until they're persisted to it. A
+// MemCachedStore wrapping another one is a snapshot of it, its changes can
+// be committed to its parent with Persist or discarded by dropping it.
+type MemCachedStore struct {
+	ps Store
+
+	lock sync.RWMutex
+	mem  map[string][]byte
+	del  map[string]bool
+}
+
+// KeyChange is a change of the value of a key cached by a MemCachedStore.
+type KeyChange struct {
+	Key     []byte
+	Value   []byte
+	Deleted bool
+}
+
+// NewMemCachedStore returns a new MemCachedStore on top of the given Store.
+func NewMemCachedStore(ps Store) *MemCachedStore {
+	return &MemCachedStore{
+		ps:  ps,
+		mem: make(map[string][]byte),
+		del: make(map[string]bool),
+	}
+}
+
+// Get implements the Store interface.
+func (s *MemCachedStore) Get(key []byte) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if value, ok := s.mem[string(key)]; ok {
+		return value, nil
+	}
+	if s.del[string(key)] {
+		return nil, ErrKeyNotFound
+	}
+	return s.ps.Get(key)
+}
+
+// Put implements the Store interface. Key and value are copied, so the
+// caller is free to reuse them after the call.
+func (s *MemCachedStore) Put(key, value []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.put(key, value)
+	return nil
+}
+
+func (s *MemCachedStore) put(key, value []byte) {
+	vcopy := make([]byte, len(value))
+	copy(vcopy, value)
+	s.mem[string(key)] = vcopy
+	delete(s.del, string(key))
+}
+
+// Delete implements the Store interface.
+func (s *MemCachedStore) Delete(key []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.delete(key)
+	return nil
+}
+
+func (s *MemCachedStore) delete(key []byte) {
+	delete(s.mem, string(key))
+	s.del[string(key)] = true
+}
+
+// Batch implements the Store interface and returns a MemoryBatch.
+func (s *MemCachedStore) Batch() Batch {
+	return newMemoryBatch()
+}
+
+// PutBatch implements the Store interface, the changes of the batch are
+// cached like the other ones.
+func (s *MemCachedStore) PutBatch(batch Batch) error {
+	b := batch.(*MemoryBatch)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for k, v := range b.m {
+		s.put([]byte(k), v)
+	}
+	for k := range b.del {
+		s.delete([]byte(k))
+	}
+	return nil
+}
+
+// Seek implements the Store interface.
+func (s *MemCachedStore) Seek(key []byte, f func(k, v []byte)) {
+	s.Iterate(PrefixRange(key), func(k, v []byte) bool {
+		f(k, v)
+		return true
+	})
+}
+
+// Iterate implements the Store interface. The keys of the parent Store in
+// the range are all read before the first one is visited.
+func (s *MemCachedStore) Iterate(r Range, f func(k, v []byte) bool) error {
+	s.lock.RLock()
+	content := make(map[string][]byte)
+	err := s.ps.Iterate(r, func(k, v []byte) bool {
+		if !s.del[string(k)] {
+			vcopy := make([]byte, len(v))
+			copy(vcopy, v)
+			content[string(k)] = vcopy
+		}
+		return true
+	})
+	for k, v := range s.mem {
+		if r.contains([]byte(k)) {
+			content[k] = v
+		}
+	}
+	s.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j] != r.Reverse
+	})
+	for _, k := range keys {
+		if !f([]byte(k), content[k]) {
+			break
+		}
+	}
+	return nil
+}
+
+// Changes returns the changes cached by the store, ordered by key.
+func (s *MemCachedStore) Changes() []KeyChange {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	changes := make([]KeyChange, 0, len(s.mem)+len(s.del))
+	for k, v := range s.mem {
+		changes = append(changes, KeyChange{Key: []byte(k), Value: v})
+	}
+	for k := range s.del {
+		changes = append(changes, KeyChange{Key: []byte(k), Deleted: true})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return bytes.Compare(changes[i].Key, changes[j].Key) < 0
+	})
+	return changes
+}
+
+// Persist writes the cached changes to the parent Store in a single batch
+// and returns their number. The changes are kept if the batch fails.
+func (s *MemCachedStore) Persist() (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	n := len(s.mem) + len(s.del)
+	if n == 0 {
+		return 0, nil
+	}
+	batch := s.ps.Batch()
+	for k, v := range s.mem {
+		batch.Put([]byte(k), v)
+	}
+	for k := range s.del {
+		batch.Delete([]byte(k))
+	}
+	if err := s.ps.PutBatch(batch); err != nil {
+		return 0, err
+	}
+	s.mem = make(map[string][]byte)
+	s.del = make(map[string]bool)
+	return n, nil
+}
+
+// Close implements the Store interface, it closes the parent Store and the
+// changes that were not persisted are lost.
+func (s *MemCachedStore) Close() error {
+	return s.ps.Close()
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemCachedStoreSuite(t *testing.T) {
+	testStoreSuite(t, func(t *testing.T) (Store, func()) {
+		return NewMemCachedStore(NewMemoryStore()), func() {}
+	})
+}
+
+func TestMemCachedStoreReadThrough(t *testing.T) {
+	ps := NewMemoryStore()
+	putKeys(t, ps, []byte{1}, []byte{2}, []byte{3})
+	s := NewMemCachedStore(ps)
+
+	value, err := s.Get([]byte{2})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{2}, value)
+
+	assert.Nil(t, s.Delete([]byte{2}))
+	putKeys(t, s, []byte{4}, []byte{1, 1})
+	_, err = s.Get([]byte{2})
+	assert.Equal(t, ErrKeyNotFound, err)
+	_, err = ps.Get([]byte{4})
+	assert.Equal(t, ErrKeyNotFound, err)
+	assert.Equal(t, [][]byte{{1}, {1, 1}, {3}, {4}}, iterate(t, s, Range{}))
+	assert.Equal(t, [][]byte{{3}, {1, 1}}, iterate(t, s, Range{Start: []byte{1, 0}, End: []byte{4}, Reverse: true}))
+	assert.Equal(t, []KeyChange{
+		{Key: []byte{1, 1}, Value: []byte{1}},
+		{Key: []byte{2}, Deleted: true},
+		{Key: []byte{4}, Value: []byte{4}},
+	}, s.Changes())
+
+	n, err := s.Persist()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, 0, len(s.Changes()))
+	assert.Equal(t, [][]byte{{1}, {1, 1}, {3}, {4}}, iterate(t, ps, Range{}))
+}
+
+func TestMemCachedStoreSnapshots(t *testing.T) {
+	ps := NewMemoryStore()
+	cache := NewMemCachedStore(ps)
+	putKeys(t, cache, []byte{1})
+
+	// Changes of a dropped snapshot are lost.
+	snapshot := NewMemCachedStore(cache)
+	putKeys(t, snapshot, []byte{2})
+	assert.Nil(t, snapshot.Delete([]byte{1}))
+	assert.Equal(t, [][]byte{{1}}, iterate(t, cache, Range{}))
+
+	// Committed ones only go one level up.
+	snapshot = NewMemCachedStore(cache)
+	putKeys(t, snapshot, []byte{3})
+	_, err := snapshot.Persist()
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{{1}, {3}}, iterate(t, cache, Range{}))
+	assert.Equal(t, 0, len(iterate(t, ps, Range{})))
+
+	_, err = cache.Persist()
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{{1}, {3}}, iterate(t, ps, Range{}))
+}
@@ -1,29 +1,45 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
 )
 
 // MemoryStore is an in-memory implementation of a Store, mainly
 // used for testing. Do not use MemoryStore in production.
 type MemoryStore struct {
-	mem map[string][]byte
+	lock sync.RWMutex
+	mem  map[string][]byte
 }
 
-// MemoryBatch a in-memory batch compatible with MemoryStore.
+// MemoryBatch a in-memory batch compatible with MemoryStore. The last change
+// made to a key is the one written.
 type MemoryBatch struct {
-	m map[*[]byte][]byte
+	m   map[string][]byte
+	del map[string]bool
 }
 
-// Put implements the Batch interface.
+// Put implements the Batch interface. Key and value are copied, so the
+// caller is free to reuse them after the call.
 func (b *MemoryBatch) Put(k, v []byte) {
-	key := &k
-	b.m[key] = v
+	vcopy := make([]byte, len(v))
+	copy(vcopy, v)
+	b.m[string(k)] = vcopy
+	delete(b.del, string(k))
+}
+
+// Delete implements the Batch interface.
+func (b *MemoryBatch) Delete(k []byte) {
+	delete(b.m, string(k))
+	b.del[string(k)] = true
 }
 
 // Len implements the Batch interface.
 func (b *MemoryBatch) Len() int {
-	return len(b.m)
+	return len(b.m) + len(b.del)
 }
 
 // NewMemoryStore creates a new MemoryStore object.
@@ -35,6 +51,8 @@ func NewMemoryStore() *MemoryStore {
 
 // Get implements the Store interface.
 func (s *MemoryStore) Get(key []byte) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	if val, ok := s.mem[makeKey(key)]; ok {
 		return val, nil
 	}
@@ -43,6 +61,8 @@ func (s *MemoryStore) Get(key []byte) ([]byte, error) {
 
 // Put implementes the Store interface.
 func (s *MemoryStore) Put(key, value []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	s.mem[makeKey(key)] = value
 	return nil
 }
@@ -50,20 +70,92 @@ func (s *MemoryStore) Put(key, value []byte) error {
 // PutBatch implementes the Store interface.
 func (s *MemoryStore) PutBatch(batch Batch) error {
 	b := batch.(*MemoryBatch)
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	for k, v := range b.m {
-		s.Put(*k, v)
+		s.mem[makeKey([]byte(k))] = v
+	}
+	for k := range b.del {
+		delete(s.mem, makeKey([]byte(k)))
 	}
 	return nil
 }
 
-// Seek implementes the Store interface.
+// Delete implements the Store interface.
+func (s *MemoryStore) Delete(key []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.mem, makeKey(key))
+	return nil
+}
+
+// Seek implementes the Store interface. The keys are visited in order, like
+// with the other stores.
 func (s *MemoryStore) Seek(key []byte, f func(k, v []byte)) {
+	prefix := makeKey(key)
+	var keys []string
+	values := make(map[string][]byte)
+	s.lock.RLock()
+	for k, v := range s.mem {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+			values[k] = v
+		}
+	}
+	s.lock.RUnlock()
+	sort.Strings(keys)
+	for _, k := range keys {
+		b, err := hex.DecodeString(k)
+		if err != nil {
+			continue
+		}
+		f(b, values[k])
+	}
+}
+
+// Iterate implements the Store interface. The keys in the range are all
+// read before the first one is visited, so f can change the store.
+func (s *MemoryStore) Iterate(r Range, f func(k, v []byte) bool) error {
+	var keys [][]byte
+	values := make(map[string][]byte)
+	s.lock.RLock()
+	for k, v := range s.mem {
+		b, err := hex.DecodeString(k)
+		if err != nil {
+			continue
+		}
+		if r.contains(b) {
+			keys = append(keys, b)
+			values[k] = v
+		}
+	}
+	s.lock.RUnlock()
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0 != r.Reverse
+	})
+	for _, k := range keys {
+		if !f(k, values[makeKey(k)]) {
+			break
+		}
+	}
+	return nil
+}
+
+// Close implements the Store interface.
+func (s *MemoryStore) Close() error {
+	return nil
 }
 
 // Batch implements the Batch interface and returns a compatible Batch.
 func (s *MemoryStore) Batch() Batch {
+	return newMemoryBatch()
+}
+
+// newMemoryBatch returns a new empty MemoryBatch.
+func newMemoryBatch() *MemoryBatch {
 	return &MemoryBatch{
-		m: make(map[*[]byte][]byte),
+		m:   make(map[string][]byte),
+		del: make(map[string]bool),
 	}
 }
 
@@ -50,3 +50,67 @@ func TestPutBatch(t *testing.T) {
 	}
 	assert.Equal(t, value, newVal)
 }
+
+func TestSeek(t *testing.T) {
+	s := NewMemoryStore()
+	s.Put([]byte{1, 2}, []byte("b"))
+	s.Put([]byte{1, 1}, []byte("a"))
+	s.Put([]byte{2, 1}, []byte("c"))
+
+	var keys, values [][]byte
+	s.Seek([]byte{1}, func(k, v []byte) {
+		keys = append(keys, k)
+		values = append(values, v)
+	})
+	assert.Equal(t, [][]byte{{1, 1}, {1, 2}}, keys)
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b")}, values)
+}
+
+func TestDeleteBatch(t *testing.T) {
+	s := NewMemoryStore()
+	s.Put([]byte("deleted"), []byte("a"))
+	s.Put([]byte("kept"), []byte("b"))
+
+	batch := s.Batch()
+	batch.Put([]byte("deleted"), []byte("c"))
+	batch.Delete([]byte("deleted"))
+	batch.Delete([]byte("put"))
+	batch.Put([]byte("put"), []byte("d"))
+	assert.Equal(t, 2, batch.Len())
+	assert.Nil(t, s.PutBatch(batch))
+
+	_, err := s.Get([]byte("deleted"))
+	assert.Equal(t, ErrKeyNotFound, err)
+	for k, v := range map[string]string{"kept": "b", "put": "d"} {
+		val, err := s.Get([]byte(k))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte(v), val)
+	}
+}
+
+func TestMemoryStoreSuite(t *testing.T) {
+	testStoreSuite(t, func(t *testing.T) (Store, func()) {
+		return NewMemoryStore(), func() {}
+	})
+}
+
+func TestMemoryStoreConcurrency(t *testing.T) {
+	s := NewMemoryStore()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			batch := s.Batch()
+			batch.Put([]byte{byte(i)}, []byte{byte(i)})
+			assert.Nil(t, s.PutBatch(batch))
+			assert.Nil(t, s.Delete([]byte{byte(i)}))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, s.Put([]byte{1, byte(i)}, []byte{1}))
+		s.Get([]byte{byte(i)})
+		s.Seek([]byte{1}, func(k, v []byte) {})
+	}
+	<-done
+	assert.Equal(t, 100, len(iterate(t, s, PrefixRange([]byte{1}))))
+}
@@ -1,11 +1,16 @@
 package storage
 
 import (
-	"fmt"
+	"bytes"
+	"sort"
+	"time"
 
 	"github.com/go-redis/redis"
 )
 
+// Number of keys asked by every SCAN and MGET.
+const redisScanCount = 1000
+
 // RedisStore holds the client and maybe later some more metadata.
 type RedisStore struct {
 	client *redis.Client
@@ -14,33 +19,47 @@ type RedisStore struct {
 // RedisBatch simple batch implementation to satisfy the Store interface.
 type RedisBatch struct {
 	mem map[string]string
+	del map[string]bool
 }
 
 // Len implements the Batch interface.
 func (b *RedisBatch) Len() int {
-	return len(b.mem)
+	return len(b.mem) + len(b.del)
 }
 
 // Put implements the Batch interface.
 func (b *RedisBatch) Put(k, v []byte) {
 	b.mem[string(k)] = string(v)
+	delete(b.del, string(k))
+}
+
+// Delete implements the Batch interface.
+func (b *RedisBatch) Delete(k []byte) {
+	delete(b.mem, string(k))
+	b.del[string(k)] = true
 }
 
 // NewRedisBatch returns a new ready to use RedisBatch.
 func NewRedisBatch() *RedisBatch {
 	return &RedisBatch{
 		mem: make(map[string]string),
+		del: make(map[string]bool),
 	}
 }
 
 // NewRedisStore returns an new initialized - ready to use RedisStore object
-func NewRedisStore() (*RedisStore, error) {
+// connected to the configured server.
+func NewRedisStore(cfg RedisDBOptions) (*RedisStore, error) {
 	c := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
+		Addr:         cfg.Addr,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		DialTimeout:  cfg.DialTimeout * time.Second,
+		ReadTimeout:  cfg.ReadTimeout * time.Second,
+		WriteTimeout: cfg.WriteTimeout * time.Second,
 	})
 	if _, err := c.Ping().Result(); err != nil {
+		c.Close()
 		return nil, err
 	}
 	return &RedisStore{
@@ -56,6 +75,9 @@ func (s *RedisStore) Batch() Batch {
 // Get implements the Store interface.
 func (s *RedisStore) Get(k []byte) ([]byte, error) {
 	val, err := s.client.Get(string(k)).Result()
+	if err == redis.Nil {
+		return nil, ErrKeyNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -64,8 +86,7 @@ func (s *RedisStore) Get(k []byte) ([]byte, error) {
 
 // Put implements the Store interface.
 func (s *RedisStore) Put(k, v []byte) error {
-	s.client.Set(string(k), string(v), 0)
-	return nil
+	return s.client.Set(string(k), string(v), 0).Err()
 }
 
 // PutBatch implements the Store interface.
@@ -74,16 +95,95 @@ func (s *RedisStore) PutBatch(b Batch) error {
 	for k, v := range b.(*RedisBatch).mem {
 		pipe.Set(k, v, 0)
 	}
+	for k := range b.(*RedisBatch).del {
+		pipe.Del(k)
+	}
 	_, err := pipe.Exec()
 	return err
 }
 
 // Seek implements the Store interface.
 func (s *RedisStore) Seek(k []byte, f func(k, v []byte)) {
-	iter := s.client.Scan(0, fmt.Sprintf("%s*", k), 0).Iterator()
+	s.iterate(k, PrefixRange(k), func(k, v []byte) bool {
+		f(k, v)
+		return true
+	})
+}
+
+// Delete implements the Store interface.
+func (s *RedisStore) Delete(k []byte) error {
+	return s.client.Del(string(k)).Err()
+}
+
+// Iterate implements the Store interface. Redis doesn't keep its keys in
+// order, the ones sharing the prefix common to the bounds of the range are
+// all scanned to be sorted.
+func (s *RedisStore) Iterate(r Range, f func(k, v []byte) bool) error {
+	var prefix []byte
+	if r.Start != nil && r.End != nil {
+		for i := 0; i < len(r.Start) && i < len(r.End) && r.Start[i] == r.End[i]; i++ {
+			prefix = r.Start[:i+1]
+		}
+	}
+	return s.iterate(prefix, r, f)
+}
+
+// iterate visits the keys with the given prefix in the given range, getting
+// their values redisScanCount at a time.
+func (s *RedisStore) iterate(prefix []byte, r Range, f func(k, v []byte) bool) error {
+	var keys []string
+	iter := s.client.Scan(0, scanPattern(prefix), redisScanCount).Iterator()
 	for iter.Next() {
-		key := iter.Val()
-		val, _ := s.client.Get(key).Result()
-		f([]byte(key), []byte(val))
+		if r.contains([]byte(iter.Val())) {
+			keys = append(keys, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
 	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j] != r.Reverse
+	})
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > redisScanCount {
+			n = redisScanCount
+		}
+		values, err := s.client.MGet(keys[:n]...).Result()
+		if err != nil {
+			return err
+		}
+		for i, v := range values {
+			// Keys deleted since the scan are skipped.
+			val, ok := v.(string)
+			if !ok {
+				continue
+			}
+			if !f([]byte(keys[i]), []byte(val)) {
+				return nil
+			}
+		}
+		keys = keys[n:]
+	}
+	return nil
+}
+
+// scanPattern returns the pattern matching the keys starting with the given
+// prefix, whose glob special characters are escaped.
+func scanPattern(prefix []byte) string {
+	var buf bytes.Buffer
+	for _, b := range prefix {
+		switch b {
+		case '*', '?', '[', ']', '\\':
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(b)
+	}
+	buf.WriteByte('*')
+	return buf.String()
+}
+
+// Close implements the Store interface.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
 }
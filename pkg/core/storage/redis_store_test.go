@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRedisStore returns a RedisStore connected to a new in-process Redis
+// server.
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	srv, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewRedisStore(RedisDBOptions{Addr: srv.Addr()})
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	return s, srv
+}
+
+func TestRedisStoreSuite(t *testing.T) {
+	testStoreSuite(t, func(t *testing.T) (Store, func()) {
+		s, srv := newTestRedisStore(t)
+		return s, func() {
+			if err := s.Close(); err != nil {
+				t.Error(err)
+			}
+			srv.Close()
+		}
+	})
+}
+
+func TestRedisStoreOptions(t *testing.T) {
+	srv, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	srv.RequireAuth("secret")
+
+	_, err = NewRedisStore(RedisDBOptions{Addr: srv.Addr()})
+	assert.NotNil(t, err)
+
+	s, err := NewRedisStore(RedisDBOptions{Addr: srv.Addr(), Password: "secret", DB: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	assert.Nil(t, s.Put([]byte("key"), []byte("value")))
+	value, err := srv.DB(2).Get("key")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func TestRedisStorePutError(t *testing.T) {
+	s, srv := newTestRedisStore(t)
+	defer s.Close()
+	srv.Close()
+	assert.NotNil(t, s.Put([]byte("key"), []byte("value")))
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 )
@@ -9,15 +10,22 @@ import (
 const (
 	DataBlock         KeyPrefix = 0x01
 	DataTransaction   KeyPrefix = 0x02
+	DataUndo          KeyPrefix = 0x03
 	STAccount         KeyPrefix = 0x40
 	STCoin            KeyPrefix = 0x44
 	STSpentCoin       KeyPrefix = 0x45
 	STValidator       KeyPrefix = 0x48
 	STAsset           KeyPrefix = 0x4c
+	STNotification    KeyPrefix = 0x4d
 	STContract        KeyPrefix = 0x50
 	STStorage         KeyPrefix = 0x70
 	IXHeaderHashList  KeyPrefix = 0x80
 	IXValidatorsCount KeyPrefix = 0x90
+	IXUnspent         KeyPrefix = 0x91
+	IXAddressHistory  KeyPrefix = 0x92
+	IXNEP5Balance     KeyPrefix = 0x93
+	IXNEP5Transfer    KeyPrefix = 0x94
+	IXClaimable       KeyPrefix = 0x95
 	SYSCurrentBlock   KeyPrefix = 0xc0
 	SYSCurrentHeader  KeyPrefix = 0xc1
 	SYSVersion        KeyPrefix = 0xf0
@@ -35,7 +43,20 @@ type (
 		Get([]byte) ([]byte, error)
 		Put(k, v []byte) error
 		PutBatch(Batch) error
+		Delete(k []byte) error
 		Seek(k []byte, f func(k, v []byte))
+		Iterate(r Range, f func(k, v []byte) bool) error
+		Close() error
+	}
+
+	// Range is the range of keys visited by Store.Iterate, from Start
+	// included to End excluded, a nil bound leaving the range open on its
+	// side. Keys are visited in increasing order, or in decreasing order if
+	// Reverse is set.
+	Range struct {
+		Start   []byte
+		End     []byte
+		Reverse bool
 	}
 
 	// Batch represents an abstraction on top of batch operations.
@@ -43,6 +64,7 @@ type (
 	// to its appropriate type.
 	Batch interface {
 		Put(k, v []byte)
+		Delete(k []byte)
 		Len() int
 	}
 
@@ -51,6 +73,26 @@ type (
 	KeyPrefix uint8
 )
 
+// PrefixRange returns the range of the keys starting with the given prefix.
+func PrefixRange(prefix []byte) Range {
+	r := Range{Start: prefix}
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			r.End = make([]byte, i+1)
+			copy(r.End, prefix)
+			r.End[i]++
+			break
+		}
+	}
+	return r
+}
+
+// contains returns whether the given key is in the range.
+func (r Range) contains(k []byte) bool {
+	return (r.Start == nil || bytes.Compare(k, r.Start) >= 0) &&
+		(r.End == nil || bytes.Compare(k, r.End) < 0)
+}
+
 // Bytes returns the bytes representation of KeyPrefix.
 func (k KeyPrefix) Bytes() []byte {
 	return []byte{byte(k)}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	// DBConfiguration describes the database the chain is stored in, its
+	// Type being one of leveldb, boltdb, redis or memory.
+	DBConfiguration struct {
+		Type           string         `yaml:"Type"`
+		LevelDBOptions LevelDBOptions `yaml:"LevelDBOptions"`
+		BoltDBOptions  BoltDBOptions  `yaml:"BoltDBOptions"`
+		RedisDBOptions RedisDBOptions `yaml:"RedisDBOptions"`
+	}
+
+	// LevelDBOptions configure a LevelDBStore.
+	LevelDBOptions struct {
+		DataDirectoryPath string `yaml:"DataDirectoryPath"`
+	}
+
+	// BoltDBOptions configure a BoltDBStore.
+	BoltDBOptions struct {
+		FilePath string `yaml:"FilePath"`
+	}
+
+	// RedisDBOptions configure a RedisStore, the timeouts are in seconds and
+	// the ones left to zero take the default values of the client.
+	RedisDBOptions struct {
+		Addr         string        `yaml:"Addr"`
+		Password     string        `yaml:"Password"`
+		DB           int           `yaml:"DB"`
+		DialTimeout  time.Duration `yaml:"DialTimeout"`
+		ReadTimeout  time.Duration `yaml:"ReadTimeout"`
+		WriteTimeout time.Duration `yaml:"WriteTimeout"`
+	}
+)
+
+// NewStore returns the Store described by the given configuration.
+func NewStore(cfg DBConfiguration) (Store, error) {
+	switch cfg.Type {
+	case "leveldb":
+		return NewLevelDBStore(cfg.LevelDBOptions)
+	case "boltdb":
+		return NewBoltDBStore(cfg.BoltDBOptions)
+	case "redis":
+		return NewRedisStore(cfg.RedisDBOptions)
+	case "memory":
+		return NewMemoryStore(), nil
+	case "":
+		return nil, errors.New("database type is not set, DBConfiguration.Type has to be one of leveldb, boltdb, redis or memory")
+	default:
+		return nil, fmt.Errorf("unknown database type %q", cfg.Type)
+	}
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, cfg := range []DBConfiguration{
+		{Type: "memory"},
+		{Type: "leveldb", LevelDBOptions: LevelDBOptions{DataDirectoryPath: filepath.Join(dir, "leveldb")}},
+		{Type: "boltdb", BoltDBOptions: BoltDBOptions{FilePath: filepath.Join(dir, "boltdb", "chain.db")}},
+	} {
+		s, err := NewStore(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, s.Put([]byte("key"), []byte("value")))
+		assert.Nil(t, s.Close())
+	}
+
+	_, err = NewStore(DBConfiguration{Type: "unknown"})
+	assert.NotNil(t, err)
+	_, err = NewStore(DBConfiguration{})
+	assert.NotNil(t, err)
+}
@@ -14,6 +14,7 @@ var (
 		STCoin,
 		STValidator,
 		STAsset,
+		STNotification,
 		STContract,
 		STStorage,
 		IXHeaderHashList,
@@ -30,6 +31,7 @@ var (
 		0x44,
 		0x48,
 		0x4c,
+		0x4d,
 		0x50,
 		0x70,
 		0x80,
@@ -55,3 +57,9 @@ func TestAppendPrefixInt(t *testing.T) {
 		assert.Equal(t, KeyPrefix(expected[i]), KeyPrefix(prefix[0]))
 	}
 }
+
+func TestPrefixRange(t *testing.T) {
+	assert.Equal(t, Range{Start: []byte{1, 2}, End: []byte{1, 3}}, PrefixRange([]byte{1, 2}))
+	assert.Equal(t, Range{Start: []byte{1, 0xff}, End: []byte{2}}, PrefixRange([]byte{1, 0xff}))
+	assert.Equal(t, Range{Start: []byte{0xff}}, PrefixRange([]byte{0xff}))
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// storeConstructor returns a new empty Store together with a function
+// releasing it.
+type storeConstructor func(t *testing.T) (Store, func())
+
+// testStoreSuite checks that the stores returned by the given constructor
+// behave like every Store must.
+func testStoreSuite(t *testing.T, newStore storeConstructor) {
+	for name, test := range map[string]func(*testing.T, Store){
+		"GetPut":        testStoreGetPut,
+		"KeyNotFound":   testStoreKeyNotFound,
+		"Delete":        testStoreDelete,
+		"PutBatch":      testStorePutBatch,
+		"Seek":          testStoreSeek,
+		"SeekPattern":   testStoreSeekPattern,
+		"Iterate":       testStoreIterate,
+		"IterateStop":   testStoreIterateStop,
+		"IterateBounds": testStoreIterateBounds,
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, release := newStore(t)
+			defer release()
+			test(t, s)
+		})
+	}
+}
+
+func testStoreGetPut(t *testing.T, s Store) {
+	assert.Nil(t, s.Put([]byte("key"), []byte("value")))
+	value, err := s.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	assert.Nil(t, s.Put([]byte("key"), []byte("other")))
+	value, err = s.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("other"), value)
+}
+
+func testStoreKeyNotFound(t *testing.T, s Store) {
+	_, err := s.Get([]byte("missing"))
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func testStoreDelete(t *testing.T, s Store) {
+	assert.Nil(t, s.Put([]byte("key"), []byte("value")))
+	assert.Nil(t, s.Delete([]byte("key")))
+	_, err := s.Get([]byte("key"))
+	assert.Equal(t, ErrKeyNotFound, err)
+	assert.Nil(t, s.Delete([]byte("missing")))
+}
+
+func testStorePutBatch(t *testing.T, s Store) {
+	assert.Nil(t, s.Put([]byte("deleted"), []byte("a")))
+	assert.Nil(t, s.Put([]byte("kept"), []byte("b")))
+
+	batch := s.Batch()
+	batch.Put([]byte("put"), []byte("x"))
+	batch.Delete([]byte("deleted"))
+	assert.Equal(t, 2, batch.Len())
+	// The last write of a key wins.
+	batch.Put([]byte("put"), []byte("c"))
+	assert.Nil(t, s.PutBatch(batch))
+
+	_, err := s.Get([]byte("deleted"))
+	assert.Equal(t, ErrKeyNotFound, err)
+	for k, v := range map[string]string{"kept": "b", "put": "c"} {
+		value, err := s.Get([]byte(k))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte(v), value)
+	}
+}
+
+// putKeys puts the given keys with their first byte as value.
+func putKeys(t *testing.T, s Store, keys ...[]byte) {
+	for _, k := range keys {
+		if err := s.Put(k, k[:1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testStoreSeek(t *testing.T, s Store) {
+	putKeys(t, s, []byte{1, 2}, []byte{2, 1}, []byte{1, 1}, []byte{1})
+
+	var keys [][]byte
+	s.Seek([]byte{1}, func(k, v []byte) {
+		assert.Equal(t, k[:1], v)
+		keys = append(keys, append([]byte{}, k...))
+	})
+	assert.Equal(t, [][]byte{{1}, {1, 1}, {1, 2}}, keys)
+}
+
+func testStoreSeekPattern(t *testing.T, s Store) {
+	putKeys(t, s, []byte("a*"), []byte("ab"), []byte("a*b"), []byte("[a]"), []byte("[a]b"), []byte("a"), []byte("\\a"))
+
+	for prefix, expected := range map[string][]string{
+		"a*":  {"a*", "a*b"},
+		"[a]": {"[a]", "[a]b"},
+		"\\":  {"\\a"},
+	} {
+		var keys []string
+		s.Seek([]byte(prefix), func(k, v []byte) {
+			keys = append(keys, string(k))
+		})
+		assert.Equal(t, expected, keys)
+	}
+}
+
+// iterate returns the keys visited by Iterate on the given range.
+func iterate(t *testing.T, s Store, r Range) [][]byte {
+	var keys [][]byte
+	assert.Nil(t, s.Iterate(r, func(k, v []byte) bool {
+		assert.Equal(t, k[:1], v)
+		keys = append(keys, append([]byte{}, k...))
+		return true
+	}))
+	return keys
+}
+
+func testStoreIterate(t *testing.T, s Store) {
+	assert.Equal(t, 0, len(iterate(t, s, Range{})))
+	putKeys(t, s, []byte{2}, []byte{1, 0xff}, []byte{1}, []byte{0xff, 0xff})
+
+	assert.Equal(t, [][]byte{{1}, {1, 0xff}, {2}, {0xff, 0xff}}, iterate(t, s, Range{}))
+	assert.Equal(t, [][]byte{{0xff, 0xff}, {2}, {1, 0xff}, {1}}, iterate(t, s, Range{Reverse: true}))
+}
+
+func testStoreIterateStop(t *testing.T, s Store) {
+	putKeys(t, s, []byte{1}, []byte{2}, []byte{3})
+
+	var keys [][]byte
+	assert.Nil(t, s.Iterate(Range{Reverse: true}, func(k, v []byte) bool {
+		keys = append(keys, append([]byte{}, k...))
+		return len(keys) < 2
+	}))
+	assert.Equal(t, [][]byte{{3}, {2}}, keys)
+}
+
+func testStoreIterateBounds(t *testing.T, s Store) {
+	putKeys(t, s, []byte{1}, []byte{1, 1}, []byte{2}, []byte{2, 0}, []byte{3})
+
+	assert.Equal(t, [][]byte{{1, 1}, {2}}, iterate(t, s, Range{Start: []byte{1, 0}, End: []byte{2, 0}}))
+	assert.Equal(t, [][]byte{{2}, {1, 1}}, iterate(t, s, Range{Start: []byte{1, 0}, End: []byte{2, 0}, Reverse: true}))
+	assert.Equal(t, [][]byte{{2, 0}, {3}}, iterate(t, s, Range{Start: []byte{2, 0}}))
+	assert.Equal(t, [][]byte{{1}, {1, 1}}, iterate(t, s, Range{End: []byte{2}}))
+	assert.Equal(t, [][]byte{{2, 0}, {2}}, iterate(t, s, Range{Start: []byte{2}, End: []byte{3}, Reverse: true}))
+	assert.Equal(t, [][]byte{{2}, {2, 0}}, iterate(t, s, PrefixRange([]byte{2})))
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// StorageItem is the value of a key stored by a contract.
+type StorageItem struct {
+	Value []byte
+}
+
+// makeStorageItemKey returns the key of the given contract's storage item.
+func makeStorageItemKey(scriptHash util.Uint160, key []byte) []byte {
+	return storage.AppendPrefix(storage.STStorage, append(scriptHash.BytesReverse(), key...))
+}
+
+// getStorageItem returns the given contract's item stored under the given
+// key in the given store, nil if there is none.
+func getStorageItem(s storage.Store, scriptHash util.Uint160, key []byte) *StorageItem {
+	b, err := s.Get(makeStorageItemKey(scriptHash, key))
+	if err != nil {
+		return nil
+	}
+	item := &StorageItem{}
+	if err := item.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil
+	}
+	return item
+}
+
+// putStorageItem stores the given contract's item under the given key in the
+// given store.
+func putStorageItem(s storage.Store, scriptHash util.Uint160, key []byte, item *StorageItem) error {
+	buf := new(bytes.Buffer)
+	if err := item.EncodeBinary(buf); err != nil {
+		return err
+	}
+	return s.Put(makeStorageItemKey(scriptHash, key), buf.Bytes())
+}
+
+// findStorageItems calls f on the given contract's items stored under the
+// keys starting with the given prefix in the given store, in the order of
+// their keys.
+func findStorageItems(s storage.Store, scriptHash util.Uint160, prefix []byte, f func(key []byte, item *StorageItem)) error {
+	var (
+		start = makeStorageItemKey(scriptHash, nil)
+		ferr  error
+	)
+	err := s.Iterate(storage.PrefixRange(makeStorageItemKey(scriptHash, prefix)), func(k, v []byte) bool {
+		item := &StorageItem{}
+		if ferr = item.DecodeBinary(bytes.NewReader(v)); ferr != nil {
+			return false
+		}
+		f(append([]byte(nil), k[len(start):]...), item)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return ferr
+}
+
+// DecodeBinary implements the Payload interface.
+func (si *StorageItem) DecodeBinary(r io.Reader) error {
+	var err error
+	si.Value, err = util.ReadVarBytes(r)
+	return err
+}
+
+// EncodeBinary implements the Payload interface.
+func (si *StorageItem) EncodeBinary(w io.Writer) error {
+	return util.WriteVarBytes(w, si.Value)
+}
@@ -1,5 +1,10 @@
 package transaction
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // AssetType represent a NEO asset type
 type AssetType uint8
 
@@ -14,3 +19,43 @@ const (
 	Invoice        AssetType = DutyFlag | 0x18
 	Token          AssetType = CreditFlag | 0x20
 )
+
+// String implements the stringer interface.
+func (a AssetType) String() string {
+	switch a {
+	case GoverningToken:
+		return "GoverningToken"
+	case UtilityToken:
+		return "UtilityToken"
+	case Currency:
+		return "Currency"
+	case Share:
+		return "Share"
+	case Invoice:
+		return "Invoice"
+	case Token:
+		return "Token"
+	default:
+		return "UnknownAssetType"
+	}
+}
+
+// MarshalJSON implements the json marshaller interface.
+func (a AssetType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON implements the json unmarshaller interface.
+func (a *AssetType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, typ := range []AssetType{GoverningToken, UtilityToken, Currency, Share, Invoice, Token} {
+		if typ.String() == s {
+			*a = typ
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown asset type %s", s)
+}
@@ -1,5 +1,7 @@
 package transaction
 
+import "fmt"
+
 // AttrUsage represents the purpose of the attribute.
 type AttrUsage uint8
 
@@ -47,3 +49,33 @@ const (
 	Remark14 AttrUsage = 0xfe
 	Remark15 AttrUsage = 0xff
 )
+
+// String implements the stringer interface.
+func (u AttrUsage) String() string {
+	switch {
+	case u == ContractHash:
+		return "ContractHash"
+	case u == ECDH02:
+		return "ECDH02"
+	case u == ECDH03:
+		return "ECDH03"
+	case u == Script:
+		return "Script"
+	case u == Vote:
+		return "Vote"
+	case u == CertURL:
+		return "CertUrl"
+	case u == DescriptionURL:
+		return "DescriptionUrl"
+	case u == Description:
+		return "Description"
+	case u >= Hash1 && u <= Hash15:
+		return fmt.Sprintf("Hash%d", u-Hash1+1)
+	case u == Remark:
+		return "Remark"
+	case u > Remark:
+		return fmt.Sprintf("Remark%d", u-Remark)
+	default:
+		return "UnknownUsage"
+	}
+}
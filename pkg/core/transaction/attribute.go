@@ -2,6 +2,8 @@ package transaction
 
 import (
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 
@@ -77,3 +79,31 @@ func (attr *Attribute) EncodeBinary(w io.Writer) error {
 	}
 	return fmt.Errorf("failed encoding TX attribute usage: 0x%2x", attr.Usage)
 }
+
+// MarshalJSON implements the json marshaller interface.
+func (attr *Attribute) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"usage": attr.Usage.String(),
+		"data":  hex.EncodeToString(attr.Data),
+	})
+}
+
+// UnmarshalJSON implements the json unmarshaller interface.
+func (attr *Attribute) UnmarshalJSON(data []byte) error {
+	var v map[string]string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(v["data"])
+	if err != nil {
+		return err
+	}
+	for i := 0; i <= 0xff; i++ {
+		if usage := AttrUsage(i); usage.String() == v["usage"] {
+			attr.Usage = usage
+			attr.Data = b
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown attribute usage %s", v["usage"])
+}
@@ -2,6 +2,7 @@ package transaction
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"io"
 
 	"github.com/CityOfZion/neo-go/pkg/util"
@@ -34,3 +35,25 @@ func (in *Input) EncodeBinary(w io.Writer) error {
 	}
 	return nil
 }
+
+// MarshalJSON implements the json marshaller interface.
+func (in *Input) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"txid": in.PrevHash,
+		"vout": in.PrevIndex,
+	})
+}
+
+// UnmarshalJSON implements the json unmarshaller interface.
+func (in *Input) UnmarshalJSON(data []byte) error {
+	var v struct {
+		PrevHash  util.Uint256 `json:"txid"`
+		PrevIndex uint16       `json:"vout"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	in.PrevHash = v.PrevHash
+	in.PrevIndex = v.PrevIndex
+	return nil
+}
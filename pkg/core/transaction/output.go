@@ -2,8 +2,10 @@ package transaction
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"io"
 
+	"github.com/CityOfZion/neo-go/pkg/crypto"
 	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
@@ -49,3 +51,32 @@ func (out *Output) EncodeBinary(w io.Writer) error {
 	}
 	return binary.Write(w, binary.LittleEndian, out.ScriptHash)
 }
+
+// MarshalJSON implements the json marshaller interface.
+func (out *Output) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"asset":   out.AssetID,
+		"value":   out.Amount,
+		"address": crypto.AddressFromUint160(out.ScriptHash),
+	})
+}
+
+// UnmarshalJSON implements the json unmarshaller interface.
+func (out *Output) UnmarshalJSON(data []byte) error {
+	var v struct {
+		AssetID util.Uint256 `json:"asset"`
+		Amount  util.Fixed8  `json:"value"`
+		Address string       `json:"address"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	scriptHash, err := crypto.Uint160DecodeAddress(v.Address)
+	if err != nil {
+		return err
+	}
+	out.AssetID = v.AssetID
+	out.Amount = v.Amount
+	out.ScriptHash = scriptHash
+	return nil
+}
@@ -77,5 +77,30 @@ func (tx *PublishTX) DecodeBinary(r io.Reader) error {
 
 // EncodeBinary implements the Payload interface.
 func (tx *PublishTX) EncodeBinary(w io.Writer) error {
+	if err := util.WriteVarBytes(w, tx.Script); err != nil {
+		return err
+	}
+
+	if err := util.WriteVarUint(w, uint64(len(tx.ParamList))); err != nil {
+		return err
+	}
+	for _, ptype := range tx.ParamList {
+		if err := binary.Write(w, binary.LittleEndian, uint8(ptype)); err != nil {
+			return err
+		}
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, uint8(tx.ReturnType)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, tx.NeedStorage); err != nil {
+		return err
+	}
+
+	for _, s := range []string{tx.Name, tx.CodeVersion, tx.Author, tx.Email, tx.Description} {
+		if err := util.WriteVarString(w, s); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,39 @@
+package transaction
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishTX(t *testing.T) {
+	tx := &Transaction{
+		Type:    PublishType,
+		Version: 1,
+		Data: &PublishTX{
+			Script:      []byte{0x51, 0x66},
+			ParamList:   []smartcontract.ParamType{smartcontract.StringType, smartcontract.ArrayType},
+			ReturnType:  smartcontract.BoolType,
+			NeedStorage: true,
+			Name:        "test",
+			CodeVersion: "1.0",
+			Author:      "author",
+			Email:       "author@example.com",
+			Description: "a test contract",
+		},
+		Attributes: []*Attribute{},
+		Inputs:     []*Input{},
+		Outputs:    []*Output{},
+		Scripts:    []*Witness{},
+	}
+
+	buf := new(bytes.Buffer)
+	assert.Nil(t, tx.EncodeBinary(buf))
+
+	txDecode := &Transaction{}
+	assert.Nil(t, txDecode.DecodeBinary(buf))
+	assert.Equal(t, tx.Data, txDecode.Data)
+	assert.Equal(t, tx.Hash(), txDecode.Hash())
+}
@@ -2,12 +2,17 @@ package transaction
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 
 	"github.com/CityOfZion/neo-go/pkg/crypto"
 	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
+// maxPrecision is the maximum number of decimals of an asset, the amounts
+// being fixed point numbers with 8 decimals.
+const maxPrecision = 8
+
 // RegisterTX represents a register transaction.
 // NOTE: This is deprecated.
 type RegisterTX struct {
@@ -49,6 +54,9 @@ func (tx *RegisterTX) DecodeBinary(r io.Reader) error {
 	if err := binary.Read(r, binary.LittleEndian, &tx.Precision); err != nil {
 		return err
 	}
+	if tx.Precision > maxPrecision {
+		return fmt.Errorf("invalid precision %d", tx.Precision)
+	}
 
 	tx.Owner = &crypto.PublicKey{}
 	if err := tx.Owner.DecodeBinary(r); err != nil {
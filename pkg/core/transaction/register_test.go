@@ -35,6 +35,23 @@ func TestRegisterTX(t *testing.T) {
 	assert.Equal(t, tx.Hash(), txDecode.Hash())
 }
 
+func TestRegisterTXInvalidPrecision(t *testing.T) {
+	tx := &Transaction{
+		Type: RegisterType,
+		Data: &RegisterTX{
+			AssetType: Token,
+			Name:      "token",
+			Amount:    util.NewFixed8(1000000),
+			Precision: maxPrecision + 1,
+			Owner:     &crypto.PublicKey{},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	assert.Nil(t, tx.EncodeBinary(buf))
+	assert.NotNil(t, (&Transaction{}).DecodeBinary(buf))
+}
+
 func TestDecodeRegisterTXFromRawString(t *testing.T) {
 	rawTX := "400000455b7b226c616e67223a227a682d434e222c226e616d65223a22e5b08fe89a81e882a1227d2c7b226c616e67223a22656e222c226e616d65223a22416e745368617265227d5d0000c16ff28623000000da1745e9b549bd0bfa1a569971c77eba30cd5a4b00000000"
 	b, err := hex.DecodeString(rawTX)
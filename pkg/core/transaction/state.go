@@ -14,6 +14,7 @@ type StateTX struct {
 // DecodeBinary implements the Payload interface.
 func (tx *StateTX) DecodeBinary(r io.Reader) error {
 	lenDesc := util.ReadVarUint(r)
+	tx.Descriptors = make([]*StateDescriptor, lenDesc)
 	for i := 0; i < int(lenDesc); i++ {
 		tx.Descriptors[i] = &StateDescriptor{}
 		if err := tx.Descriptors[i].DecodeBinary(r); err != nil {
@@ -25,5 +26,13 @@ func (tx *StateTX) DecodeBinary(r io.Reader) error {
 
 // EncodeBinary implements the Payload interface.
 func (tx *StateTX) EncodeBinary(w io.Writer) error {
+	if err := util.WriteVarUint(w, uint64(len(tx.Descriptors))); err != nil {
+		return err
+	}
+	for _, desc := range tx.Descriptors {
+		if err := desc.EncodeBinary(w); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -16,7 +16,8 @@ const (
 	Validator DescStateType = 0x48
 )
 
-// StateDescriptor ..
+// StateDescriptor describes a change of the state of an account or of a
+// validator made by a state transaction.
 type StateDescriptor struct {
 	Type  DescStateType
 	Key   []byte
@@ -54,5 +55,14 @@ func (s *StateDescriptor) DecodeBinary(r io.Reader) error {
 
 // EncodeBinary implements the Payload interface.
 func (s *StateDescriptor) EncodeBinary(w io.Writer) error {
-	return nil
+	if err := binary.Write(w, binary.LittleEndian, s.Type); err != nil {
+		return err
+	}
+	if err := util.WriteVarBytes(w, s.Key); err != nil {
+		return err
+	}
+	if err := util.WriteVarBytes(w, s.Value); err != nil {
+		return err
+	}
+	return util.WriteVarString(w, s.Field)
 }
@@ -0,0 +1,43 @@
+package transaction
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateTX(t *testing.T) {
+	tx := &Transaction{
+		Type:    StateType,
+		Version: 0,
+		Data: &StateTX{
+			Descriptors: []*StateDescriptor{
+				{
+					Type:  Account,
+					Key:   []byte{1, 2, 3},
+					Value: []byte{4, 5},
+					Field: "Votes",
+				},
+				{
+					Type:  Validator,
+					Key:   []byte{6},
+					Value: []byte{1},
+					Field: "Registered",
+				},
+			},
+		},
+		Attributes: []*Attribute{},
+		Inputs:     []*Input{},
+		Outputs:    []*Output{},
+		Scripts:    []*Witness{},
+	}
+
+	buf := new(bytes.Buffer)
+	assert.Nil(t, tx.EncodeBinary(buf))
+
+	txDecode := &Transaction{}
+	assert.Nil(t, txDecode.DecodeBinary(buf))
+	assert.Equal(t, tx.Data, txDecode.Data)
+	assert.Equal(t, tx.Hash(), txDecode.Hash())
+}
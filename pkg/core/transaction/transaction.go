@@ -227,6 +227,16 @@ func (t *Transaction) encodeHashableFields(w io.Writer) error {
 	return nil
 }
 
+// GetHashableData returns the serialized fields of the transaction that are
+// covered by its hash, this is the data the witnesses have to sign.
+func (t *Transaction) GetHashableData() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := t.encodeHashableFields(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // createHash creates the hash of the transaction.
 func (t *Transaction) createHash() error {
 	buf := new(bytes.Buffer)
@@ -244,8 +254,13 @@ func (t *Transaction) createHash() error {
 
 // GroupTXInputsByPrevHash groups all TX inputs by their previous hash.
 func (t *Transaction) GroupInputsByPrevHash() map[util.Uint256][]*Input {
+	return GroupInputsByPrevHash(t.Inputs)
+}
+
+// GroupInputsByPrevHash groups the given inputs by their previous hash.
+func GroupInputsByPrevHash(inputs []*Input) map[util.Uint256][]*Input {
 	m := make(map[util.Uint256][]*Input)
-	for _, in := range t.Inputs {
+	for _, in := range inputs {
 		m[in.PrevHash] = append(m[in.PrevHash], in)
 	}
 	return m
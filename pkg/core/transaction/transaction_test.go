@@ -3,6 +3,7 @@ package transaction
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"testing"
 
 	"github.com/CityOfZion/neo-go/pkg/crypto"
@@ -109,3 +110,34 @@ func TestDecodeEncodeInvocationTX(t *testing.T) {
 	}
 	assert.Equal(t, rawInvocationTX, hex.EncodeToString(buf.Bytes()))
 }
+
+func TestTransactionJSONRoundTrip(t *testing.T) {
+	tx := &Transaction{
+		Type:    ContractType,
+		Version: 0,
+		Data:    &ContractTX{},
+		Attributes: []*Attribute{
+			{Usage: Remark1, Data: []byte{1, 2, 3}},
+			{Usage: Hash2, Data: make([]byte, 32)},
+		},
+		Inputs: []*Input{{PrevHash: util.Uint256{1, 2, 3}, PrevIndex: 4}},
+		Outputs: []*Output{{
+			AssetID:    util.Uint256{5, 6},
+			Amount:     util.NewFixed8(42),
+			ScriptHash: util.Uint160{7, 8, 9},
+		}},
+		Scripts: []*Witness{{InvocationScript: []byte{1}, VerificationScript: []byte{2}}},
+	}
+
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := &Transaction{}
+	if err := json.Unmarshal(data, actual); err != nil {
+		t.Fatal(err)
+	}
+	// The type specific data is not part of the JSON representation.
+	actual.Data = tx.Data
+	assert.Equal(t, tx, actual)
+}
@@ -1,5 +1,10 @@
 package transaction
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // TXType is the type of a transaction.
 type TXType uint8
 
@@ -46,3 +51,26 @@ func (t TXType) String() string {
 		return "UnkownTransaction"
 	}
 }
+
+// MarshalJSON implements the json marshaller interface.
+func (t TXType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON implements the json unmarshaller interface.
+func (t *TXType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, typ := range []TXType{
+		MinerType, IssueType, ClaimType, EnrollmentType, VotingType, RegisterType,
+		ContractType, StateType, AgencyType, PublishType, InvocationType,
+	} {
+		if typ.String() == s {
+			*t = typ
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown transaction type %s", s)
+}
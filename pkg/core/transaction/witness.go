@@ -50,3 +50,22 @@ func (w *Witness) MarshalJSON() ([]byte, error) {
 
 	return json.Marshal(data)
 }
+
+// UnmarshalJSON implements the json unmarshaller interface.
+func (w *Witness) UnmarshalJSON(data []byte) error {
+	var v map[string]string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	invocation, err := hex.DecodeString(v["invocation"])
+	if err != nil {
+		return err
+	}
+	verification, err := hex.DecodeString(v["verification"])
+	if err != nil {
+		return err
+	}
+	w.InvocationScript = invocation
+	w.VerificationScript = verification
+	return nil
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// undoEntry is the value a key had before being changed by a block, if it
+// existed.
+type undoEntry struct {
+	key     []byte
+	existed bool
+	value   []byte
+}
+
+// putUndoData puts in the given cache of the given store the values its
+// changes replace, to be restored by undoBlock when removing the block with
+// the given index.
+func putUndoData(s storage.Store, cache *storage.MemCachedStore, index uint32) error {
+	changes := cache.Changes()
+	entries := make([]undoEntry, len(changes))
+	for i, change := range changes {
+		entries[i].key = change.Key
+		// Keys that can't be read are taken as missing.
+		if value, err := s.Get(change.Key); err == nil {
+			entries[i].existed = true
+			entries[i].value = value
+		}
+	}
+	buf := new(bytes.Buffer)
+	if err := encodeUndoEntries(buf, entries); err != nil {
+		return err
+	}
+	return cache.Put(storage.AppendPrefixInt(storage.DataUndo, int(index)), buf.Bytes())
+}
+
+// pruneUndoData deletes in the given cache the undo data of the block that
+// falls out of the given number of the latest blocks when persisting the
+// block with the given index. It must be called after putUndoData for the
+// undo data of the pruned block not to be saved itself.
+func pruneUndoData(cache *storage.MemCachedStore, index uint32, maxBlocks uint32) error {
+	if index < maxBlocks {
+		return nil
+	}
+	return cache.Delete(storage.AppendPrefixInt(storage.DataUndo, int(index-maxBlocks)))
+}
+
+func encodeUndoEntries(w io.Writer, entries []undoEntry) error {
+	if err := util.WriteVarUint(w, uint64(len(entries))); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := util.WriteVarBytes(w, entry.key); err != nil {
+			return err
+		}
+		existed := byte(0)
+		if entry.existed {
+			existed = 1
+		}
+		if _, err := w.Write([]byte{existed}); err != nil {
+			return err
+		}
+		if err := util.WriteVarBytes(w, entry.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeUndoEntries(r io.Reader) ([]undoEntry, error) {
+	entries := make([]undoEntry, util.ReadVarUint(r))
+	for i := range entries {
+		key, err := util.ReadVarBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		existed := make([]byte, 1)
+		if _, err := io.ReadFull(r, existed); err != nil {
+			return nil, err
+		}
+		value, err := util.ReadVarBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = undoEntry{key: key, existed: existed[0] == 1, value: value}
+	}
+	return entries, nil
+}
+
+// Reset rolls the chain back to the block with the given index, undoing the
+// changes made by the following blocks and forgetting their headers. Blocks
+// can only be undone if they were persisted with their undo data, which is
+// the case of the blocks persisted by this version as long as they are among
+// the latest MaxUndoBlocks ones, none being kept if it's 0. It should not be
+// used while the node is syncing.
+func (bc *Blockchain) Reset(height uint32) (err error) {
+	bc.persistLock.Lock()
+	defer bc.persistLock.Unlock()
+
+	bc.headersOp <- func(headerList *HeaderHashList) {
+		err = bc.reset(headerList, height)
+	}
+	<-bc.headersOpDone
+	return err
+}
+
+// reset implements Reset, it must be executed in a headers operation.
+func (bc *Blockchain) reset(headerList *HeaderHashList, height uint32) error {
+	current := bc.BlockHeight()
+	if height > current {
+		return fmt.Errorf("block %d is above the current height %d", height, current)
+	}
+	// Nothing is undone if any of the blocks can't be.
+	for index := current; index > height; index-- {
+		if _, err := bc.Get(storage.AppendPrefixInt(storage.DataUndo, int(index))); err != nil {
+			return fmt.Errorf("no undo data for block %d: %s", index, err)
+		}
+	}
+	for index := current; index > height; index-- {
+		if err := bc.undoBlock(index); err != nil {
+			return err
+		}
+		atomic.StoreUint32(&bc.blockHeight, index-1)
+	}
+
+	// The blocks were turned back into headers, the ones above the new
+	// height are dropped.
+	return bc.removeHeaders(headerList, height)
+}
+
+// undoBlock restores the values of the keys changed by the block with the
+// given index, which must be the current one.
+func (bc *Blockchain) undoBlock(index uint32) error {
+	key := storage.AppendPrefixInt(storage.DataUndo, int(index))
+	data, err := bc.Get(key)
+	if err != nil {
+		return fmt.Errorf("no undo data for block %d: %s", index, err)
+	}
+	entries, err := decodeUndoEntries(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid undo data for block %d: %s", index, err)
+	}
+
+	batch := bc.Batch()
+	for _, entry := range entries {
+		if entry.existed {
+			batch.Put(entry.key, entry.value)
+		} else {
+			batch.Delete(entry.key)
+		}
+	}
+	batch.Delete(key)
+	return bc.PutBatch(batch)
+}
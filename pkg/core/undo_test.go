@@ -0,0 +1,128 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// storeContent returns everything the given chain has stored.
+func storeContent(bc *Blockchain) map[string][]byte {
+	content := make(map[string][]byte)
+	bc.Seek([]byte{}, func(k, v []byte) {
+		content[string(k)] = v
+	})
+	return content
+}
+
+func TestUndoEntriesEncodeDecode(t *testing.T) {
+	entries := []undoEntry{
+		{key: []byte{1, 2}, existed: true, value: []byte{3}},
+		{key: []byte{4}, value: []byte{}},
+	}
+	buf := new(bytes.Buffer)
+	assert.Nil(t, encodeUndoEntries(buf, entries))
+	decoded, err := decodeUndoEntries(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, entries, decoded)
+}
+
+func TestReset(t *testing.T) {
+	bc := newTestChain(t)
+	token := newTokenScript(t)
+	tokenHash, err := util.Uint160FromScript(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := util.Uint160{1, 2, 3}, util.Uint160{3, 2, 1}
+	genesis, err := bc.GetBlock(bc.CurrentBlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block1 := newSignedBlock(t, bc, genesis.Header(), newPublishTX(token),
+		newTransferInvocationTX(t, tokenHash, util.Uint160{}, alice, 100))
+	assert.Nil(t, bc.AddBlock(block1))
+	assert.Nil(t, bc.persist())
+	snapshot := storeContent(bc)
+
+	block2 := newSignedBlock(t, bc, block1.Header(), newTransferInvocationTX(t, tokenHash, alice, bob, 30))
+	assert.Nil(t, bc.AddBlock(block2))
+	assert.Nil(t, bc.persist())
+	assert.Equal(t, uint32(2), bc.BlockHeight())
+
+	assert.NotNil(t, bc.Reset(3))
+	assert.Nil(t, bc.Reset(1))
+	assert.Equal(t, uint32(1), bc.BlockHeight())
+	assert.Equal(t, uint32(1), bc.HeaderHeight())
+	assert.Equal(t, block1.Hash(), bc.CurrentHeaderHash())
+	assert.False(t, bc.HasBlock(block2.Hash()))
+	assert.Equal(t, snapshot, storeContent(bc))
+
+	// The chain goes on from the restored block.
+	assert.Nil(t, bc.AddBlock(block2))
+	assert.Nil(t, bc.persist())
+	assert.Equal(t, uint32(2), bc.BlockHeight())
+	balances, err := bc.GetNEP5Balances(bob)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(balances))
+
+	// Blocks without undo data can't be undone.
+	batch := bc.Batch()
+	batch.Delete(storage.AppendPrefixInt(storage.DataUndo, 2))
+	assert.Nil(t, bc.PutBatch(batch))
+	assert.NotNil(t, bc.Reset(0))
+	assert.Equal(t, uint32(2), bc.BlockHeight())
+}
+
+func TestPruneUndoData(t *testing.T) {
+	bc := newTestChain(t)
+	bc.maxUndoBlocks = 2
+	prev, err := bc.GetBlock(bc.CurrentBlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		block := newSignedBlock(t, bc, prev.Header())
+		assert.Nil(t, bc.AddBlock(block))
+		assert.Nil(t, bc.persist())
+		prev = block
+	}
+	assert.Equal(t, uint32(4), bc.BlockHeight())
+
+	// Only the undo data of the last 2 blocks is kept.
+	for index, kept := range []bool{false, false, false, true, true} {
+		_, err := bc.Get(storage.AppendPrefixInt(storage.DataUndo, index))
+		assert.Equal(t, kept, err == nil, "block %d", index)
+	}
+
+	// The chain is left untouched when going back beyond them.
+	snapshot := storeContent(bc)
+	assert.NotNil(t, bc.Reset(1))
+	assert.Equal(t, uint32(4), bc.BlockHeight())
+	assert.Equal(t, snapshot, storeContent(bc))
+
+	assert.Nil(t, bc.Reset(2))
+	assert.Equal(t, uint32(2), bc.BlockHeight())
+}
+
+func TestNoUndoData(t *testing.T) {
+	bc := newTestChain(t)
+	bc.maxUndoBlocks = 0
+	prev, err := bc.GetBlock(bc.CurrentBlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := newSignedBlock(t, bc, prev.Header())
+	assert.Nil(t, bc.AddBlock(block))
+	assert.Nil(t, bc.persist())
+	assert.Equal(t, uint32(1), bc.BlockHeight())
+
+	_, err = bc.Get(storage.AppendPrefixInt(storage.DataUndo, 1))
+	assert.Equal(t, storage.ErrKeyNotFound, err)
+	assert.NotNil(t, bc.Reset(0))
+	assert.Equal(t, uint32(1), bc.BlockHeight())
+}
@@ -10,31 +10,30 @@ import (
 	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
-// UnspentCoins is mapping between transactions and their unspent
-// coin state.
-type UnspentCoins map[util.Uint256]*UnspentCoinState
-
-func (u UnspentCoins) getAndUpdate(s storage.Store, hash util.Uint256) (*UnspentCoinState, error) {
-	if unspent, ok := u[hash]; ok {
-		return unspent, nil
+// getUnspentCoinState returns the state of the outputs of the transaction
+// with the given hash in the given store.
+func getUnspentCoinState(s storage.Store, hash util.Uint256) (*UnspentCoinState, error) {
+	b, err := s.Get(storage.AppendPrefix(storage.STCoin, hash.BytesReverse()))
+	if err != nil {
+		return nil, err
 	}
-
 	unspent := &UnspentCoinState{}
-	key := storage.AppendPrefix(storage.STCoin, hash.BytesReverse())
-	if b, err := s.Get(key); err == nil {
-		if err := unspent.DecodeBinary(bytes.NewReader(b)); err != nil {
-			return nil, fmt.Errorf("failed to decode (UnspentCoinState): %s", err)
-		}
-	} else {
-		unspent = &UnspentCoinState{
-			states: []CoinState{},
-		}
+	if err := unspent.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("failed to decode (UnspentCoinState): %s", err)
 	}
-
-	u[hash] = unspent
 	return unspent, nil
 }
 
+// putUnspentCoinState stores the state of the outputs of the transaction
+// with the given hash in the given store.
+func putUnspentCoinState(s storage.Store, hash util.Uint256, unspent *UnspentCoinState) error {
+	buf := new(bytes.Buffer)
+	if err := unspent.EncodeBinary(buf); err != nil {
+		return err
+	}
+	return s.Put(storage.AppendPrefix(storage.STCoin, hash.BytesReverse()), buf.Bytes())
+}
+
 // UnspentCoinState hold the state of a unspent coin.
 type UnspentCoinState struct {
 	states []CoinState
@@ -51,18 +50,10 @@ func NewUnspentCoinState(n int) *UnspentCoinState {
 	return u
 }
 
-// commit writes all unspent coin states to the given Batch.
-func (s UnspentCoins) commit(b storage.Batch) error {
-	buf := new(bytes.Buffer)
-	for hash, state := range s {
-		if err := state.EncodeBinary(buf); err != nil {
-			return err
-		}
-		key := storage.AppendPrefix(storage.STCoin, hash.BytesReverse())
-		b.Put(key, buf.Bytes())
-		buf.Reset()
-	}
-	return nil
+// States returns the states of the outputs of the transaction, indexed by
+// their position.
+func (s *UnspentCoinState) States() []CoinState {
+	return s.states
 }
 
 // EncodeBinary encodes UnspentCoinState to the given io.Writer.
@@ -26,34 +26,17 @@ func TestDecodeEncodeUnspentCoinState(t *testing.T) {
 	assert.Nil(t, unspentDecode.DecodeBinary(buf))
 }
 
-func TestCommitUnspentCoins(t *testing.T) {
-	var (
-		store        = storage.NewMemoryStore()
-		batch        = store.Batch()
-		unspentCoins = make(UnspentCoins)
-	)
-
-	txA := util.RandomUint256()
-	txB := util.RandomUint256()
-	txC := util.RandomUint256()
-
-	unspentCoins[txA] = &UnspentCoinState{
-		states: []CoinState{CoinStateConfirmed},
-	}
-	unspentCoins[txB] = &UnspentCoinState{
-		states: []CoinState{
-			CoinStateConfirmed,
-			CoinStateConfirmed,
-		},
-	}
-	unspentCoins[txC] = &UnspentCoinState{
-		states: []CoinState{
-			CoinStateConfirmed,
-			CoinStateConfirmed,
-			CoinStateConfirmed,
-		},
-	}
-
-	assert.Nil(t, unspentCoins.commit(batch))
-	assert.Nil(t, store.PutBatch(batch))
+func TestPutGetUnspentCoinState(t *testing.T) {
+	store := storage.NewMemoryStore()
+	hash := util.RandomUint256()
+
+	_, err := getUnspentCoinState(store, hash)
+	assert.Equal(t, storage.ErrKeyNotFound, err)
+
+	unspent := NewUnspentCoinState(2)
+	unspent.states[1] = CoinStateSpent
+	assert.Nil(t, putUnspentCoinState(store, hash, unspent))
+	stored, err := getUnspentCoinState(store, hash)
+	assert.Nil(t, err)
+	assert.Equal(t, unspent, stored)
 }
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// UnspentOutput is an output that can be spent by the account it was sent
+// to.
+type UnspentOutput struct {
+	TxHash  util.Uint256
+	Index   uint16
+	AssetID util.Uint256
+	Amount  util.Fixed8
+}
+
+// Length of the transaction hash and of the output index that follow the
+// script hash of the account in the keys of the unspent outputs.
+const unspentKeySuffixLen = 32 + 2
+
+// makeUnspentKey returns the key of the given output in the unspent outputs
+// of the given account.
+func makeUnspentKey(scriptHash util.Uint160, hash util.Uint256, index uint16) []byte {
+	suffix := make([]byte, unspentKeySuffixLen)
+	copy(suffix, hash.BytesReverse())
+	binary.BigEndian.PutUint16(suffix[32:], index)
+	return storage.AppendPrefix(storage.IXUnspent, append(scriptHash.Bytes(), suffix...))
+}
+
+// putUnspentOutput adds the given output to the unspent outputs of the given
+// account in the given store.
+func putUnspentOutput(s storage.Store, scriptHash util.Uint160, output *UnspentOutput) error {
+	buf := new(bytes.Buffer)
+	if err := output.EncodeBinary(buf); err != nil {
+		return err
+	}
+	return s.Put(makeUnspentKey(scriptHash, output.TxHash, output.Index), buf.Bytes())
+}
+
+// deleteUnspentOutput removes the output with the given transaction hash and
+// index from the unspent outputs of the given account in the given store.
+func deleteUnspentOutput(s storage.Store, scriptHash util.Uint160, hash util.Uint256, index uint16) error {
+	return s.Delete(makeUnspentKey(scriptHash, hash, index))
+}
+
+// getUnspentOutputs returns the unspent outputs of the given account in the
+// given store, ordered by transaction hash and index.
+func getUnspentOutputs(s storage.Store, scriptHash util.Uint160) ([]*UnspentOutput, error) {
+	var (
+		prefix  = storage.AppendPrefix(storage.IXUnspent, scriptHash.Bytes())
+		outputs []*UnspentOutput
+		err     error
+	)
+	iterErr := s.Iterate(storage.PrefixRange(prefix), func(k, v []byte) bool {
+		if len(k) != len(prefix)+unspentKeySuffixLen {
+			err = fmt.Errorf("invalid unspent output key %x", k)
+			return false
+		}
+		output := &UnspentOutput{}
+		if output.TxHash, err = util.Uint256DecodeBytes(k[len(prefix) : len(prefix)+32]); err != nil {
+			return false
+		}
+		output.Index = binary.BigEndian.Uint16(k[len(prefix)+32:])
+		if decodeErr := output.DecodeBinary(bytes.NewReader(v)); decodeErr != nil {
+			err = fmt.Errorf("failed to decode (UnspentOutput): %s", decodeErr)
+			return false
+		}
+		outputs = append(outputs, output)
+		return true
+	})
+	if iterErr != nil {
+		return nil, iterErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+// DecodeBinary decodes the asset and the amount of the UnspentOutput from
+// the given io.Reader, the transaction hash and the index being in its key.
+func (o *UnspentOutput) DecodeBinary(r io.Reader) error {
+	var version uint8
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &o.AssetID); err != nil {
+		return err
+	}
+	return binary.Read(r, binary.LittleEndian, &o.Amount)
+}
+
+// EncodeBinary encodes the asset and the amount of the UnspentOutput to the
+// given io.Writer.
+func (o *UnspentOutput) EncodeBinary(w io.Writer) error {
+	// State version.
+	if err := binary.Write(w, binary.LittleEndian, uint8(0)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, o.AssetID); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, o.Amount)
+}
+
+// GetUnspents returns the unspent outputs of the account with the given
+// script hash, none if it never received anything.
+func (bc *Blockchain) GetUnspents(scriptHash util.Uint160) ([]*UnspentOutput, error) {
+	return getUnspentOutputs(bc.Store, scriptHash)
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnspentOutputs(t *testing.T) {
+	var (
+		store   = storage.NewMemoryStore()
+		account = util.Uint160{1, 2, 3}
+		other   = util.Uint160{1, 2, 4}
+		outputs = make([]*UnspentOutput, 3)
+	)
+	for i := range outputs {
+		outputs[i] = &UnspentOutput{
+			TxHash:  util.Uint256{byte(i)},
+			Index:   uint16(i),
+			AssetID: util.RandomUint256(),
+			Amount:  util.NewFixed8(i + 1),
+		}
+		assert.Nil(t, putUnspentOutput(store, account, outputs[i]))
+	}
+	assert.Nil(t, putUnspentOutput(store, other, &UnspentOutput{TxHash: util.Uint256{9}}))
+
+	// Every output has its own key.
+	unspents, err := getUnspentOutputs(store, account)
+	assert.Nil(t, err)
+	assert.Equal(t, outputs, unspents)
+
+	assert.Nil(t, deleteUnspentOutput(store, account, outputs[1].TxHash, 1))
+	unspents, err = getUnspentOutputs(store, account)
+	assert.Nil(t, err)
+	assert.Equal(t, []*UnspentOutput{outputs[0], outputs[2]}, unspents)
+
+	// Invalid values are reported.
+	assert.Nil(t, store.Put(makeUnspentKey(account, outputs[0].TxHash, 0), []byte{0}))
+	_, err = getUnspentOutputs(store, account)
+	assert.NotNil(t, err)
+}
+
+func TestGetUnspents(t *testing.T) {
+	bc := newTestChain(t)
+	genesis, err := createGenesisBlock(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueTX := genesis.Transactions[3]
+	neo := governingTokenTX().Hash()
+	amount := issueTX.Outputs[0].Amount
+	owner := issueTX.Outputs[0].ScriptHash
+
+	unspents, err := bc.GetUnspents(owner)
+	assert.Nil(t, err)
+	assert.Equal(t, []*UnspentOutput{{
+		TxHash:  issueTX.Hash(),
+		Index:   0,
+		AssetID: neo,
+		Amount:  amount,
+	}}, unspents)
+
+	unspents, err = bc.GetUnspents(util.Uint160{1, 2, 3})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(unspents))
+
+	// Spent outputs are replaced by the new ones.
+	spend := &transaction.Transaction{
+		Type:       transaction.ContractType,
+		Data:       &transaction.ContractTX{},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{{PrevHash: issueTX.Hash(), PrevIndex: 0}},
+		Outputs: []*transaction.Output{
+			transaction.NewOutput(neo, util.NewFixed8(1), util.Uint160{1, 2, 3}),
+			transaction.NewOutput(neo, amount-util.NewFixed8(1), owner),
+		},
+	}
+	assert.Nil(t, bc.persistBlock(newBlock(1, spend)))
+	unspents, err = bc.GetUnspents(owner)
+	assert.Nil(t, err)
+	assert.Equal(t, []*UnspentOutput{{
+		TxHash:  spend.Hash(),
+		Index:   1,
+		AssetID: neo,
+		Amount:  amount - util.NewFixed8(1),
+	}}, unspents)
+	unspents, err = bc.GetUnspents(util.Uint160{1, 2, 3})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(unspents))
+}
@@ -189,7 +189,8 @@ func storeAsCurrentBlock(batch storage.Batch, block *Block) {
 	batch.Put(storage.SYSCurrentBlock.Bytes(), buf.Bytes())
 }
 
-// storeAsBlock stores the given block as DataBlock.
+// storeAsBlock stores the given block as DataBlock, prefixed with the total
+// system fee of the blocks up to it.
 func storeAsBlock(batch storage.Batch, block *Block, sysFee uint32) error {
 	var (
 		key = storage.AppendPrefix(storage.DataBlock, block.Hash().BytesReverse())
@@ -198,6 +199,7 @@ func storeAsBlock(batch storage.Batch, block *Block, sysFee uint32) error {
 
 	b := make([]byte, 4)
 	binary.LittleEndian.PutUint32(b, sysFee)
+	buf.Write(b)
 
 	b, err := block.Trim()
 	if err != nil {
@@ -223,3 +225,14 @@ func storeAsTransaction(batch storage.Batch, tx *transaction.Transaction, index
 
 	return nil
 }
+
+// storeAsAppExecResult stores the given execution result as STNotification.
+func storeAsAppExecResult(batch storage.Batch, aer *AppExecResult) error {
+	key := storage.AppendPrefix(storage.STNotification, aer.TxHash.BytesReverse())
+	buf := new(bytes.Buffer)
+	if err := aer.EncodeBinary(buf); err != nil {
+		return err
+	}
+	batch.Put(key, buf.Bytes())
+	return nil
+}
@@ -1,12 +1,44 @@
 package core
 
 import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
 	"github.com/CityOfZion/neo-go/pkg/crypto"
 	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
-// Validators is a mapping between public keys and ValidatorState.
-type Validators map[*crypto.PublicKey]*ValidatorState
+// MaxValidators is the maximum number of validators an account can vote for.
+const MaxValidators = 1024
+
+// getValidatorState returns the state of the validator with the given public
+// key in the given store, a new one if the validator is unknown.
+func getValidatorState(s storage.Store, publicKey *crypto.PublicKey) (*ValidatorState, error) {
+	b, err := s.Get(storage.AppendPrefix(storage.STValidator, publicKey.Bytes()))
+	if err == storage.ErrKeyNotFound {
+		return NewValidatorState(publicKey), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	validator := &ValidatorState{}
+	if err := validator.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("failed to decode (ValidatorState): %s", err)
+	}
+	return validator, nil
+}
+
+// putValidatorState stores the given validator state in the given store.
+func putValidatorState(s storage.Store, validator *ValidatorState) error {
+	buf := new(bytes.Buffer)
+	if err := validator.EncodeBinary(buf); err != nil {
+		return err
+	}
+	return s.Put(storage.AppendPrefix(storage.STValidator, validator.PublicKey.Bytes()), buf.Bytes())
+}
 
 // ValidatorState holds the state of a validator.
 type ValidatorState struct {
@@ -14,3 +46,107 @@ type ValidatorState struct {
 	Registered bool
 	Votes      util.Fixed8
 }
+
+// NewValidatorState returns a new unregistered ValidatorState with no votes.
+func NewValidatorState(publicKey *crypto.PublicKey) *ValidatorState {
+	return &ValidatorState{
+		PublicKey: publicKey,
+	}
+}
+
+// DecodeBinary decodes ValidatorState from the given io.Reader.
+func (vs *ValidatorState) DecodeBinary(r io.Reader) error {
+	var version uint8
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return err
+	}
+	vs.PublicKey = &crypto.PublicKey{}
+	if err := vs.PublicKey.DecodeBinary(r); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &vs.Registered); err != nil {
+		return err
+	}
+	return binary.Read(r, binary.LittleEndian, &vs.Votes)
+}
+
+// EncodeBinary encodes ValidatorState to the given io.Writer.
+func (vs *ValidatorState) EncodeBinary(w io.Writer) error {
+	// State version.
+	if err := binary.Write(w, binary.LittleEndian, uint8(0)); err != nil {
+		return err
+	}
+	if err := vs.PublicKey.EncodeBinary(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, vs.Registered); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, vs.Votes)
+}
+
+// ValidatorsCount holds, for every number of validators an account can vote
+// for, the governing token amount of the accounts voting for that many
+// validators. It's used to compute the number of validators.
+type ValidatorsCount [MaxValidators]util.Fixed8
+
+// getValidatorsCount reads the ValidatorsCount from the given store, all the
+// counts being zero if it was never stored.
+func getValidatorsCount(s storage.Store) (*ValidatorsCount, error) {
+	count := &ValidatorsCount{}
+	b, err := s.Get(storage.IXValidatorsCount.Bytes())
+	if err == storage.ErrKeyNotFound {
+		return count, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := count.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("failed to decode (ValidatorsCount): %s", err)
+	}
+	return count, nil
+}
+
+// putValidatorsCount stores the given ValidatorsCount in the given store.
+func putValidatorsCount(s storage.Store, count *ValidatorsCount) error {
+	buf := new(bytes.Buffer)
+	if err := count.EncodeBinary(buf); err != nil {
+		return err
+	}
+	return s.Put(storage.IXValidatorsCount.Bytes(), buf.Bytes())
+}
+
+// DecodeBinary decodes ValidatorsCount from the given io.Reader.
+func (vc *ValidatorsCount) DecodeBinary(r io.Reader) error {
+	var version uint8
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return err
+	}
+	n := util.ReadVarUint(r)
+	if n > MaxValidators {
+		return fmt.Errorf("too many validators counts: %d", n)
+	}
+	for i := 0; i < int(n); i++ {
+		if err := binary.Read(r, binary.LittleEndian, &vc[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeBinary encodes ValidatorsCount to the given io.Writer.
+func (vc *ValidatorsCount) EncodeBinary(w io.Writer) error {
+	// State version.
+	if err := binary.Write(w, binary.LittleEndian, uint8(0)); err != nil {
+		return err
+	}
+	if err := util.WriteVarUint(w, uint64(len(vc))); err != nil {
+		return err
+	}
+	for _, votes := range vc {
+		if err := binary.Write(w, binary.LittleEndian, votes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,428 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// State descriptor fields that can be changed by state transactions.
+const (
+	votesField      = "Votes"
+	registeredField = "Registered"
+)
+
+// GetValidators returns the validators of the next block, computed from the
+// current votes updated with the given transactions and sorted by public key.
+// The standby validators are used when there are not enough candidates.
+func (bc *Blockchain) GetValidators(txs ...*transaction.Transaction) ([]*crypto.PublicKey, error) {
+	// The transactions are applied to a snapshot that is dropped afterwards.
+	cache := storage.NewMemCachedStore(bc.Store)
+	count, err := getValidatorsCount(cache)
+	if err != nil {
+		return nil, err
+	}
+
+	neo := governingTokenTX().Hash()
+	for _, tx := range txs {
+		// The following transactions can spend the outputs of this one.
+		batch := cache.Batch()
+		if err := storeAsTransaction(batch, tx, 0); err != nil {
+			return nil, err
+		}
+		if err := cache.PutBatch(batch); err != nil {
+			return nil, err
+		}
+		for _, output := range tx.Outputs {
+			if !output.AssetID.Equals(neo) {
+				continue
+			}
+			if err := changeGoverningBalance(cache, output.ScriptHash, output.Amount, count); err != nil {
+				return nil, err
+			}
+		}
+		references, err := getReferences(cache, tx)
+		if err != nil {
+			return nil, err
+		}
+		for _, output := range references {
+			if !output.AssetID.Equals(neo) {
+				continue
+			}
+			if err := changeGoverningBalance(cache, output.ScriptHash, -output.Amount, count); err != nil {
+				return nil, err
+			}
+		}
+		if state, ok := tx.Data.(*transaction.StateTX); ok {
+			if err := processStateTX(cache, state, count); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return bc.computeValidators(cache, count)
+}
+
+// changeGoverningBalance adds the given governing token amount to the balance
+// of the given account and to the votes of the validators it voted for, in
+// the given store.
+func changeGoverningBalance(s storage.Store, scriptHash util.Uint160, amount util.Fixed8, count *ValidatorsCount) error {
+	account, err := getAccountState(s, scriptHash)
+	if err != nil {
+		return err
+	}
+	account.Balances[governingTokenTX().Hash()] += amount
+	if err := putAccountState(s, account); err != nil {
+		return err
+	}
+	return addVotes(s, account, amount, count)
+}
+
+// computeValidators returns the validators elected with the votes in the
+// given store and the given validators count.
+func (bc *Blockchain) computeValidators(s storage.Store, count *ValidatorsCount) ([]*crypto.PublicKey, error) {
+	standby, err := getValidators(bc.config)
+	if err != nil {
+		return nil, err
+	}
+	n := validatorsNumber(count)
+	if n < len(standby) {
+		n = len(standby)
+	}
+
+	isStandby := make(map[string]bool, len(standby))
+	for _, key := range standby {
+		isStandby[string(key.Bytes())] = true
+	}
+	states, err := getValidatorStates(s)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]*ValidatorState, 0, len(states))
+	for k, state := range states {
+		if (state.Registered && state.Votes > 0) || isStandby[k] {
+			candidates = append(candidates, state)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Votes != candidates[j].Votes {
+			return candidates[i].Votes > candidates[j].Votes
+		}
+		return crypto.PublicKeys{candidates[i].PublicKey, candidates[j].PublicKey}.Less(0, 1)
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+
+	elected := make(map[string]bool, n)
+	result := make(crypto.PublicKeys, 0, n)
+	for _, state := range candidates {
+		elected[string(state.PublicKey.Bytes())] = true
+		result = append(result, state.PublicKey)
+	}
+	for i := 0; i < len(standby) && len(result) < n; i++ {
+		if !elected[string(standby[i].Bytes())] {
+			result = append(result, standby[i])
+		}
+	}
+	sort.Sort(result)
+	return result, nil
+}
+
+// GetEnrollments returns the states of the registered validators and of the
+// standby ones, sorted by public key.
+func (bc *Blockchain) GetEnrollments() ([]*ValidatorState, error) {
+	standby, err := getValidators(bc.config)
+	if err != nil {
+		return nil, err
+	}
+	states, err := getValidatorStates(bc.Store)
+	if err != nil {
+		return nil, err
+	}
+	isStandby := make(map[string]bool, len(standby))
+	for _, key := range standby {
+		k := string(key.Bytes())
+		isStandby[k] = true
+		if _, ok := states[k]; !ok {
+			states[k] = NewValidatorState(key)
+		}
+	}
+
+	enrollments := make([]*ValidatorState, 0, len(states))
+	for k, state := range states {
+		if state.Registered || isStandby[k] {
+			enrollments = append(enrollments, state)
+		}
+	}
+	sort.Slice(enrollments, func(i, j int) bool {
+		return crypto.PublicKeys{enrollments[i].PublicKey, enrollments[j].PublicKey}.Less(0, 1)
+	})
+	return enrollments, nil
+}
+
+// getValidatorStates returns all the validator states of the given store
+// indexed by their public key in its compressed binary form.
+func getValidatorStates(s storage.Store) (map[string]*ValidatorState, error) {
+	var (
+		states = make(map[string]*ValidatorState)
+		err    error
+	)
+	s.Seek(storage.STValidator.Bytes(), func(k, v []byte) {
+		state := &ValidatorState{}
+		if decodeErr := state.DecodeBinary(bytes.NewReader(v)); decodeErr != nil {
+			err = fmt.Errorf("failed to decode (ValidatorState): %s", decodeErr)
+			return
+		}
+		states[string(state.PublicKey.Bytes())] = state
+	})
+	if err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// validatorsNumber returns the number of validators voted for by the
+// accounts, the average of the numbers of validators they voted for weighted
+// by their votes once the lowest and the highest quarters of the votes are
+// filtered out.
+func validatorsNumber(count *ValidatorsCount) int {
+	const start, end = 0.25, 0.75
+
+	var total float64
+	for _, votes := range count {
+		total += float64(votes)
+	}
+
+	var (
+		sum       int64
+		current   float64
+		weighted  int64
+		sumWeight int64
+	)
+	for i, votes := range count {
+		if votes <= 0 {
+			continue
+		}
+		if current >= end {
+			break
+		}
+		weight := int64(votes)
+		sum += weight
+		old := current
+		current = float64(sum) / total
+		if current <= start {
+			continue
+		}
+		if old < start {
+			if current > end {
+				weight = int64((end - start) * total)
+			} else {
+				weight = int64((current - start) * total)
+			}
+		} else if current > end {
+			weight = int64((end - old) * total)
+		}
+		weighted += int64(i) * weight
+		sumWeight += weight
+	}
+	if sumWeight == 0 {
+		return 0
+	}
+	return int(weighted / sumWeight)
+}
+
+// addVotes adds the given governing token amount to the votes of the
+// validators the given account voted for, in the given store.
+func addVotes(s storage.Store, account *AccountState, amount util.Fixed8, count *ValidatorsCount) error {
+	if len(account.Votes) == 0 {
+		return nil
+	}
+	for _, key := range account.Votes {
+		validator, err := getValidatorState(s, key)
+		if err != nil {
+			return err
+		}
+		validator.Votes += amount
+		if err := putValidatorState(s, validator); err != nil {
+			return err
+		}
+	}
+	count[len(account.Votes)-1] += amount
+	return nil
+}
+
+// processStateTX applies the changes described by the given state
+// transaction to the accounts and to the validators of the given store.
+func processStateTX(s storage.Store, tx *transaction.StateTX, count *ValidatorsCount) error {
+	for _, desc := range tx.Descriptors {
+		switch desc.Type {
+		case transaction.Account:
+			if desc.Field != votesField {
+				continue
+			}
+			hash, err := util.Uint160DecodeBytes(desc.Key)
+			if err != nil {
+				return err
+			}
+			account, err := getAccountState(s, hash)
+			if err != nil {
+				return err
+			}
+			votes, err := decodeVotes(desc.Value)
+			if err != nil {
+				return err
+			}
+			// Move the votes of the account to its new validators.
+			balance := account.Balances[governingTokenTX().Hash()]
+			if err := addVotes(s, account, -balance, count); err != nil {
+				return err
+			}
+			account.Votes = votes
+			if err := addVotes(s, account, balance, count); err != nil {
+				return err
+			}
+			if err := putAccountState(s, account); err != nil {
+				return err
+			}
+		case transaction.Validator:
+			if desc.Field != registeredField {
+				continue
+			}
+			key := &crypto.PublicKey{}
+			if err := key.DecodeBytes(desc.Key); err != nil {
+				return err
+			}
+			validator, err := getValidatorState(s, key)
+			if err != nil {
+				return err
+			}
+			validator.Registered = len(desc.Value) > 0 && desc.Value[0] != 0
+			if err := putValidatorState(s, validator); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// registersValidator returns true if the given descriptor registers a
+// validator.
+func registersValidator(desc *transaction.StateDescriptor) bool {
+	if desc.Type != transaction.Validator || desc.Field != registeredField {
+		return false
+	}
+	for _, b := range desc.Value {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeVotes decodes the distinct public keys voted for in an account state
+// descriptor.
+func decodeVotes(data []byte) ([]*crypto.PublicKey, error) {
+	r := bytes.NewReader(data)
+	n := util.ReadVarUint(r)
+	if n > MaxValidators {
+		return nil, fmt.Errorf("too many votes: %d", n)
+	}
+	var (
+		votes = make([]*crypto.PublicKey, 0, n)
+		seen  = make(map[string]bool, n)
+	)
+	for i := 0; i < int(n); i++ {
+		key := &crypto.PublicKey{}
+		if err := key.DecodeBinary(r); err != nil {
+			return nil, err
+		}
+		if !seen[string(key.Bytes())] {
+			seen[string(key.Bytes())] = true
+			votes = append(votes, key)
+		}
+	}
+	return votes, nil
+}
+
+// verifyStateTX checks that the descriptors of the given state transaction
+// describe valid changes: accounts can only vote for registered or standby
+// validators and only if they are not frozen and own governing tokens.
+func (bc *Blockchain) verifyStateTX(tx *transaction.StateTX) error {
+	standby, err := getValidators(bc.config)
+	if err != nil {
+		return err
+	}
+	isStandby := make(map[string]bool, len(standby))
+	for _, key := range standby {
+		isStandby[string(key.Bytes())] = true
+	}
+
+	for _, desc := range tx.Descriptors {
+		switch desc.Type {
+		case transaction.Account:
+			if desc.Field != votesField {
+				return fmt.Errorf("invalid account field %q", desc.Field)
+			}
+			hash, err := util.Uint160DecodeBytes(desc.Key)
+			if err != nil {
+				return err
+			}
+			votes, err := decodeVotes(desc.Value)
+			if err != nil {
+				return err
+			}
+			account := bc.GetAccountState(hash)
+			if account == nil {
+				return fmt.Errorf("unknown account %s", hash)
+			}
+			if account.IsFrozen {
+				return fmt.Errorf("account %s is frozen", hash)
+			}
+			if len(votes) == 0 {
+				continue
+			}
+			if account.Balances[governingTokenTX().Hash()] == 0 {
+				return fmt.Errorf("account %s has no governing token to vote with", hash)
+			}
+			for _, key := range votes {
+				if isStandby[string(key.Bytes())] {
+					continue
+				}
+				validator := bc.GetValidatorState(key)
+				if validator == nil || !validator.Registered {
+					return fmt.Errorf("validator %x is not registered", key.Bytes())
+				}
+			}
+		case transaction.Validator:
+			if desc.Field != registeredField {
+				return fmt.Errorf("invalid validator field %q", desc.Field)
+			}
+			if err := (&crypto.PublicKey{}).DecodeBytes(desc.Key); err != nil {
+				return err
+			}
+		default:
+			return errors.New("invalid state descriptor type")
+		}
+	}
+	return nil
+}
+
+// GetValidatorState returns the state of the validator with the given public
+// key or nil if it was never registered nor voted for.
+func (bc *Blockchain) GetValidatorState(publicKey *crypto.PublicKey) *ValidatorState {
+	b, err := bc.Get(storage.AppendPrefix(storage.STValidator, publicKey.Bytes()))
+	if err != nil {
+		return nil
+	}
+	state := &ValidatorState{}
+	if err := state.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil
+	}
+	return state
+}
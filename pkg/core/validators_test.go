@@ -0,0 +1,213 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStateTX(descriptors ...*transaction.StateDescriptor) *transaction.Transaction {
+	return &transaction.Transaction{
+		Type:       transaction.StateType,
+		Data:       &transaction.StateTX{Descriptors: descriptors},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{},
+		Outputs:    []*transaction.Output{},
+	}
+}
+
+func newRegisterDescriptor(key *crypto.PublicKey) *transaction.StateDescriptor {
+	return &transaction.StateDescriptor{
+		Type:  transaction.Validator,
+		Key:   key.Bytes(),
+		Value: []byte{1},
+		Field: "Registered",
+	}
+}
+
+func newVoteDescriptor(t *testing.T, account util.Uint160, keys ...*crypto.PublicKey) *transaction.StateDescriptor {
+	buf := new(bytes.Buffer)
+	if err := util.WriteVarUint(buf, uint64(len(keys))); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if err := key.EncodeBinary(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &transaction.StateDescriptor{
+		Type:  transaction.Account,
+		Key:   account.Bytes(),
+		Value: buf.Bytes(),
+		Field: "Votes",
+	}
+}
+
+func newCandidateKey(t *testing.T) *crypto.PublicKey {
+	priv, err := wallet.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := priv.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &crypto.PublicKey{}
+	if err := key.DecodeBytes(b); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// containsKey returns true if the given key is one of the given keys.
+func containsKey(keys []*crypto.PublicKey, key *crypto.PublicKey) bool {
+	for _, k := range keys {
+		if bytes.Equal(k.Bytes(), key.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGetValidatorsStandby(t *testing.T) {
+	bc := newTestChain(t)
+	validators, err := bc.GetValidators()
+	assert.Nil(t, err)
+	keys := getValidatorKeys(t)
+	if assert.Equal(t, len(keys), len(validators)) {
+		for i := range keys {
+			assert.Equal(t, keys[i].pub.Bytes(), validators[i].Bytes())
+		}
+	}
+
+	enrollments, err := bc.GetEnrollments()
+	assert.Nil(t, err)
+	assert.Equal(t, len(keys), len(enrollments))
+}
+
+func TestVoting(t *testing.T) {
+	bc := newTestChain(t)
+	genesis, err := createGenesisBlock(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueTX := genesis.Transactions[3]
+	neo := governingTokenTX().Hash()
+	amount := issueTX.Outputs[0].Amount
+	owner := issueTX.Outputs[0].ScriptHash
+	candidate := newCandidateKey(t)
+	standby := getValidatorKeys(t)
+
+	// Unregistered candidates can't be voted for.
+	vote := newStateTX(newVoteDescriptor(t, owner, candidate))
+	assert.NotNil(t, bc.verifyStateTX(vote.Data.(*transaction.StateTX)))
+
+	register := newStateTX(newRegisterDescriptor(candidate))
+	assert.Nil(t, bc.verifyStateTX(register.Data.(*transaction.StateTX)))
+	assert.Equal(t, util.NewFixed8(int(bc.config.SystemFee.EnrollmentTransaction)), bc.SystemFee(register))
+	assert.Nil(t, bc.persistBlock(newBlock(1, register)))
+	state := bc.GetValidatorState(candidate)
+	if assert.NotNil(t, state) {
+		assert.True(t, state.Registered)
+		assert.Equal(t, util.Fixed8(0), state.Votes)
+	}
+	enrollments, err := bc.GetEnrollments()
+	assert.Nil(t, err)
+	assert.Equal(t, len(standby)+1, len(enrollments))
+
+	// Registered candidates with no votes are not elected.
+	validators, err := bc.GetValidators()
+	assert.Nil(t, err)
+	assert.False(t, containsKey(validators, candidate))
+
+	// Accounts with no governing token can't vote.
+	empty := newStateTX(newVoteDescriptor(t, util.Uint160{1, 2, 3}, candidate))
+	assert.NotNil(t, bc.verifyStateTX(empty.Data.(*transaction.StateTX)))
+
+	assert.Nil(t, bc.verifyStateTX(vote.Data.(*transaction.StateTX)))
+	validators, err = bc.GetValidators(vote)
+	assert.Nil(t, err)
+	assert.True(t, containsKey(validators, candidate))
+
+	assert.Nil(t, bc.persistBlock(newBlock(2, vote)))
+	state = bc.GetValidatorState(candidate)
+	if assert.NotNil(t, state) {
+		assert.Equal(t, amount, state.Votes)
+	}
+	account := bc.GetAccountState(owner)
+	if assert.NotNil(t, account) {
+		assert.Equal(t, []*crypto.PublicKey{candidate}, account.Votes)
+	}
+	count, err := getValidatorsCount(bc.Store)
+	assert.Nil(t, err)
+	assert.Equal(t, amount, count[0])
+
+	// The candidate replaces the last standby validator of the
+	// configuration.
+	validators, err = bc.GetValidators()
+	assert.Nil(t, err)
+	standbyKeys, err := getValidators(bc.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Equal(t, len(standby), len(validators)) {
+		assert.True(t, containsKey(validators, candidate))
+		for _, key := range standbyKeys[:len(standbyKeys)-1] {
+			assert.True(t, containsKey(validators, key))
+		}
+	}
+
+	// Spending the governing token takes the votes away.
+	spend := &transaction.Transaction{
+		Type:       transaction.ContractType,
+		Data:       &transaction.ContractTX{},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{{PrevHash: issueTX.Hash(), PrevIndex: 0}},
+		Outputs:    []*transaction.Output{transaction.NewOutput(neo, amount, util.Uint160{1, 2, 3})},
+	}
+	assert.Nil(t, bc.persistBlock(newBlock(3, spend)))
+	state = bc.GetValidatorState(candidate)
+	if assert.NotNil(t, state) {
+		assert.Equal(t, util.Fixed8(0), state.Votes)
+	}
+	validators, err = bc.GetValidators()
+	assert.Nil(t, err)
+	assert.False(t, containsKey(validators, candidate))
+}
+
+func TestValidatorsNumber(t *testing.T) {
+	count := &ValidatorsCount{}
+	assert.Equal(t, 0, validatorsNumber(count))
+
+	count[6] = util.NewFixed8(100)
+	assert.Equal(t, 6, validatorsNumber(count))
+
+	// The lowest and the highest quarters are filtered out.
+	count[0] = util.NewFixed8(50)
+	count[6] = util.NewFixed8(100)
+	count[20] = util.NewFixed8(50)
+	assert.Equal(t, 6, validatorsNumber(count))
+
+	count[20] = util.NewFixed8(150)
+	assert.Equal(t, 13, validatorsNumber(count))
+}
+
+func TestValidatorStateEncodeDecode(t *testing.T) {
+	state := &ValidatorState{
+		PublicKey:  getValidatorKeys(t)[0].pub,
+		Registered: true,
+		Votes:      util.NewFixed8(42),
+	}
+	buf := new(bytes.Buffer)
+	assert.Nil(t, state.EncodeBinary(buf))
+	decoded := &ValidatorState{}
+	assert.Nil(t, decoded.DecodeBinary(buf))
+	assert.Equal(t, state.PublicKey.Bytes(), decoded.PublicKey.Bytes())
+	assert.Equal(t, state.Registered, decoded.Registered)
+	assert.Equal(t, state.Votes, decoded.Votes)
+}
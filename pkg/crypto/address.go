@@ -1,6 +1,8 @@
 package crypto
 
 import (
+	"errors"
+
 	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
@@ -19,5 +21,8 @@ func Uint160DecodeAddress(s string) (u util.Uint160, err error) {
 	if err != nil {
 		return u, err
 	}
+	if len(b) != 21 || b[0] != 0x17 {
+		return u, errors.New("invalid address")
+	}
 	return util.Uint160DecodeBytes(b[1:21])
 }
@@ -20,3 +20,13 @@ func TestUint160DecodeAddress(t *testing.T) {
 		assert.Equal(t, addr, AddressFromUint160(val))
 	}
 }
+
+func TestUint160DecodeInvalidAddress(t *testing.T) {
+	// Wrong checksum.
+	_, err := Uint160DecodeAddress("AMLr1CpPQtbEdiJdriX1HpRNMZUwbU2Huk")
+	assert.NotNil(t, err)
+
+	// Valid base58check, but not an address.
+	_, err = Uint160DecodeAddress(Base58CheckEncode([]byte{0x17, 1, 2, 3}))
+	assert.NotNil(t, err)
+}
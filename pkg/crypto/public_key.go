@@ -2,8 +2,11 @@ package crypto
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math/big"
@@ -15,16 +18,9 @@ type PublicKeys []*PublicKey
 func (keys PublicKeys) Len() int      { return len(keys) }
 func (keys PublicKeys) Swap(i, j int) { keys[i], keys[j] = keys[j], keys[i] }
 func (keys PublicKeys) Less(i, j int) bool {
-	if keys[i].X.Cmp(keys[j].X) == -1 {
-		return true
+	if cmp := keys[i].X.Cmp(keys[j].X); cmp != 0 {
+		return cmp == -1
 	}
-	if keys[i].X.Cmp(keys[j].X) == 1 {
-		return false
-	}
-	if keys[i].X.Cmp(keys[j].X) == 0 {
-		return false
-	}
-
 	return keys[i].Y.Cmp(keys[j].Y) == -1
 }
 
@@ -69,6 +65,29 @@ func (p *PublicKey) Bytes() []byte {
 	return append([]byte{prefix}, paddedX...)
 }
 
+// MarshalJSON implements the json marshaller interface.
+func (p *PublicKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(p.Bytes()))
+}
+
+// UnmarshalJSON implements the json unmarshaller interface.
+func (p *PublicKey) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return p.DecodeBytes(b)
+}
+
+// DecodeBytes decodes a PublicKey from the given slice of bytes.
+func (p *PublicKey) DecodeBytes(data []byte) error {
+	return p.DecodeBinary(bytes.NewReader(data))
+}
+
 // DecodeBinary decodes a PublicKey from the given io.Reader.
 func (p *PublicKey) DecodeBinary(r io.Reader) error {
 	var prefix uint8
@@ -113,3 +132,18 @@ func (p *PublicKey) DecodeBinary(r io.Reader) error {
 func (p *PublicKey) EncodeBinary(w io.Writer) error {
 	return binary.Write(w, binary.LittleEndian, p.Bytes())
 }
+
+// Verify returns true if the given signature is a valid ECDSA signature
+// of the given hash made with the private key coupled with p. The signature
+// is expected to be 64 bytes long, the concatenation of r and s.
+func (p *PublicKey) Verify(signature []byte, hash []byte) bool {
+	if p.X == nil || p.Y == nil || len(signature) != 64 {
+		return false
+	}
+	var (
+		publicKey = &ecdsa.PublicKey{Curve: elliptic.P256(), X: p.X, Y: p.Y}
+		r         = new(big.Int).SetBytes(signature[0:32])
+		s         = new(big.Int).SetBytes(signature[32:64])
+	)
+	return ecdsa.Verify(publicKey, hash, r, s)
+}
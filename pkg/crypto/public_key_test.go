@@ -3,6 +3,7 @@ package crypto
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,3 +40,18 @@ func TestDecodeFromString(t *testing.T) {
 	}
 	assert.Equal(t, str, hex.EncodeToString(pubKey.Bytes()))
 }
+
+func TestPublicKeyMarshalUnmarshalJSON(t *testing.T) {
+	str := "03b209fd4f53a7170ea4444e0cb0a6bb6a53c2bd016926989cf85f9b0fba17a70c"
+	pubKey, err := NewPublicKeyFromString(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(pubKey)
+	assert.Nil(t, err)
+	assert.Equal(t, `"`+str+`"`, string(data))
+
+	actual := &PublicKey{}
+	assert.Nil(t, json.Unmarshal(data, actual))
+	assert.Equal(t, pubKey.Bytes(), actual.Bytes())
+}
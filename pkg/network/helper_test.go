@@ -4,44 +4,11 @@ import (
 	"testing"
 	"time"
 
-	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/core/coretest"
 	"github.com/CityOfZion/neo-go/pkg/network/payload"
 	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
-type testChain struct{}
-
-func (chain testChain) AddHeaders(...*core.Header) error {
-	return nil
-}
-func (chain testChain) AddBlock(*core.Block) error {
-	return nil
-}
-func (chain testChain) BlockHeight() uint32 {
-	return 0
-}
-func (chain testChain) HeaderHeight() uint32 {
-	return 0
-}
-func (chain testChain) GetBlock(hash util.Uint256) (*core.Block, error) {
-	return nil, nil
-}
-func (chain testChain) GetHeaderHash(int) util.Uint256 {
-	return util.Uint256{}
-}
-func (chain testChain) CurrentHeaderHash() util.Uint256 {
-	return util.Uint256{}
-}
-func (chain testChain) CurrentBlockHash() util.Uint256 {
-	return util.Uint256{}
-}
-func (chain testChain) HasBlock(util.Uint256) bool {
-	return false
-}
-func (chain testChain) HasTransaction(util.Uint256) bool {
-	return false
-}
-
 type testDiscovery struct{}
 
 func (d testDiscovery) BackFill(addrs ...string)   {}
@@ -99,7 +66,7 @@ func (p *localPeer) SetVersion(v *payload.Version) {
 func newTestServer() *Server {
 	return &Server{
 		ServerConfig: ServerConfig{},
-		chain:        testChain{},
+		chain:        coretest.NewChain(),
 		transport:    localTransport{},
 		discovery:    testDiscovery{},
 		id:           util.RandUint32(1000000, 9999999),
@@ -58,6 +58,7 @@ const (
 	CMDGetBlocks   CommandType = "getblocks"
 	CMDInv         CommandType = "inv"
 	CMDGetData     CommandType = "getdata"
+	CMDNotFound    CommandType = "notfound"
 	CMDBlock       CommandType = "block"
 	CMDTX          CommandType = "tx"
 	CMDConsensus   CommandType = "consensus"
@@ -117,6 +118,8 @@ func (m *Message) CommandType() CommandType {
 		return CMDInv
 	case "getdata":
 		return CMDGetData
+	case "notfound":
+		return CMDNotFound
 	case "block":
 		return CMDBlock
 	case "tx":
@@ -185,6 +188,16 @@ func (m *Message) decodePayload(r io.Reader) error {
 		if err := p.DecodeBinary(buf); err != nil {
 			return err
 		}
+	case CMDGetData:
+		p = &payload.Inventory{}
+		if err := p.DecodeBinary(buf); err != nil {
+			return err
+		}
+	case CMDNotFound:
+		p = &payload.Inventory{}
+		if err := p.DecodeBinary(buf); err != nil {
+			return err
+		}
 	case CMDAddr:
 		p = &payload.AddressList{}
 		if err := p.DecodeBinary(buf); err != nil {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
 	"github.com/CityOfZion/neo-go/pkg/network/payload"
 	"github.com/CityOfZion/neo-go/pkg/util"
 	log "github.com/sirupsen/logrus"
@@ -56,7 +57,7 @@ type (
 )
 
 // NewServer returns a new Server, initialized with the given configuration.
-func NewServer(config ServerConfig, chain *core.Blockchain) *Server {
+func NewServer(config ServerConfig, chain core.Blockchainer) *Server {
 	s := &Server{
 		ServerConfig: config,
 		chain:        chain,
@@ -123,19 +124,25 @@ func (s *Server) run() {
 		select {
 		case <-s.quit:
 			s.transport.Close()
+			s.lock.RLock()
 			for p := range s.peers {
 				p.Disconnect(errServerShutdown)
 			}
+			s.lock.RUnlock()
 			return
 		case p := <-s.register:
 			// When a new peer is connected we send out our version immediately.
 			s.sendVersion(p)
+			s.lock.Lock()
 			s.peers[p] = true
+			s.lock.Unlock()
 			log.WithFields(log.Fields{
 				"endpoint": p.Endpoint(),
 			}).Info("new peer connected")
 		case drop := <-s.unregister:
+			s.lock.Lock()
 			delete(s.peers, drop.peer)
+			s.lock.Unlock()
 			log.WithFields(log.Fields{
 				"endpoint":  drop.peer.Endpoint(),
 				"reason":    drop.reason,
@@ -236,16 +243,130 @@ func (s *Server) handleBlockCmd(p Peer, block *core.Block) error {
 	return nil
 }
 
+// handleTxCmd adds the received transaction to the memory pool and relays
+// it to the other peers if relaying is enabled.
+func (s *Server) handleTxCmd(p Peer, tx *transaction.Transaction) error {
+	// An invalid transaction is not a reason to drop the peer, it could
+	// have been valid when the peer received it.
+	if err := s.chain.PoolTx(tx); err != nil {
+		log.WithFields(log.Fields{
+			"endpoint": p.Endpoint(),
+			"hash":     tx.Hash(),
+		}).Debugf("transaction not added to the memory pool: %s", err)
+		return nil
+	}
+	if s.Relay {
+		s.relayInventory(payload.TXType, tx.Hash())
+	}
+	return nil
+}
+
 // handleInvCmd will process the received inventory.
 func (s *Server) handleInvCmd(p Peer, inv *payload.Inventory) error {
 	if !inv.Type.Valid() || len(inv.Hashes) == 0 {
 		return errInvalidInvType
 	}
-	payload := payload.NewInventory(inv.Type, inv.Hashes)
+	hashes := inv.Hashes
+	if inv.Type == payload.TXType {
+		// Only ask for the transactions we don't know yet.
+		hashes = make([]util.Uint256, 0, len(inv.Hashes))
+		for _, hash := range inv.Hashes {
+			if !s.chain.GetMemPool().ContainsKey(hash) && !s.chain.HasTransaction(hash) {
+				hashes = append(hashes, hash)
+			}
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+	}
+	payload := payload.NewInventory(inv.Type, hashes)
 	p.WriteMsg(NewMessage(s.Net, CMDGetData, payload))
 	return nil
 }
 
+// handleGetDataCmd sends the requested transactions and blocks to the peer.
+// The hashes that are unknown are sent back in a notfound message.
+func (s *Server) handleGetDataCmd(p Peer, inv *payload.Inventory) error {
+	if !inv.Type.Valid() {
+		return errInvalidInvType
+	}
+	var notFound []util.Uint256
+	for _, hash := range inv.Hashes {
+		var msg *Message
+		switch inv.Type {
+		case payload.TXType:
+			if tx, ok := s.chain.GetMemPool().TryGetValue(hash); ok {
+				msg = NewMessage(s.Net, CMDTX, tx)
+			} else if tx, _, err := s.chain.GetTransaction(hash); err == nil {
+				msg = NewMessage(s.Net, CMDTX, tx)
+			}
+		case payload.BlockType:
+			if block, err := s.getFullBlock(hash); err == nil {
+				msg = NewMessage(s.Net, CMDBlock, block)
+			}
+		}
+		// Consensus payloads aren't relayed, so they are never known.
+		if msg == nil {
+			notFound = append(notFound, hash)
+			continue
+		}
+		if err := p.WriteMsg(msg); err != nil {
+			return err
+		}
+	}
+	if len(notFound) > 0 {
+		return p.WriteMsg(NewMessage(s.Net, CMDNotFound, payload.NewInventory(inv.Type, notFound)))
+	}
+	return nil
+}
+
+// getFullBlock returns the persisted block with the given hash together
+// with its transactions, the stored blocks only holding their hashes.
+func (s *Server) getFullBlock(hash util.Uint256) (*core.Block, error) {
+	if !s.chain.HasBlock(hash) {
+		return nil, fmt.Errorf("unknown block %s", hash)
+	}
+	block, err := s.chain.GetBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	full := *block
+	full.Transactions = make([]*transaction.Transaction, len(block.Transactions))
+	for i, t := range block.Transactions {
+		tx, _, err := s.chain.GetTransaction(t.Hash())
+		if err != nil {
+			return nil, err
+		}
+		full.Transactions[i] = tx
+	}
+	full.Trimmed = false
+	return &full, nil
+}
+
+// RelayTxn adds the given transaction to the memory pool and announces it
+// to the connected peers.
+func (s *Server) RelayTxn(tx *transaction.Transaction) error {
+	if err := s.chain.PoolTx(tx); err != nil {
+		return err
+	}
+	s.relayInventory(payload.TXType, tx.Hash())
+	return nil
+}
+
+// relayInventory announces the given hashes to all the connected peers.
+func (s *Server) relayInventory(t payload.InventoryType, hashes ...util.Uint256) {
+	msg := NewMessage(s.Net, CMDInv, payload.NewInventory(t, hashes))
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for p := range s.peers {
+		if err := p.WriteMsg(msg); err != nil {
+			log.WithFields(log.Fields{
+				"endpoint": p.Endpoint(),
+			}).Warnf("failed to relay inventory: %s", err)
+		}
+	}
+}
+
 // requestHeaders will send a getheaders message to the peer.
 // The peer will respond with headers op to a count of 2000.
 func (s *Server) requestHeaders(p Peer) {
@@ -294,6 +415,12 @@ func (s *Server) handleMessage(peer Peer, msg *Message) error {
 	case CMDInv:
 		inventory := msg.Payload.(*payload.Inventory)
 		return s.handleInvCmd(peer, inventory)
+	case CMDGetData:
+		inventory := msg.Payload.(*payload.Inventory)
+		return s.handleGetDataCmd(peer, inventory)
+	case CMDTX:
+		tx := msg.Payload.(*transaction.Transaction)
+		return s.handleTxCmd(peer, tx)
 	case CMDBlock:
 		block := msg.Payload.(*core.Block)
 		return s.handleBlockCmd(peer, block)
@@ -3,6 +3,8 @@ package network
 import (
 	"testing"
 
+	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
 	"github.com/CityOfZion/neo-go/pkg/network/payload"
 	"github.com/CityOfZion/neo-go/pkg/util"
 	"github.com/stretchr/testify/assert"
@@ -87,3 +89,122 @@ func TestRequestHeaders(t *testing.T) {
 	}
 	s.requestHeaders(p)
 }
+
+func TestHandleTxCmd(t *testing.T) {
+	var (
+		s  = newTestServer()
+		p  = newLocalPeer(t)
+		tx = transaction.NewInvocationTX([]byte{0x51})
+	)
+	s.Relay = true
+	s.peers[p] = true
+
+	relayed := false
+	p.messageHandler = func(t *testing.T, msg *Message) {
+		assert.Equal(t, CMDInv, msg.CommandType())
+		inv := msg.Payload.(*payload.Inventory)
+		assert.Equal(t, payload.TXType, inv.Type)
+		assert.Equal(t, []util.Uint256{tx.Hash()}, inv.Hashes)
+		relayed = true
+	}
+	assert.Nil(t, s.handleTxCmd(p, tx))
+	assert.True(t, relayed)
+	assert.True(t, s.chain.GetMemPool().ContainsKey(tx.Hash()))
+
+	// A transaction that is already pooled is not relayed again and
+	// doesn't disconnect the peer.
+	relayed = false
+	assert.Nil(t, s.handleTxCmd(p, tx))
+	assert.False(t, relayed)
+}
+
+func TestHandleTxCmdNoRelay(t *testing.T) {
+	var (
+		s  = newTestServer()
+		p  = newLocalPeer(t)
+		tx = transaction.NewInvocationTX([]byte{0x51})
+	)
+	s.peers[p] = true
+
+	p.messageHandler = func(t *testing.T, msg *Message) {
+		t.Fatalf("unexpected message %s", msg.CommandType())
+	}
+	assert.Nil(t, s.handleTxCmd(p, tx))
+	assert.True(t, s.chain.GetMemPool().ContainsKey(tx.Hash()))
+}
+
+func TestHandleInvCmdFiltersKnownTx(t *testing.T) {
+	var (
+		s     = newTestServer()
+		p     = newLocalPeer(t)
+		known = transaction.NewInvocationTX([]byte{0x51})
+		other = util.Uint256{1, 2, 3}
+	)
+	assert.Nil(t, s.chain.PoolTx(known))
+
+	p.messageHandler = func(t *testing.T, msg *Message) {
+		assert.Equal(t, CMDGetData, msg.CommandType())
+		inv := msg.Payload.(*payload.Inventory)
+		assert.Equal(t, []util.Uint256{other}, inv.Hashes)
+	}
+	inv := payload.NewInventory(payload.TXType, []util.Uint256{known.Hash(), other})
+	assert.Nil(t, s.handleInvCmd(p, inv))
+}
+
+func TestHandleGetDataCmd(t *testing.T) {
+	var (
+		s       = newTestServer()
+		p       = newLocalPeer(t)
+		tx      = transaction.NewInvocationTX([]byte{0x51})
+		unknown = util.Uint256{1, 2, 3}
+	)
+	assert.Nil(t, s.chain.PoolTx(tx))
+
+	var sent []CommandType
+	p.messageHandler = func(t *testing.T, msg *Message) {
+		sent = append(sent, msg.CommandType())
+		switch msg.CommandType() {
+		case CMDTX:
+			assert.Equal(t, tx.Hash(), msg.Payload.(*transaction.Transaction).Hash())
+		case CMDNotFound:
+			inv := msg.Payload.(*payload.Inventory)
+			assert.Equal(t, payload.TXType, inv.Type)
+			assert.Equal(t, []util.Uint256{unknown}, inv.Hashes)
+		}
+	}
+	inv := payload.NewInventory(payload.TXType, []util.Uint256{tx.Hash(), unknown})
+	assert.Nil(t, s.handleGetDataCmd(p, inv))
+	assert.Equal(t, []CommandType{CMDTX, CMDNotFound}, sent)
+}
+
+func TestHandleGetDataCmdBlocks(t *testing.T) {
+	var (
+		s     = newTestServer()
+		p     = newLocalPeer(t)
+		tx    = transaction.NewInvocationTX([]byte{0x51})
+		block = &core.Block{
+			BlockBase:    core.BlockBase{Script: &transaction.Witness{}},
+			Transactions: []*transaction.Transaction{tx},
+		}
+		unknown = util.Uint256{1, 2, 3}
+	)
+	assert.Nil(t, s.chain.AddBlock(block))
+
+	var sent []CommandType
+	p.messageHandler = func(t *testing.T, msg *Message) {
+		sent = append(sent, msg.CommandType())
+		switch msg.CommandType() {
+		case CMDBlock:
+			b := msg.Payload.(*core.Block)
+			assert.Equal(t, block.Hash(), b.Hash())
+			assert.Equal(t, []*transaction.Transaction{tx}, b.Transactions)
+		case CMDNotFound:
+			inv := msg.Payload.(*payload.Inventory)
+			assert.Equal(t, payload.BlockType, inv.Type)
+			assert.Equal(t, []util.Uint256{unknown}, inv.Hashes)
+		}
+	}
+	inv := payload.NewInventory(payload.BlockType, []util.Uint256{block.Hash(), unknown})
+	assert.Nil(t, s.handleGetDataCmd(p, inv))
+	assert.Equal(t, []CommandType{CMDBlock, CMDNotFound}, sent)
+}
@@ -82,6 +82,8 @@ func NewClient(ctx context.Context, endpoint string, opts ClientOptions) (*Clien
 	}, nil
 }
 
+// performRequest calls the given method and decodes the result of the
+// response into v. The JSON-RPC errors are returned as *Error.
 func (c *Client) performRequest(method string, p params, v interface{}) error {
 	r := request{
 		JSONRPC: c.version,
@@ -104,11 +106,26 @@ func (c *Client) performRequest(method string, p params, v interface{}) error {
 	}
 	defer resp.Body.Close()
 
+	// The server reports the JSON-RPC errors with non 200 responses, so the
+	// body is decoded first to get the error it holds.
+	res := &response{}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("Remote responded with a non 200 response: %d", resp.StatusCode)
+		}
+		return err
+	}
+	if res.Error != nil {
+		return res.Error
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("Remote responded with a non 200 response: %d", resp.StatusCode)
 	}
-
-	return json.NewDecoder(resp.Body).Decode(v)
+	// A null result is omitted by the server.
+	if v == nil || len(res.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(res.Result, v)
 }
 
 // Ping attempts to create a connection to the endpoint.
@@ -0,0 +1,156 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T) (*Client, *core.Blockchain, func()) {
+	s, chain := newTestServer(t)
+	srv := httptest.NewServer(http.HandlerFunc(s.requestHandler))
+	c, err := NewClient(context.TODO(), srv.URL, ClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, chain, srv.Close
+}
+
+func TestClientGetBlock(t *testing.T) {
+	c, chain, closer := newTestClient(t)
+	defer closer()
+	genesis := getGenesisBlock(t, chain)
+
+	count, err := c.GetBlockCount()
+	assert.Nil(t, err)
+	assert.Equal(t, chain.BlockHeight(), count)
+
+	hash, err := c.GetBestBlockHash()
+	assert.Nil(t, err)
+	assert.Equal(t, genesis.Hash(), hash)
+
+	hash, err = c.GetBlockHash(0)
+	assert.Nil(t, err)
+	assert.Equal(t, genesis.Hash(), hash)
+
+	block, err := c.GetBlockByIndex(0)
+	assert.Nil(t, err)
+	assert.Equal(t, genesis.Hash(), block.Hash())
+	assert.Equal(t, len(genesis.Transactions), len(block.Transactions))
+	for i, tx := range genesis.Transactions {
+		assert.Equal(t, tx.Hash(), block.Transactions[i].Hash())
+	}
+
+	block, err = c.GetBlockByHash(genesis.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, genesis.Hash(), block.Hash())
+
+	_, err = c.GetBlockByIndex(1)
+	assert.NotNil(t, err)
+
+	fee, err := c.GetBlockSysFee(0)
+	assert.Nil(t, err)
+	assert.Equal(t, util.Fixed8(0), fee)
+}
+
+func TestClientGetStates(t *testing.T) {
+	c, chain, closer := newTestClient(t)
+	defer closer()
+	issueTX := getGenesisIssueTX(t, chain)
+	out := issueTX.Outputs[0]
+
+	account, err := c.GetAccountState(crypto.AddressFromUint160(out.ScriptHash))
+	assert.Nil(t, err)
+	assert.Equal(t, out.ScriptHash, account.ScriptHash)
+	assert.Equal(t, out.Amount, account.Balances[out.AssetID])
+
+	asset, err := c.GetAssetState(out.AssetID)
+	assert.Nil(t, err)
+	assert.Equal(t, chain.GetAssetState(out.AssetID), asset)
+
+	_, err = c.GetAssetState(util.Uint256{1, 2, 3})
+	assert.NotNil(t, err)
+	rpcErr, ok := err.(*Error)
+	assert.True(t, ok)
+	assert.Equal(t, int64(-100), rpcErr.Code)
+
+	_, err = c.GetContractState(util.Uint160{1, 2, 3})
+	assert.NotNil(t, err)
+
+	value, err := c.GetStorage(util.Uint160{1, 2, 3}, []byte{1})
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+
+	valid, err := c.ValidateAddress(crypto.AddressFromUint160(out.ScriptHash))
+	assert.Nil(t, err)
+	assert.True(t, valid.IsValid)
+}
+
+func TestClientGetRawTransaction(t *testing.T) {
+	c, chain, closer := newTestClient(t)
+	defer closer()
+	issueTX := getGenesisIssueTX(t, chain)
+
+	tx, err := c.GetRawTransaction(issueTX.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, issueTX.Hash(), tx.Hash())
+	assert.Equal(t, issueTX.Outputs, tx.Outputs)
+
+	raw, err := c.GetRawTransactionVerbose(issueTX.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, issueTX.Hash(), raw.TxHash)
+	assert.Equal(t, transaction.IssueType, raw.Type)
+	assert.Equal(t, issueTX.Scripts, raw.Scripts)
+	assert.Equal(t, chain.GetHeaderHash(0), *raw.Blockhash)
+	assert.Equal(t, len(issueTX.Outputs), len(raw.Outputs))
+
+	out, err := c.GetTxOut(issueTX.Hash(), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, issueTX.Outputs[0], out)
+
+	out, err = c.GetTxOut(issueTX.Hash(), len(issueTX.Outputs))
+	assert.Nil(t, err)
+	assert.Nil(t, out)
+
+	hashes, err := c.GetRawMemPool()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(hashes))
+}
+
+func TestClientInvoke(t *testing.T) {
+	c, _, closer := newTestClient(t)
+	defer closer()
+
+	// PUSH2 PUSH3 ADD
+	res, err := c.InvokeScript([]byte{0x52, 0x53, 0x93})
+	assert.Nil(t, err)
+	assert.Equal(t, "HALT", res.State)
+	assert.Equal(t, 1, len(res.Stack))
+	assert.Equal(t, "5", res.Stack[0].Value)
+
+	res, err = c.InvokeFunction(util.Uint160{1, 2, 3}, "name", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "FAULT", res.State)
+}
+
+func TestClientSendRawTransaction(t *testing.T) {
+	c, chain, closer := newTestClient(t)
+	defer closer()
+
+	// Already in the chain.
+	err := c.SendRawTransaction(getGenesisIssueTX(t, chain))
+	assert.NotNil(t, err)
+	rpcErr, ok := err.(*Error)
+	assert.True(t, ok)
+	assert.Equal(t, int64(-501), rpcErr.Code)
+
+	err = c.SubmitBlock(getGenesisBlock(t, chain))
+	assert.NotNil(t, err)
+}
@@ -5,9 +5,9 @@ Package rpc provides interaction with a NEO node over JSON-RPC.
 After creating a client instance with or without a ClientConfig
 you can interact with the NEO blockchain by its exposed methods.
 
-Some of the methods also allow to pass a verbose bool. This will
-return a more pretty printed response from the server instead of
-a raw hex string.
+The methods return the types of the core package when the node
+sends them encoded, GetBlockByIndex returns a *core.Block for
+example. The errors returned by the node are of type *Error.
 
 An example:
   endpoint := "http://seed5.bridgeprotocol.io:10332"
@@ -22,12 +22,12 @@ An example:
 	  log.Fatal(err)
   }
 
-  resp, err := client.GetAccountState("ATySFJAbLW7QHsZGHScLhxq6EyNBxx3eFP")
+  account, err := client.GetAccountState("ATySFJAbLW7QHsZGHScLhxq6EyNBxx3eFP")
   if err != nil {
 	  log.Fatal(err)
   }
-  log.Println(resp.Result.ScriptHash)
-  log.Println(resp.Result.Balances)
+  log.Println(account.ScriptHash)
+  log.Println(account.Balances)
 
 To be continued with more in depth examples.
 */
@@ -62,3 +62,16 @@ func NewInternalServerError(data string, cause error) *Error {
 func (e Error) Error() string {
 	return fmt.Sprintf("%s (%d) - %s - %s", e.Message, e.Code, e.Data, e.Cause)
 }
+
+// NewRPCError creates a new error with
+// code -100.
+func NewRPCError(message string, data string, cause error) *Error {
+	return newError(-100, http.StatusUnprocessableEntity, message, data, cause)
+}
+
+// NewSubmitError creates a new error with
+// the given code, used to report why a block or
+// a transaction was not accepted.
+func NewSubmitError(code int64, message string) *Error {
+	return newError(code, http.StatusUnprocessableEntity, message, "", nil)
+}
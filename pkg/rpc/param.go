@@ -1,7 +1,15 @@
 package rpc
 
 import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
 type (
@@ -16,6 +24,63 @@ type (
 	}
 )
 
+var errNotAString = errors.New("param is not a string")
+
 func (p Param) String() string {
 	return fmt.Sprintf("%v", p.RawValue)
 }
+
+// GetBytesHex returns the bytes encoded in the hex string param.
+func (p Param) GetBytesHex() ([]byte, error) {
+	if p.Type != "string" {
+		return nil, errNotAString
+	}
+	return hex.DecodeString(p.StringVal)
+}
+
+// GetUint256 returns the Uint256 encoded in the string param, optionally
+// prefixed by 0x.
+func (p Param) GetUint256() (util.Uint256, error) {
+	if p.Type != "string" {
+		return util.Uint256{}, errNotAString
+	}
+	return util.Uint256DecodeString(strings.TrimPrefix(p.StringVal, "0x"))
+}
+
+// GetUint160FromHex returns the Uint160 encoded in the string param the way
+// script hashes are displayed, optionally prefixed by 0x.
+func (p Param) GetUint160FromHex() (util.Uint160, error) {
+	if p.Type != "string" {
+		return util.Uint160{}, errNotAString
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(p.StringVal, "0x"))
+	if err != nil {
+		return util.Uint160{}, err
+	}
+	return util.Uint160DecodeBytes(util.ArrayReverse(b))
+}
+
+// GetUint160FromAddress returns the Uint160 of the NEO address in the
+// string param.
+func (p Param) GetUint160FromAddress() (util.Uint160, error) {
+	if p.Type != "string" {
+		return util.Uint160{}, errNotAString
+	}
+	return crypto.Uint160DecodeAddress(p.StringVal)
+}
+
+// GetFuncParams returns the smart contract parameters of the array param.
+func (p Param) GetFuncParams() ([]smartcontract.Parameter, error) {
+	if p.Type != "array" {
+		return nil, errors.New("param is not an array")
+	}
+	b, err := json.Marshal(p.RawValue)
+	if err != nil {
+		return nil, err
+	}
+	var params []smartcontract.Parameter
+	if err := json.Unmarshal(b, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
@@ -33,6 +33,15 @@ func (p *Params) UnmarshalJSON(data []byte) error {
 			newVal, _ := params[i].(float64)
 			param.IntVal = int(newVal)
 			param.Type = "number"
+
+		case bool:
+			if val {
+				param.IntVal = 1
+			}
+			param.Type = "boolean"
+
+		case []interface{}:
+			param.Type = "array"
 		}
 
 		*p = append(*p, param)
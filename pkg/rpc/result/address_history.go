@@ -0,0 +1,22 @@
+package result
+
+import (
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+type (
+	// AddressHistory represents the result of the getaddresshistory call, a
+	// page of the transactions touching an address, newest first. Next is
+	// the cursor of the following page, empty if this one is the last.
+	AddressHistory struct {
+		Address      string               `json:"address"`
+		Transactions []AddressTransaction `json:"transactions"`
+		Next         string               `json:"next,omitempty"`
+	}
+
+	// AddressTransaction represents a transaction touching an address.
+	AddressTransaction struct {
+		TxID   util.Uint256 `json:"txid"`
+		Height uint32       `json:"height"`
+	}
+)
@@ -0,0 +1,36 @@
+package result
+
+import (
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+type (
+	// Claimable represents the result of the getclaimable call, the spent
+	// NEO outputs of an address whose GAS is not claimed yet.
+	Claimable struct {
+		Claimable []Claim     `json:"claimable"`
+		Address   string      `json:"address"`
+		Unclaimed util.Fixed8 `json:"unclaimed"`
+	}
+
+	// Claim represents a single claimable output.
+	Claim struct {
+		TxID        util.Uint256 `json:"txid"`
+		Index       uint16       `json:"n"`
+		Value       util.Fixed8  `json:"value"`
+		StartHeight uint32       `json:"start_height"`
+		EndHeight   uint32       `json:"end_height"`
+		Generated   util.Fixed8  `json:"generated"`
+		SysFee      util.Fixed8  `json:"sys_fee"`
+		Unclaimed   util.Fixed8  `json:"unclaimed"`
+	}
+
+	// Unclaimed represents the result of the getunclaimed call, the GAS
+	// of an address that can be claimed (available) and the one that will
+	// only be claimable once its NEO is spent (unavailable).
+	Unclaimed struct {
+		Available   util.Fixed8 `json:"available"`
+		Unavailable util.Fixed8 `json:"unavailable"`
+		Unclaimed   util.Fixed8 `json:"unclaimed"`
+	}
+)
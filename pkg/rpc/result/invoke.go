@@ -0,0 +1,16 @@
+package result
+
+import (
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+)
+
+type (
+	// Invoke represents the result of the invoke, invokefunction and
+	// invokescript calls.
+	Invoke struct {
+		State       string                    `json:"state"`
+		GasConsumed string                    `json:"gas_consumed"`
+		Script      string                    `json:"script"`
+		Stack       []smartcontract.Parameter `json:"stack"`
+	}
+)
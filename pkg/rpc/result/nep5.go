@@ -0,0 +1,41 @@
+package result
+
+import (
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+type (
+	// NEP5Balances represents the result of the getnep5balances call, the
+	// balances of an address in the NEP-5 tokens it ever received.
+	NEP5Balances struct {
+		Balances []NEP5Balance `json:"balance"`
+		Address  string        `json:"address"`
+	}
+
+	// NEP5Balance represents the balance of an address in a NEP-5 token.
+	NEP5Balance struct {
+		Asset       util.Uint160 `json:"asset_hash"`
+		Amount      string       `json:"amount"`
+		LastUpdated uint32       `json:"last_updated_block"`
+	}
+
+	// NEP5Transfers represents the result of the getnep5transfers call, the
+	// NEP-5 transfers sent and received by an address, newest first.
+	NEP5Transfers struct {
+		Sent     []NEP5Transfer `json:"sent"`
+		Received []NEP5Transfer `json:"received"`
+		Address  string         `json:"address"`
+	}
+
+	// NEP5Transfer represents a NEP-5 transfer, Address being the one of the
+	// other party, empty for mints and burns.
+	NEP5Transfer struct {
+		Timestamp   uint32       `json:"timestamp"`
+		Asset       util.Uint160 `json:"asset_hash"`
+		Address     string       `json:"transfer_address,omitempty"`
+		Amount      string       `json:"amount"`
+		Index       uint32       `json:"block_index"`
+		NotifyIndex uint16       `json:"transfer_notify_index"`
+		TxHash      util.Uint256 `json:"tx_hash"`
+	}
+)
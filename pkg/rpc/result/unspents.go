@@ -0,0 +1,30 @@
+package result
+
+import (
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+type (
+	// Unspents represents the result of the getunspents call, the unspent
+	// outputs of an address grouped by asset.
+	Unspents struct {
+		Balance []UnspentBalanceInfo `json:"balance"`
+		Address string               `json:"address"`
+	}
+
+	// UnspentBalanceInfo holds the unspent outputs of a single asset.
+	UnspentBalanceInfo struct {
+		Unspents    []Unspent    `json:"unspent"`
+		AssetHash   util.Uint256 `json:"asset_hash"`
+		Asset       string       `json:"asset"`
+		AssetSymbol string       `json:"asset_symbol"`
+		Amount      util.Fixed8  `json:"amount"`
+	}
+
+	// Unspent represents a single unspent output.
+	Unspent struct {
+		TxID  util.Uint256 `json:"txid"`
+		Index uint16       `json:"n"`
+		Value util.Fixed8  `json:"value"`
+	}
+)
@@ -0,0 +1,9 @@
+package result
+
+type (
+	// ValidateAddress represents the result of the validateaddress call.
+	ValidateAddress struct {
+		Address interface{} `json:"address"`
+		IsValid bool        `json:"isvalid"`
+	}
+)
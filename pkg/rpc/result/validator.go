@@ -0,0 +1,14 @@
+package result
+
+import (
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// Validator represents a validator candidate as returned by the
+// getvalidators call, active ones being the validators of the next block.
+type Validator struct {
+	PublicKey *crypto.PublicKey `json:"publickey"`
+	Votes     util.Fixed8       `json:"votes"`
+	Active    bool              `json:"active"`
+}
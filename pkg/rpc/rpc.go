@@ -1,55 +1,358 @@
 package rpc
 
-import "github.com/CityOfZion/neo-go/pkg/smartcontract"
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 
-// GetBlock returns a block by its hash or index/height. If verbose is true
-// the response will contain a pretty Block object instead of the raw hex string.
-func (c *Client) GetBlock(indexOrHash interface{}, verbose bool) (*response, error) {
-	var (
-		params = newParams(indexOrHash)
-		resp   = &response{}
-	)
-	if verbose {
-		params = newParams(indexOrHash, 1)
+	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/rpc/result"
+	"github.com/CityOfZion/neo-go/pkg/rpc/wrappers"
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// GetBestBlockHash returns the hash of the tallest block in the main chain.
+func (c *Client) GetBestBlockHash() (util.Uint256, error) {
+	var hash util.Uint256
+	if err := c.performRequest("getbestblockhash", newParams(), &hash); err != nil {
+		return util.Uint256{}, err
+	}
+	return hash, nil
+}
+
+// GetBlockCount returns the height of the main chain.
+func (c *Client) GetBlockCount() (uint32, error) {
+	var count uint32
+	if err := c.performRequest("getblockcount", newParams(), &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetBlockHash returns the hash of the block at the given index.
+func (c *Client) GetBlockHash(index uint32) (util.Uint256, error) {
+	var hash util.Uint256
+	if err := c.performRequest("getblockhash", newParams(index), &hash); err != nil {
+		return util.Uint256{}, err
+	}
+	return hash, nil
+}
+
+// GetBlockByIndex returns the block at the given index.
+func (c *Client) GetBlockByIndex(index uint32) (*core.Block, error) {
+	return c.getBlock(index)
+}
+
+// GetBlockByHash returns the block with the given hash.
+func (c *Client) GetBlockByHash(hash util.Uint256) (*core.Block, error) {
+	return c.getBlock(hash.String())
+}
+
+func (c *Client) getBlock(indexOrHash interface{}) (*core.Block, error) {
+	b, err := c.performHexRequest("getblock", newParams(indexOrHash))
+	if err != nil {
+		return nil, err
+	}
+	block := &core.Block{}
+	if err := block.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// GetBlockSysFee returns the system fees of all the blocks up to the given
+// index, inclusive.
+func (c *Client) GetBlockSysFee(index uint32) (util.Fixed8, error) {
+	var fee util.Fixed8
+	if err := c.performRequest("getblocksysfee", newParams(index), &fee); err != nil {
+		return 0, err
+	}
+	return fee, nil
+}
+
+// GetConnectionCount returns the number of peers the node is connected to.
+func (c *Client) GetConnectionCount() (int, error) {
+	var count int
+	if err := c.performRequest("getconnectioncount", newParams(), &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetPeers returns the peers known to the node.
+func (c *Client) GetPeers() (*result.Peers, error) {
+	resp := &result.Peers{}
+	if err := c.performRequest("getpeers", newParams(), resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetVersion returns the version information of the node.
+func (c *Client) GetVersion() (*result.Version, error) {
+	resp := &result.Version{}
+	if err := c.performRequest("getversion", newParams(), resp); err != nil {
+		return nil, err
 	}
-	if err := c.performRequest("getblock", params, resp); err != nil {
+	return resp, nil
+}
+
+// ValidateAddress verifies that the given address is a valid NEO address.
+func (c *Client) ValidateAddress(address string) (*result.ValidateAddress, error) {
+	resp := &result.ValidateAddress{}
+	if err := c.performRequest("validateaddress", newParams(address), resp); err != nil {
 		return nil, err
 	}
 	return resp, nil
 }
 
 // GetAccountState will return detailed information about a NEO account.
-func (c *Client) GetAccountState(address string) (*AccountStateResponse, error) {
-	var (
-		params = newParams(address)
-		resp   = &AccountStateResponse{}
-	)
-	if err := c.performRequest("getaccountstate", params, resp); err != nil {
+func (c *Client) GetAccountState(address string) (*core.AccountState, error) {
+	scriptHash, err := crypto.Uint160DecodeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	resp := &wrappers.AccountState{}
+	if err := c.performRequest("getaccountstate", newParams(address), resp); err != nil {
+		return nil, err
+	}
+
+	account := core.NewAccountState(scriptHash)
+	account.Version = resp.Version
+	account.IsFrozen = resp.IsFrozen
+	account.Votes = resp.Votes
+	for _, balance := range resp.Balances {
+		account.Balances[balance.Asset] = balance.Value
+	}
+	return account, nil
+}
+
+// GetAssetState returns the state of the asset with the given ID.
+func (c *Client) GetAssetState(id util.Uint256) (*core.AssetState, error) {
+	var resp struct {
+		wrappers.AssetState
+		Name json.RawMessage `json:"name"`
+	}
+	if err := c.performRequest("getassetstate", newParams(id.String()), &resp); err != nil {
+		return nil, err
+	}
+	admin, err := crypto.Uint160DecodeAddress(resp.Admin)
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := crypto.Uint160DecodeAddress(resp.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	// The names of the system assets are JSON lists of localized names,
+	// those are kept as is.
+	var name string
+	if err := json.Unmarshal(resp.Name, &name); err != nil {
+		name = string(resp.Name)
+	}
+
+	return &core.AssetState{
+		ID:         resp.ID,
+		AssetType:  resp.AssetType,
+		Name:       name,
+		Amount:     resp.Amount,
+		Available:  resp.Available,
+		Precision:  resp.Precision,
+		Owner:      resp.Owner,
+		Admin:      admin,
+		Issuer:     issuer,
+		Expiration: resp.Expiration,
+		IsFrozen:   resp.IsFrozen,
+	}, nil
+}
+
+// GetContractState returns the state of the contract with the given script
+// hash.
+func (c *Client) GetContractState(hash util.Uint160) (*core.ContractState, error) {
+	resp := &wrappers.ContractState{}
+	params := newParams(hex.EncodeToString(hash.BytesReverse()))
+	if err := c.performRequest("getcontractstate", params, resp); err != nil {
+		return nil, err
+	}
+	script, err := hex.DecodeString(resp.Script)
+	if err != nil {
+		return nil, err
+	}
+	return &core.ContractState{
+		Script:           script,
+		ParamList:        resp.ParamList,
+		ReturnType:       resp.ReturnType,
+		Name:             resp.Name,
+		CodeVersion:      resp.CodeVersion,
+		Author:           resp.Author,
+		Email:            resp.Email,
+		Description:      resp.Description,
+		HasStorage:       resp.Properties.HasStorage,
+		HasDynamicInvoke: resp.Properties.HasDynamicInvoke,
+	}, nil
+}
+
+// GetRawMemPool returns the hashes of the transactions in the memory pool
+// of the node.
+func (c *Client) GetRawMemPool() ([]util.Uint256, error) {
+	var hashes []util.Uint256
+	if err := c.performRequest("getrawmempool", newParams(), &hashes); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// GetRawTransaction returns the transaction with the given hash.
+func (c *Client) GetRawTransaction(hash util.Uint256) (*transaction.Transaction, error) {
+	b, err := c.performHexRequest("getrawtransaction", newParams(hash.String()))
+	if err != nil {
+		return nil, err
+	}
+	tx := &transaction.Transaction{}
+	if err := tx.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// GetRawTransactionVerbose returns the transaction with the given hash along
+// with the metadata the node keeps about it. Notice that the type specific
+// data of the transaction is not part of the verbose representation, use
+// GetRawTransaction to get it.
+func (c *Client) GetRawTransactionVerbose(hash util.Uint256) (*wrappers.TransactionOutputRaw, error) {
+	resp := &wrappers.TransactionOutputRaw{}
+	if err := c.performRequest("getrawtransaction", newParams(hash.String(), 1), resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetStorage returns the value stored by the contract with the given script
+// hash under the given key, or nil if there is no such value.
+func (c *Client) GetStorage(hash util.Uint160, key []byte) ([]byte, error) {
+	var value *string
+	params := newParams(hex.EncodeToString(hash.BytesReverse()), hex.EncodeToString(key))
+	if err := c.performRequest("getstorage", params, &value); err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return hex.DecodeString(*value)
+}
+
+// GetTxOut returns the output of the transaction with the given hash at the
+// given index, or nil if it's spent.
+func (c *Client) GetTxOut(hash util.Uint256, index int) (*transaction.Output, error) {
+	var out *transaction.Output
+	if err := c.performRequest("gettxout", newParams(hash.String(), index), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetClaimable returns the spent NEO outputs of the given address whose GAS
+// is not claimed yet.
+func (c *Client) GetClaimable(address string) (*result.Claimable, error) {
+	resp := &result.Claimable{}
+	if err := c.performRequest("getclaimable", newParams(address), resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetUnclaimed returns the claimable and the not yet claimable GAS of the
+// given address.
+func (c *Client) GetUnclaimed(address string) (*result.Unclaimed, error) {
+	resp := &result.Unclaimed{}
+	if err := c.performRequest("getunclaimed", newParams(address), resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetValidators returns the registered and the standby validators.
+func (c *Client) GetValidators() ([]result.Validator, error) {
+	var resp []result.Validator
+	if err := c.performRequest("getvalidators", newParams(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetAddressHistory returns a page of the transactions touching the given
+// address, newest first, starting at the given cursor or at the newest
+// transaction if it's empty.
+func (c *Client) GetAddressHistory(address string, count int, cursor string) (*result.AddressHistory, error) {
+	resp := &result.AddressHistory{}
+	if err := c.performRequest("getaddresshistory", newParams(address, count, cursor), resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetNEP5Balances returns the balances of the given address in the NEP-5
+// tokens it ever received.
+func (c *Client) GetNEP5Balances(address string) (*result.NEP5Balances, error) {
+	resp := &result.NEP5Balances{}
+	if err := c.performRequest("getnep5balances", newParams(address), resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetNEP5Transfers returns the NEP-5 transfers sent and received by the
+// given address between the given timestamps.
+func (c *Client) GetNEP5Transfers(address string, start, end uint32) (*result.NEP5Transfers, error) {
+	resp := &result.NEP5Transfers{}
+	if err := c.performRequest("getnep5transfers", newParams(address, start, end), resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetUnspents returns the unspent outputs of the given address.
+func (c *Client) GetUnspents(address string) (*result.Unspents, error) {
+	resp := &result.Unspents{}
+	if err := c.performRequest("getunspents", newParams(address), resp); err != nil {
 		return nil, err
 	}
 	return resp, nil
 }
 
-// InvokeScipt returns the result of the given script after running it true the VM.
-// NOTE: This is a test invoke and will not affect the blokchain.
-func (c *Client) InvokeScript(script string) (*InvokeScriptResponse, error) {
+// Invoke returns the results after calling the smart contract with the
+// given script hash and parameters.
+// NOTE: this is a test invoke and will not affect the blockchain.
+func (c *Client) Invoke(contract util.Uint160, params []smartcontract.Parameter) (*result.Invoke, error) {
+	if params == nil {
+		params = []smartcontract.Parameter{}
+	}
 	var (
-		params = newParams(script)
-		resp   = &InvokeScriptResponse{}
+		p    = newParams(hex.EncodeToString(contract.BytesReverse()), params)
+		resp = &result.Invoke{}
 	)
-	if err := c.performRequest("invokescript", params, resp); err != nil {
+	if err := c.performRequest("invoke", p, resp); err != nil {
 		return nil, err
 	}
 	return resp, nil
 }
 
-// InvokeFunction return the results after calling a the smart contract scripthash
-// with the given operation and parameters.
-// NOTE: this is test invoke and will not affect the blockchain.
-func (c *Client) InvokeFunction(script, operation string, params []smartcontract.Parameter) (*InvokeScriptResponse, error) {
+// InvokeFunction returns the results after calling the smart contract with
+// the given script hash, operation and parameters.
+// NOTE: this is a test invoke and will not affect the blockchain.
+func (c *Client) InvokeFunction(contract util.Uint160, operation string, params []smartcontract.Parameter) (*result.Invoke, error) {
+	// The parameters are always sent as an array, even if there are none.
+	if params == nil {
+		params = []smartcontract.Parameter{}
+	}
 	var (
-		p    = newParams(script, operation, params)
-		resp = &InvokeScriptResponse{}
+		p    = newParams(hex.EncodeToString(contract.BytesReverse()), operation, params)
+		resp = &result.Invoke{}
 	)
 	if err := c.performRequest("invokefunction", p, resp); err != nil {
 		return nil, err
@@ -57,29 +360,57 @@ func (c *Client) InvokeFunction(script, operation string, params []smartcontract
 	return resp, nil
 }
 
-// GetRawTransaction queries a transaction by hash.
-func (c *Client) GetRawTransaction(hash string, verbose bool) (*response, error) {
-	var (
-		params = newParams(hash, verbose)
-		resp   = &response{}
-	)
-	if err := c.performRequest("getrawtransaction", params, resp); err != nil {
+// InvokeScript returns the result of the given script after running it
+// through the VM.
+// NOTE: this is a test invoke and will not affect the blockchain.
+func (c *Client) InvokeScript(script []byte) (*result.Invoke, error) {
+	resp := &result.Invoke{}
+	if err := c.performRequest("invokescript", newParams(hex.EncodeToString(script)), resp); err != nil {
 		return nil, err
 	}
 	return resp, nil
 }
 
 // SendRawTransaction broadcasts a transaction over the NEO network.
-// The given hex string needs to be signed with a keypair.
-// When the result of the response object is true, the TX has successfully
-// been broadcasted to the network.
-func (c *Client) SendRawTransaction(rawTX string) (*response, error) {
-	var (
-		params = newParams(rawTX)
-		resp   = &response{}
-	)
-	if err := c.performRequest("sendrawtransaction", params, resp); err != nil {
+// The transaction needs to be signed. A nil error means that the node
+// accepted the transaction.
+func (c *Client) SendRawTransaction(tx *transaction.Transaction) error {
+	buf := new(bytes.Buffer)
+	if err := tx.EncodeBinary(buf); err != nil {
+		return err
+	}
+	return c.performBoolRequest("sendrawtransaction", newParams(hex.EncodeToString(buf.Bytes())))
+}
+
+// SubmitBlock broadcasts a block over the NEO network. A nil error means
+// that the node accepted the block.
+func (c *Client) SubmitBlock(b *core.Block) error {
+	buf := new(bytes.Buffer)
+	if err := b.EncodeBinary(buf); err != nil {
+		return err
+	}
+	return c.performBoolRequest("submitblock", newParams(hex.EncodeToString(buf.Bytes())))
+}
+
+// performHexRequest calls the given method and decodes the hex string
+// it returns.
+func (c *Client) performHexRequest(method string, p params) ([]byte, error) {
+	var s string
+	if err := c.performRequest(method, p, &s); err != nil {
 		return nil, err
 	}
-	return resp, nil
+	return hex.DecodeString(s)
+}
+
+// performBoolRequest calls the given method and returns an error if it
+// doesn't return true.
+func (c *Client) performBoolRequest(method string, p params) error {
+	var ok bool
+	if err := c.performRequest(method, p, &ok); err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New(method + " was not accepted")
+	}
+	return nil
 }
@@ -0,0 +1,142 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+)
+
+// expandArrayIntoScript pushes the given parameters to the script in
+// reverse order, so the first one ends up on top of the stack.
+func expandArrayIntoScript(script *bytes.Buffer, params []smartcontract.Parameter) error {
+	for i := len(params) - 1; i >= 0; i-- {
+		if err := emitParam(script, params[i]); err != nil {
+			return fmt.Errorf("parameter %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+// emitParam pushes the value of the given parameter to the script.
+func emitParam(script *bytes.Buffer, p smartcontract.Parameter) error {
+	switch p.Type {
+	case smartcontract.ArrayType:
+		items, err := toFuncParams(p.Value)
+		if err != nil {
+			return err
+		}
+		if err := expandArrayIntoScript(script, items); err != nil {
+			return err
+		}
+		if err := vm.EmitInt(script, int64(len(items))); err != nil {
+			return err
+		}
+		return vm.EmitOpcode(script, vm.Opack)
+	case smartcontract.BoolType:
+		switch val := p.Value.(type) {
+		case bool:
+			return vm.EmitBool(script, val)
+		case string:
+			return vm.EmitBool(script, val == "true")
+		}
+		return errors.New("invalid boolean value")
+	case smartcontract.IntegerType:
+		var (
+			n   int64
+			err error
+		)
+		switch val := p.Value.(type) {
+		case float64:
+			n = int64(val)
+		case string:
+			n, err = strconv.ParseInt(val, 10, 64)
+		default:
+			err = errors.New("invalid integer value")
+		}
+		if err != nil {
+			return err
+		}
+		return vm.EmitInt(script, n)
+	case smartcontract.StringType:
+		s, ok := p.Value.(string)
+		if !ok {
+			return errors.New("invalid string value")
+		}
+		return vm.EmitString(script, s)
+	}
+
+	s, ok := p.Value.(string)
+	if !ok {
+		return fmt.Errorf("invalid %s value", p.Type)
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return err
+	}
+	switch p.Type {
+	case smartcontract.Hash160Type:
+		// Script hashes are displayed in the reverse byte order.
+		if len(b) != 20 {
+			return errors.New("invalid Hash160 value")
+		}
+		b = util.ArrayReverse(b)
+	case smartcontract.Hash256Type:
+		if len(b) != 32 {
+			return errors.New("invalid Hash256 value")
+		}
+		b = util.ArrayReverse(b)
+	case smartcontract.ByteArrayType, smartcontract.SignatureType, smartcontract.PublicKeyType:
+	default:
+		return fmt.Errorf("unsupported parameter type %s", p.Type)
+	}
+	return vm.EmitBytes(script, b)
+}
+
+// toFuncParams converts the value of an array parameter as decoded from
+// JSON to a list of parameters.
+func toFuncParams(v interface{}) ([]smartcontract.Parameter, error) {
+	if params, ok := v.([]smartcontract.Parameter); ok {
+		return params, nil
+	}
+	return Param{Type: "array", RawValue: v}.GetFuncParams()
+}
+
+// createInvocationScript returns a script calling the given contract with
+// the given parameters.
+func createInvocationScript(contract util.Uint160, params []smartcontract.Parameter) ([]byte, error) {
+	script := new(bytes.Buffer)
+	if err := expandArrayIntoScript(script, params); err != nil {
+		return nil, err
+	}
+	if err := vm.EmitAppCall(script, contract, false); err != nil {
+		return nil, err
+	}
+	return script.Bytes(), nil
+}
+
+// createFunctionInvocationScript returns a script calling the given
+// operation of the given contract with the given arguments.
+func createFunctionInvocationScript(contract util.Uint160, operation string, args []smartcontract.Parameter) ([]byte, error) {
+	script := new(bytes.Buffer)
+	err := emitParam(script, smartcontract.Parameter{
+		Type:  smartcontract.ArrayType,
+		Value: args,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := vm.EmitString(script, operation); err != nil {
+		return nil, err
+	}
+	if err := vm.EmitAppCall(script, contract, false); err != nil {
+		return nil, err
+	}
+	return script.Bytes(), nil
+}
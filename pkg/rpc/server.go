@@ -1,19 +1,39 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
 	"github.com/CityOfZion/neo-go/pkg/network"
 	"github.com/CityOfZion/neo-go/pkg/rpc/result"
 	"github.com/CityOfZion/neo-go/pkg/rpc/wrappers"
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
 	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
 	log "github.com/sirupsen/logrus"
 )
 
+// Number of transactions returned by getaddresshistory by default and at
+// most.
+const (
+	defaultAddressHistoryCount = 100
+	maxAddressHistoryCount     = 1000
+)
+
+// nep5TransfersPeriod is the period getnep5transfers covers by default.
+const nep5TransfersPeriod = 7 * 24 * time.Hour
+
 type (
 	// Server represents the JSON-RPC 2.0 server.
 	Server struct {
@@ -27,6 +47,9 @@ var (
 	invalidBlockHeightError = func(index int, height int) error {
 		return fmt.Errorf("Param at index %d should be greater than or equal to 0 and less then or equal to current block height, got: %d", index, height)
 	}
+	invalidParamError = func(index int, err error) *Error {
+		return NewInvalidParamsError(fmt.Sprintf("Problem parsing param at index %d", index), err)
+	}
 )
 
 // NewServer creates a new Server struct.
@@ -97,50 +120,13 @@ func (s *Server) methodHandler(w http.ResponseWriter, req *Request, reqParams Pa
 	var results interface{}
 	var resultsErr *Error
 
-Methods:
 	switch req.Method {
 	case "getbestblockhash":
 		results = s.chain.CurrentBlockHash().String()
 
 	case "getblock":
-		var hash util.Uint256
-		var err error
-
-		param, exists := reqParams.ValueAt(0)
-		if !exists {
-			err = errors.New("Param at index at 0 doesn't exist")
-			resultsErr = NewInvalidParamsError(err.Error(), err)
-			break
-		}
-
-		switch param.Type {
-		case "string":
-			hash, err = util.Uint256DecodeString(param.StringVal)
-			if err != nil {
-				resultsErr = NewInvalidParamsError("Problem decoding block hash", err)
-				break
-			}
-		case "number":
-			if !s.validBlockHeight(param) {
-				err = invalidBlockHeightError(0, param.IntVal)
-				resultsErr = NewInvalidParamsError(err.Error(), err)
-				break Methods
-			}
-
-			hash = s.chain.GetHeaderHash(param.IntVal)
-		case "default":
-			err = errors.New("Expected param at index 0 to be either string or number")
-			resultsErr = NewInvalidParamsError(err.Error(), err)
-			break
-		}
+		results, resultsErr = s.getBlock(reqParams)
 
-		block, err := s.chain.GetBlock(hash)
-		if err != nil {
-			resultsErr = NewInternalServerError(fmt.Sprintf("Problem locating block with hash: %s", hash), err)
-			break
-		}
-
-		results = wrappers.NewBlock(block, s.chain)
 	case "getblockcount":
 		results = s.chain.BlockHeight()
 
@@ -179,8 +165,86 @@ Methods:
 
 		results = peers
 
-	case "validateaddress", "getblocksysfee", "getcontractstate", "getrawmempool", "getrawtransaction", "getstorage", "submitblock", "gettxout", "invoke", "invokefunction", "invokescript", "sendrawtransaction", "getaccountstate", "getassetstate":
-		results = "TODO"
+	case "validateaddress":
+		param, exists := reqParams.ValueAt(0)
+		if !exists {
+			err := errors.New("Param at index at 0 doesn't exist")
+			resultsErr = NewInvalidParamsError(err.Error(), err)
+			break
+		}
+		_, err := param.GetUint160FromAddress()
+		results = result.ValidateAddress{
+			Address: param.RawValue,
+			IsValid: err == nil,
+		}
+
+	case "getaccountstate":
+		results, resultsErr = s.getAccountState(reqParams)
+
+	case "getaddresshistory":
+		results, resultsErr = s.getAddressHistory(reqParams)
+
+	case "getapplicationlog":
+		results, resultsErr = s.getApplicationLog(reqParams)
+
+	case "getassetstate":
+		results, resultsErr = s.getAssetState(reqParams)
+
+	case "getblocksysfee":
+		results, resultsErr = s.getBlockSysFee(reqParams)
+
+	case "getclaimable":
+		results, resultsErr = s.getClaimable(reqParams)
+
+	case "getcontractstate":
+		results, resultsErr = s.getContractState(reqParams)
+
+	case "getnep5balances":
+		results, resultsErr = s.getNEP5Balances(reqParams)
+
+	case "getnep5transfers":
+		results, resultsErr = s.getNEP5Transfers(reqParams)
+
+	case "getrawmempool":
+		txs := s.chain.GetMemPool().GetVerifiedTransactions()
+		hashes := make([]util.Uint256, len(txs))
+		for i, tx := range txs {
+			hashes[i] = tx.Hash()
+		}
+		results = hashes
+
+	case "getrawtransaction":
+		results, resultsErr = s.getRawTransaction(reqParams)
+
+	case "getstorage":
+		results, resultsErr = s.getStorage(reqParams)
+
+	case "gettxout":
+		results, resultsErr = s.getTxOut(reqParams)
+
+	case "getunclaimed":
+		results, resultsErr = s.getUnclaimed(reqParams)
+
+	case "getunspents":
+		results, resultsErr = s.getUnspents(reqParams)
+
+	case "getvalidators":
+		results, resultsErr = s.getValidators()
+
+	case "invoke":
+		results, resultsErr = s.invoke(reqParams)
+
+	case "invokefunction":
+		results, resultsErr = s.invokeFunction(reqParams)
+
+	case "invokescript":
+		results, resultsErr = s.invokeScript(reqParams)
+
+	case "sendrawtransaction":
+		results, resultsErr = s.sendRawTransaction(reqParams)
+
+	case "submitblock":
+		results, resultsErr = s.submitBlock(reqParams)
 
 	default:
 		resultsErr = NewMethodNotFoundError(fmt.Sprintf("Method '%s' not supported", req.Method), nil)
@@ -197,3 +261,678 @@ Methods:
 func (s Server) validBlockHeight(param *Param) bool {
 	return param.IntVal >= 0 && param.IntVal <= int(s.chain.BlockHeight())
 }
+
+func (s *Server) getBlock(reqParams Params) (interface{}, *Error) {
+	var hash util.Uint256
+
+	param, exists := reqParams.ValueAt(0)
+	if !exists {
+		err := errors.New("Param at index at 0 doesn't exist")
+		return nil, NewInvalidParamsError(err.Error(), err)
+	}
+
+	switch param.Type {
+	case "string":
+		var err error
+		hash, err = param.GetUint256()
+		if err != nil {
+			return nil, NewInvalidParamsError("Problem decoding block hash", err)
+		}
+	case "number":
+		if !s.validBlockHeight(param) {
+			err := invalidBlockHeightError(0, param.IntVal)
+			return nil, NewInvalidParamsError(err.Error(), err)
+		}
+		hash = s.chain.GetHeaderHash(param.IntVal)
+	default:
+		err := errors.New("Expected param at index 0 to be either string or number")
+		return nil, NewInvalidParamsError(err.Error(), err)
+	}
+
+	block, err := s.chain.GetBlock(hash)
+	if err != nil {
+		return nil, NewInternalServerError(fmt.Sprintf("Problem locating block with hash: %s", hash), err)
+	}
+	// The stored blocks only hold the hashes of their transactions.
+	for i, t := range block.Transactions {
+		tx, _, err := s.chain.GetTransaction(t.Hash())
+		if err != nil {
+			return nil, NewInternalServerError(fmt.Sprintf("Problem locating transaction with hash: %s", t.Hash()), err)
+		}
+		block.Transactions[i] = tx
+	}
+	block.Trimmed = false
+
+	if verbose, exists := reqParams.ValueAt(1); exists && verbose.IntVal != 0 {
+		return wrappers.NewBlock(block, s.chain), nil
+	}
+	buf := new(bytes.Buffer)
+	if err := block.EncodeBinary(buf); err != nil {
+		return nil, NewInternalServerError("Problem encoding block", err)
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+func (s *Server) getAccountState(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	scriptHash, err := param.GetUint160FromAddress()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+
+	// Accounts that never received anything are reported with no balances.
+	account := s.chain.GetAccountState(scriptHash)
+	if account == nil {
+		account = core.NewAccountState(scriptHash)
+	}
+	return wrappers.NewAccountState(account), nil
+}
+
+func (s *Server) getAssetState(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	assetID, err := param.GetUint256()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+
+	asset := s.chain.GetAssetState(assetID)
+	if asset == nil {
+		return nil, NewRPCError("Unknown asset", "", nil)
+	}
+	return wrappers.NewAssetState(asset), nil
+}
+
+// getBlockSysFee returns the total system fee of all the transactions up to
+// and including the block at the given height.
+func (s *Server) getBlockSysFee(reqParams Params) (interface{}, *Error) {
+	param, exists := reqParams.ValueAtAndType(0, "number")
+	if !exists || !s.validBlockHeight(param) {
+		return nil, NewRPCError("Invalid height", "", nil)
+	}
+
+	fee := s.chain.GetSysFeeAmount(s.chain.GetHeaderHash(param.IntVal))
+	return strconv.FormatUint(uint64(fee), 10), nil
+}
+
+// getClaimable returns the spent NEO outputs of the given address whose GAS
+// is not claimed yet.
+func (s *Server) getClaimable(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	scriptHash, err := param.GetUint160FromAddress()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	res, err := s.claimable(scriptHash)
+	if err != nil {
+		return nil, NewInternalServerError("Problem computing the claimable outputs", err)
+	}
+	res.Address = param.StringVal
+	return res, nil
+}
+
+// getUnclaimed returns the GAS of the given address that can be claimed and
+// the one that will be claimable once its NEO is spent.
+func (s *Server) getUnclaimed(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	scriptHash, err := param.GetUint160FromAddress()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	claimable, err := s.claimable(scriptHash)
+	if err != nil {
+		return nil, NewInternalServerError("Problem computing the claimable outputs", err)
+	}
+	unavailable, err := s.chain.GetUnavailable(scriptHash)
+	if err != nil {
+		return nil, NewInternalServerError("Problem computing the unavailable GAS", err)
+	}
+	return result.Unclaimed{
+		Available:   claimable.Unclaimed,
+		Unavailable: unavailable,
+		Unclaimed:   claimable.Unclaimed + unavailable,
+	}, nil
+}
+
+// getUnspents returns the unspent outputs of the given address grouped by
+// asset.
+func (s *Server) getUnspents(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	scriptHash, err := param.GetUint160FromAddress()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	outputs, err := s.chain.GetUnspents(scriptHash)
+	if err != nil {
+		return nil, NewInternalServerError("Problem reading the unspent outputs", err)
+	}
+
+	res := result.Unspents{
+		Balance: []result.UnspentBalanceInfo{},
+		Address: param.StringVal,
+	}
+	assets := make(map[util.Uint256]int)
+	for _, output := range outputs {
+		i, ok := assets[output.AssetID]
+		if !ok {
+			i = len(res.Balance)
+			assets[output.AssetID] = i
+			symbol := assetSymbol(s.chain.GetAssetState(output.AssetID))
+			res.Balance = append(res.Balance, result.UnspentBalanceInfo{
+				Unspents:    []result.Unspent{},
+				AssetHash:   output.AssetID,
+				Asset:       symbol,
+				AssetSymbol: symbol,
+			})
+		}
+		balance := &res.Balance[i]
+		balance.Unspents = append(balance.Unspents, result.Unspent{
+			TxID:  output.TxHash,
+			Index: output.Index,
+			Value: output.Amount,
+		})
+		balance.Amount += output.Amount
+	}
+	return res, nil
+}
+
+// getAddressHistory returns a page of the transactions touching the given
+// address, newest first. The optional second parameter is the size of the
+// page, the optional third one the cursor returned with the previous page.
+func (s *Server) getAddressHistory(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	scriptHash, err := param.GetUint160FromAddress()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	count := defaultAddressHistoryCount
+	if countParam, ok := reqParams.ValueAtAndType(1, "number"); ok {
+		if countParam.IntVal <= 0 || countParam.IntVal > maxAddressHistoryCount {
+			return nil, invalidParamError(1, fmt.Errorf("count must be between 1 and %d", maxAddressHistoryCount))
+		}
+		count = countParam.IntVal
+	}
+	var before *core.AddressTransaction
+	if cursorParam, ok := reqParams.ValueAt(2); ok && cursorParam.StringVal != "" {
+		b, err := cursorParam.GetBytesHex()
+		if err != nil || len(b) != 6 {
+			return nil, invalidParamError(2, errors.New("invalid cursor"))
+		}
+		before = &core.AddressTransaction{
+			Height: binary.BigEndian.Uint32(b),
+			Index:  binary.BigEndian.Uint16(b[4:]),
+		}
+	}
+
+	// One more entry is read to know whether there is a next page.
+	txs, err := s.chain.GetAddressHistory(scriptHash, before, count+1)
+	if err != nil {
+		return nil, NewInternalServerError("Problem reading the address history", err)
+	}
+	more := len(txs) > count
+	if more {
+		txs = txs[:count]
+	}
+	res := result.AddressHistory{
+		Address:      param.StringVal,
+		Transactions: make([]result.AddressTransaction, len(txs)),
+	}
+	for i, tx := range txs {
+		res.Transactions[i] = result.AddressTransaction{
+			TxID:   tx.Hash,
+			Height: tx.Height,
+		}
+	}
+	if more {
+		last := txs[len(txs)-1]
+		cursor := make([]byte, 6)
+		binary.BigEndian.PutUint32(cursor, last.Height)
+		binary.BigEndian.PutUint16(cursor[4:], last.Index)
+		res.Next = hex.EncodeToString(cursor)
+	}
+	return res, nil
+}
+
+// getNEP5Balances returns the balances of the given address in the NEP-5
+// tokens it ever received.
+func (s *Server) getNEP5Balances(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	scriptHash, err := param.GetUint160FromAddress()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	balances, err := s.chain.GetNEP5Balances(scriptHash)
+	if err != nil {
+		return nil, NewInternalServerError("Problem reading the NEP-5 balances", err)
+	}
+	res := result.NEP5Balances{
+		Balances: make([]result.NEP5Balance, len(balances)),
+		Address:  param.StringVal,
+	}
+	for i, balance := range balances {
+		res.Balances[i] = result.NEP5Balance{
+			Asset:       balance.Asset,
+			Amount:      balance.Amount.String(),
+			LastUpdated: balance.LastUpdatedBlock,
+		}
+	}
+	return res, nil
+}
+
+// getNEP5Transfers returns the NEP-5 transfers sent and received by the
+// given address between the optional start and end timestamps, the last
+// nep5TransfersPeriod by default.
+func (s *Server) getNEP5Transfers(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	scriptHash, err := param.GetUint160FromAddress()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	end := time.Now().Unix()
+	if endParam, ok := reqParams.ValueAtAndType(2, "number"); ok {
+		end = int64(endParam.IntVal)
+	}
+	start := end - int64(nep5TransfersPeriod/time.Second)
+	if startParam, ok := reqParams.ValueAtAndType(1, "number"); ok {
+		start = int64(startParam.IntVal)
+	}
+	if start < 0 || end > math.MaxUint32 || start > end {
+		return nil, invalidParamError(1, errors.New("invalid time range"))
+	}
+
+	transfers, err := s.chain.GetNEP5Transfers(scriptHash, uint32(start), uint32(end))
+	if err != nil {
+		return nil, NewInternalServerError("Problem reading the NEP-5 transfers", err)
+	}
+	res := result.NEP5Transfers{
+		Sent:     []result.NEP5Transfer{},
+		Received: []result.NEP5Transfer{},
+		Address:  param.StringVal,
+	}
+	for _, transfer := range transfers {
+		t := result.NEP5Transfer{
+			Timestamp:   transfer.Timestamp,
+			Asset:       transfer.Asset,
+			Amount:      transfer.Amount.String(),
+			Index:       transfer.Block,
+			NotifyIndex: transfer.NotifyIndex,
+			TxHash:      transfer.TxHash,
+		}
+		// A transfer to oneself is both sent and received.
+		if transfer.From.Equals(scriptHash) {
+			if !transfer.To.Equals(util.Uint160{}) {
+				t.Address = crypto.AddressFromUint160(transfer.To)
+			}
+			res.Sent = append(res.Sent, t)
+		}
+		if transfer.To.Equals(scriptHash) {
+			t.Address = ""
+			if !transfer.From.Equals(util.Uint160{}) {
+				t.Address = crypto.AddressFromUint160(transfer.From)
+			}
+			res.Received = append(res.Received, t)
+		}
+	}
+	return res, nil
+}
+
+// assetSymbol returns NEO and GAS for the system assets and the name of the
+// given asset for the others.
+func assetSymbol(asset *core.AssetState) string {
+	if asset == nil {
+		return ""
+	}
+	switch asset.AssetType {
+	case transaction.GoverningToken:
+		return "NEO"
+	case transaction.UtilityToken:
+		return "GAS"
+	default:
+		return asset.Name
+	}
+}
+
+func (s *Server) claimable(scriptHash util.Uint160) (*result.Claimable, error) {
+	coins, err := s.chain.GetClaimable(scriptHash)
+	if err != nil {
+		return nil, err
+	}
+	res := &result.Claimable{Claimable: make([]result.Claim, len(coins))}
+	for i, coin := range coins {
+		generated, sysFee := s.chain.CalculateBonus(coin)
+		res.Claimable[i] = result.Claim{
+			TxID:        coin.TxHash,
+			Index:       coin.Index,
+			Value:       coin.Output.Amount,
+			StartHeight: coin.StartHeight,
+			EndHeight:   coin.EndHeight,
+			Generated:   generated,
+			SysFee:      sysFee,
+			Unclaimed:   generated + sysFee,
+		}
+		res.Unclaimed += generated + sysFee
+	}
+	return res, nil
+}
+
+// getValidators returns the registered and the standby validators, the
+// active ones being the validators of the next block.
+func (s *Server) getValidators() (interface{}, *Error) {
+	validators, err := s.chain.GetValidators()
+	if err != nil {
+		return nil, NewInternalServerError("Problem computing the validators", err)
+	}
+	enrollments, err := s.chain.GetEnrollments()
+	if err != nil {
+		return nil, NewInternalServerError("Problem reading the validators", err)
+	}
+
+	active := make(map[string]bool, len(validators))
+	for _, key := range validators {
+		active[string(key.Bytes())] = true
+	}
+	res := make([]result.Validator, len(enrollments))
+	for i, state := range enrollments {
+		res[i] = result.Validator{
+			PublicKey: state.PublicKey,
+			Votes:     state.Votes,
+			Active:    active[string(state.PublicKey.Bytes())],
+		}
+	}
+	return res, nil
+}
+
+func (s *Server) getContractState(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	scriptHash, err := param.GetUint160FromHex()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+
+	contract := s.chain.GetContractState(scriptHash)
+	if contract == nil {
+		return nil, NewRPCError("Unknown contract", "", nil)
+	}
+	return wrappers.NewContractState(contract), nil
+}
+
+func (s *Server) getApplicationLog(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	hash, err := param.GetUint256()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+
+	tx, _, err := s.chain.GetTransaction(hash)
+	if err != nil {
+		return nil, NewRPCError("Unknown transaction", "", err)
+	}
+	invocation, ok := tx.Data.(*transaction.InvocationTX)
+	if !ok {
+		return nil, NewRPCError("Not an invocation transaction", "", nil)
+	}
+	aer, err := s.chain.GetAppExecResult(hash)
+	if err != nil {
+		return nil, NewRPCError("Unknown application log", "", err)
+	}
+	scriptHash, err := util.Uint160FromScript(invocation.Script)
+	if err != nil {
+		return nil, NewInternalServerError("Problem hashing the script", err)
+	}
+	return wrappers.NewApplicationLog(aer, scriptHash), nil
+}
+
+func (s *Server) getRawTransaction(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	hash, err := param.GetUint256()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+
+	var header *core.Header
+	tx, ok := s.chain.GetMemPool().TryGetValue(hash)
+	if !ok {
+		var height uint32
+		tx, height, err = s.chain.GetTransaction(hash)
+		if err != nil {
+			return nil, NewRPCError("Unknown transaction", "", err)
+		}
+		block, err := s.chain.GetBlock(s.chain.GetHeaderHash(int(height)))
+		if err != nil {
+			return nil, NewInternalServerError(fmt.Sprintf("Problem locating block at height: %d", height), err)
+		}
+		header = block.Header()
+	}
+
+	if verbose, exists := reqParams.ValueAt(1); exists && verbose.IntVal != 0 {
+		return wrappers.NewTransactionOutputRaw(tx, header, s.chain), nil
+	}
+	buf := new(bytes.Buffer)
+	if err := tx.EncodeBinary(buf); err != nil {
+		return nil, NewInternalServerError("Problem encoding transaction", err)
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+func (s *Server) getStorage(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	scriptHash, err := param.GetUint160FromHex()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	param, _ = reqParams.ValueAt(1)
+	if param == nil {
+		return nil, invalidParamError(1, nil)
+	}
+	key, err := param.GetBytesHex()
+	if err != nil {
+		return nil, invalidParamError(1, err)
+	}
+
+	item := s.chain.GetStorageItem(scriptHash, key)
+	if item == nil {
+		return nil, nil
+	}
+	return hex.EncodeToString(item.Value), nil
+}
+
+func (s *Server) getTxOut(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	hash, err := param.GetUint256()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	param, exists := reqParams.ValueAtAndType(1, "number")
+	if !exists || param.IntVal < 0 || param.IntVal > math.MaxUint16 {
+		return nil, invalidParamError(1, nil)
+	}
+
+	out := s.chain.GetUnspent(hash, uint16(param.IntVal))
+	if out == nil {
+		return nil, nil
+	}
+	return wrappers.NewTransactionOutput(out, param.IntVal), nil
+}
+
+// invoke runs a call of the given contract with the given parameters.
+func (s *Server) invoke(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	scriptHash, err := param.GetUint160FromHex()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	param, _ = reqParams.ValueAt(1)
+	if param == nil {
+		return nil, invalidParamError(1, nil)
+	}
+	params, err := param.GetFuncParams()
+	if err != nil {
+		return nil, invalidParamError(1, err)
+	}
+
+	script, err := createInvocationScript(scriptHash, params)
+	if err != nil {
+		return nil, invalidParamError(1, err)
+	}
+	return s.runScriptThroughVM(script), nil
+}
+
+// invokeFunction runs a call of the given operation of the given contract
+// with the given arguments.
+func (s *Server) invokeFunction(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	scriptHash, err := param.GetUint160FromHex()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	operation, exists := reqParams.ValueAtAndType(1, "string")
+	if !exists {
+		return nil, invalidParamError(1, nil)
+	}
+	var args []smartcontract.Parameter
+	if param, exists := reqParams.ValueAt(2); exists {
+		if args, err = param.GetFuncParams(); err != nil {
+			return nil, invalidParamError(2, err)
+		}
+	}
+
+	script, err := createFunctionInvocationScript(scriptHash, operation.StringVal, args)
+	if err != nil {
+		return nil, invalidParamError(2, err)
+	}
+	return s.runScriptThroughVM(script), nil
+}
+
+// invokeScript runs the given script.
+func (s *Server) invokeScript(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	script, err := param.GetBytesHex()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	return s.runScriptThroughVM(script), nil
+}
+
+// runScriptThroughVM runs the given script in a new VM without changing
+// the state of the chain.
+func (s *Server) runScriptThroughVM(script []byte) result.Invoke {
+	v := s.chain.GetTestVM()
+	v.LoadScript(script)
+	v.Run()
+
+	// The results are listed from the bottom of the stack.
+	stack := make([]smartcontract.Parameter, v.Estack().Len())
+	i := len(stack)
+	v.Estack().Iter(func(e *vm.Element) {
+		i--
+		stack[i] = smartcontract.NewParameterFromStackItem(e.Item())
+	})
+	return result.Invoke{
+		State:       v.State(),
+		GasConsumed: v.GasConsumed().String(),
+		Script:      hex.EncodeToString(script),
+		Stack:       stack,
+	}
+}
+
+func (s *Server) sendRawTransaction(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	b, err := param.GetBytesHex()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	tx := &transaction.Transaction{}
+	if err := tx.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, invalidParamError(0, err)
+	}
+
+	switch err := s.coreServer.RelayTxn(tx); err {
+	case nil:
+		return true, nil
+	case core.ErrAlreadyExists:
+		return nil, NewSubmitError(-501, "Block or transaction already exists and cannot be sent repeatedly.")
+	case core.ErrOOM:
+		return nil, NewSubmitError(-502, "The memory pool is full and no more transactions can be sent.")
+	default:
+		log.WithFields(log.Fields{
+			"hash": tx.Hash(),
+		}).Debugf("rejected transaction: %s", err)
+		return nil, NewSubmitError(-504, "Block or transaction validation failed.")
+	}
+}
+
+func (s *Server) submitBlock(reqParams Params) (interface{}, *Error) {
+	param, _ := reqParams.ValueAt(0)
+	if param == nil {
+		return nil, invalidParamError(0, nil)
+	}
+	b, err := param.GetBytesHex()
+	if err != nil {
+		return nil, invalidParamError(0, err)
+	}
+	block := &core.Block{}
+	if err := block.DecodeBinary(bytes.NewReader(b)); err != nil {
+		return nil, invalidParamError(0, err)
+	}
+
+	if s.chain.HasBlock(block.Hash()) {
+		return nil, NewSubmitError(-501, "Block or transaction already exists and cannot be sent repeatedly.")
+	}
+	if err := s.chain.AddBlock(block); err != nil {
+		log.WithFields(log.Fields{
+			"hash": block.Hash(),
+		}).Debugf("rejected block: %s", err)
+		return nil, NewSubmitError(-504, "Block or transaction validation failed.")
+	}
+	return true, nil
+}
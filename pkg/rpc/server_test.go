@@ -0,0 +1,557 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/CityOfZion/neo-go/config"
+	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/core/coretest"
+	"github.com/CityOfZion/neo-go/pkg/core/storage"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/network"
+	"github.com/CityOfZion/neo-go/pkg/rpc/result"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+func newTestServer(t *testing.T) (*Server, *core.Blockchain) {
+	cfg, err := config.Load("../../config", config.ModePrivNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := core.NewBlockchain(storage.NewMemoryStore(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coreServer := network.NewServer(network.ServerConfig{}, chain)
+	server := NewServer(chain, 0, coreServer)
+	return &server, chain
+}
+
+func doRPCCall(t *testing.T, s *Server, method string, params string) rpcResponse {
+	body := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "%s", "params": %s}`, method, params)
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	s.requestHandler(w, req)
+
+	var resp rpcResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// getGenesisBlock returns the genesis block with all of its transactions.
+func getGenesisBlock(t *testing.T, chain *core.Blockchain) *core.Block {
+	genesis, err := chain.GetBlock(chain.GetHeaderHash(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tx := range genesis.Transactions {
+		genesis.Transactions[i], _, err = chain.GetTransaction(tx.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	genesis.Trimmed = false
+	return genesis
+}
+
+// getGenesisIssueTX returns the transaction issuing NEO in the genesis block.
+func getGenesisIssueTX(t *testing.T, chain *core.Blockchain) *transaction.Transaction {
+	for _, tx := range getGenesisBlock(t, chain).Transactions {
+		if tx.Type == transaction.IssueType {
+			return tx
+		}
+	}
+	t.Fatal("no issue transaction in the genesis block")
+	return nil
+}
+
+func TestValidateAddress(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	resp := doRPCCall(t, s, "validateaddress", `["AQVh2pG732YvtNaxEGkQUei3YA4cvo7d2i"]`)
+	assert.Nil(t, resp.Error)
+	assert.JSONEq(t, `{"address": "AQVh2pG732YvtNaxEGkQUei3YA4cvo7d2i", "isvalid": true}`, string(resp.Result))
+
+	resp = doRPCCall(t, s, "validateaddress", `["AQVh2pG732YvtNaxEGkQUei3YA4cvo7d2j"]`)
+	assert.Nil(t, resp.Error)
+	assert.JSONEq(t, `{"address": "AQVh2pG732YvtNaxEGkQUei3YA4cvo7d2j", "isvalid": false}`, string(resp.Result))
+
+	resp = doRPCCall(t, s, "validateaddress", `[1]`)
+	assert.Nil(t, resp.Error)
+	assert.JSONEq(t, `{"address": 1, "isvalid": false}`, string(resp.Result))
+}
+
+func TestGetAccountState(t *testing.T) {
+	s, chain := newTestServer(t)
+	issueTX := getGenesisIssueTX(t, chain)
+	out := issueTX.Outputs[0]
+	address := crypto.AddressFromUint160(out.ScriptHash)
+
+	resp := doRPCCall(t, s, "getaccountstate", fmt.Sprintf(`["%s"]`, address))
+	assert.Nil(t, resp.Error)
+	var account struct {
+		ScriptHash string `json:"script_hash"`
+		Balances   []struct {
+			Asset util.Uint256 `json:"asset"`
+			Value util.Fixed8  `json:"value"`
+		} `json:"balances"`
+	}
+	assert.Nil(t, json.Unmarshal(resp.Result, &account))
+	assert.Equal(t, "0x"+hex.EncodeToString(out.ScriptHash.BytesReverse()), account.ScriptHash)
+	assert.Equal(t, 1, len(account.Balances))
+	assert.Equal(t, out.AssetID, account.Balances[0].Asset)
+	assert.Equal(t, out.Amount, account.Balances[0].Value)
+
+	// Unknown accounts have no balances.
+	resp = doRPCCall(t, s, "getaccountstate", `["AQVh2pG732YvtNaxEGkQUei3YA4cvo7d2i"]`)
+	assert.Nil(t, resp.Error)
+	assert.Nil(t, json.Unmarshal(resp.Result, &account))
+	assert.Equal(t, 0, len(account.Balances))
+
+	resp = doRPCCall(t, s, "getaccountstate", `["notanaddress"]`)
+	assert.NotNil(t, resp.Error)
+}
+
+func TestGetClaimableAndUnclaimed(t *testing.T) {
+	s, chain := newTestServer(t)
+	issueTX := getGenesisIssueTX(t, chain)
+	address := crypto.AddressFromUint160(issueTX.Outputs[0].ScriptHash)
+
+	// The genesis NEO isn't spent, so nothing is claimable yet.
+	resp := doRPCCall(t, s, "getclaimable", fmt.Sprintf(`["%s"]`, address))
+	assert.Nil(t, resp.Error)
+	var claimable result.Claimable
+	assert.Nil(t, json.Unmarshal(resp.Result, &claimable))
+	assert.Equal(t, address, claimable.Address)
+	assert.Equal(t, 0, len(claimable.Claimable))
+	assert.Equal(t, util.Fixed8(0), claimable.Unclaimed)
+
+	// Spending it in the next block would make 8 GAS claimable.
+	resp = doRPCCall(t, s, "getunclaimed", fmt.Sprintf(`["%s"]`, address))
+	assert.Nil(t, resp.Error)
+	var unclaimed result.Unclaimed
+	assert.Nil(t, json.Unmarshal(resp.Result, &unclaimed))
+	assert.Equal(t, util.Fixed8(0), unclaimed.Available)
+	assert.Equal(t, util.NewFixed8(8), unclaimed.Unavailable)
+	assert.Equal(t, util.NewFixed8(8), unclaimed.Unclaimed)
+
+	resp = doRPCCall(t, s, "getclaimable", `["notanaddress"]`)
+	assert.NotNil(t, resp.Error)
+	resp = doRPCCall(t, s, "getunclaimed", `[]`)
+	assert.NotNil(t, resp.Error)
+}
+
+func TestGetUnspents(t *testing.T) {
+	s, chain := newTestServer(t)
+	issueTX := getGenesisIssueTX(t, chain)
+	out := issueTX.Outputs[0]
+	address := crypto.AddressFromUint160(out.ScriptHash)
+
+	resp := doRPCCall(t, s, "getunspents", fmt.Sprintf(`["%s"]`, address))
+	assert.Nil(t, resp.Error)
+	var unspents result.Unspents
+	assert.Nil(t, json.Unmarshal(resp.Result, &unspents))
+	assert.Equal(t, address, unspents.Address)
+	if assert.Equal(t, 1, len(unspents.Balance)) {
+		balance := unspents.Balance[0]
+		assert.Equal(t, out.AssetID, balance.AssetHash)
+		assert.Equal(t, "NEO", balance.Asset)
+		assert.Equal(t, "NEO", balance.AssetSymbol)
+		assert.Equal(t, out.Amount, balance.Amount)
+		assert.Equal(t, []result.Unspent{{TxID: issueTX.Hash(), Index: 0, Value: out.Amount}}, balance.Unspents)
+	}
+
+	// Unknown accounts have nothing to spend.
+	resp = doRPCCall(t, s, "getunspents", `["AQVh2pG732YvtNaxEGkQUei3YA4cvo7d2i"]`)
+	assert.Nil(t, resp.Error)
+	assert.Nil(t, json.Unmarshal(resp.Result, &unspents))
+	assert.Equal(t, 0, len(unspents.Balance))
+
+	resp = doRPCCall(t, s, "getunspents", `["notanaddress"]`)
+	assert.NotNil(t, resp.Error)
+}
+
+func TestGetAddressHistory(t *testing.T) {
+	chain := coretest.NewChain()
+	server := NewServer(chain, 0, network.NewServer(network.ServerConfig{}, chain))
+	scriptHash := util.Uint160{1, 2, 3}
+	address := crypto.AddressFromUint160(scriptHash)
+	chain.AddressHistory[scriptHash] = []*core.AddressTransaction{
+		{Hash: util.Uint256{3}, Height: 2, Index: 1},
+		{Hash: util.Uint256{2}, Height: 2, Index: 0},
+		{Hash: util.Uint256{1}, Height: 1, Index: 0},
+	}
+
+	resp := doRPCCall(t, &server, "getaddresshistory", fmt.Sprintf(`["%s", 2]`, address))
+	assert.Nil(t, resp.Error)
+	var history result.AddressHistory
+	assert.Nil(t, json.Unmarshal(resp.Result, &history))
+	assert.Equal(t, address, history.Address)
+	assert.Equal(t, []result.AddressTransaction{
+		{TxID: util.Uint256{3}, Height: 2},
+		{TxID: util.Uint256{2}, Height: 2},
+	}, history.Transactions)
+	assert.Equal(t, "000000020000", history.Next)
+
+	resp = doRPCCall(t, &server, "getaddresshistory", fmt.Sprintf(`["%s", 2, "%s"]`, address, history.Next))
+	assert.Nil(t, resp.Error)
+	history = result.AddressHistory{}
+	assert.Nil(t, json.Unmarshal(resp.Result, &history))
+	assert.Equal(t, []result.AddressTransaction{{TxID: util.Uint256{1}, Height: 1}}, history.Transactions)
+	assert.Equal(t, "", history.Next)
+
+	// A page ending with the last transaction has no next one.
+	resp = doRPCCall(t, &server, "getaddresshistory", fmt.Sprintf(`["%s", 3]`, address))
+	assert.Nil(t, resp.Error)
+	history = result.AddressHistory{}
+	assert.Nil(t, json.Unmarshal(resp.Result, &history))
+	assert.Len(t, history.Transactions, 3)
+	assert.Equal(t, "", history.Next)
+
+	resp = doRPCCall(t, &server, "getaddresshistory", fmt.Sprintf(`["%s", 0]`, address))
+	assert.NotNil(t, resp.Error)
+	resp = doRPCCall(t, &server, "getaddresshistory", fmt.Sprintf(`["%s", 2, "zz"]`, address))
+	assert.NotNil(t, resp.Error)
+
+	// The real chain doesn't index addresses unless configured to.
+	s, _ := newTestServer(t)
+	resp = doRPCCall(t, s, "getaddresshistory", fmt.Sprintf(`["%s"]`, address))
+	assert.NotNil(t, resp.Error)
+}
+
+func TestGetNEP5BalancesAndTransfers(t *testing.T) {
+	chain := coretest.NewChain()
+	server := NewServer(chain, 0, network.NewServer(network.ServerConfig{}, chain))
+	alice, bob, token := util.Uint160{1, 2, 3}, util.Uint160{3, 2, 1}, util.Uint160{4, 5, 6}
+	address := crypto.AddressFromUint160(alice)
+	chain.NEP5Balances[alice] = []*core.NEP5Balance{{Asset: token, Amount: big.NewInt(70), LastUpdatedBlock: 2}}
+	chain.NEP5Transfers[alice] = []*core.NEP5Transfer{
+		{Asset: token, From: alice, To: bob, Amount: big.NewInt(30), Block: 2, Timestamp: 200, TxHash: util.Uint256{2}},
+		{Asset: token, To: alice, Amount: big.NewInt(100), Block: 1, Timestamp: 100, TxHash: util.Uint256{1}, NotifyIndex: 1},
+	}
+
+	resp := doRPCCall(t, &server, "getnep5balances", fmt.Sprintf(`["%s"]`, address))
+	assert.Nil(t, resp.Error)
+	var balances struct {
+		Balances []struct {
+			Asset       string `json:"asset_hash"`
+			Amount      string `json:"amount"`
+			LastUpdated uint32 `json:"last_updated_block"`
+		} `json:"balance"`
+		Address string `json:"address"`
+	}
+	assert.Nil(t, json.Unmarshal(resp.Result, &balances))
+	assert.Equal(t, address, balances.Address)
+	if assert.Equal(t, 1, len(balances.Balances)) {
+		assert.Equal(t, "0x"+hex.EncodeToString(token.BytesReverse()), balances.Balances[0].Asset)
+		assert.Equal(t, "70", balances.Balances[0].Amount)
+		assert.Equal(t, uint32(2), balances.Balances[0].LastUpdated)
+	}
+
+	resp = doRPCCall(t, &server, "getnep5transfers", fmt.Sprintf(`["%s", 0, 1000]`, address))
+	assert.Nil(t, resp.Error)
+	var transfers struct {
+		Sent []struct {
+			Address     string       `json:"transfer_address"`
+			Amount      string       `json:"amount"`
+			Index       uint32       `json:"block_index"`
+			NotifyIndex uint16       `json:"transfer_notify_index"`
+			TxHash      util.Uint256 `json:"tx_hash"`
+		} `json:"sent"`
+		Received []struct {
+			Address     string       `json:"transfer_address"`
+			Amount      string       `json:"amount"`
+			Index       uint32       `json:"block_index"`
+			NotifyIndex uint16       `json:"transfer_notify_index"`
+			TxHash      util.Uint256 `json:"tx_hash"`
+		} `json:"received"`
+	}
+	assert.Nil(t, json.Unmarshal(resp.Result, &transfers))
+	if assert.Equal(t, 1, len(transfers.Sent)) {
+		assert.Equal(t, crypto.AddressFromUint160(bob), transfers.Sent[0].Address)
+		assert.Equal(t, "30", transfers.Sent[0].Amount)
+		assert.Equal(t, uint32(2), transfers.Sent[0].Index)
+		assert.Equal(t, util.Uint256{2}, transfers.Sent[0].TxHash)
+	}
+	if assert.Equal(t, 1, len(transfers.Received)) {
+		assert.Equal(t, "", transfers.Received[0].Address)
+		assert.Equal(t, "100", transfers.Received[0].Amount)
+		assert.Equal(t, uint16(1), transfers.Received[0].NotifyIndex)
+	}
+
+	// The last week is returned by default.
+	resp = doRPCCall(t, &server, "getnep5transfers", fmt.Sprintf(`["%s"]`, address))
+	assert.Nil(t, resp.Error)
+	assert.Nil(t, json.Unmarshal(resp.Result, &transfers))
+	assert.Equal(t, 0, len(transfers.Sent))
+	assert.Equal(t, 0, len(transfers.Received))
+
+	resp = doRPCCall(t, &server, "getnep5transfers", fmt.Sprintf(`["%s", 1000, 0]`, address))
+	assert.NotNil(t, resp.Error)
+	resp = doRPCCall(t, &server, "getnep5balances", `["notanaddress"]`)
+	assert.NotNil(t, resp.Error)
+}
+
+func TestGetValidators(t *testing.T) {
+	s, _ := newTestServer(t)
+	resp := doRPCCall(t, s, "getvalidators", `[]`)
+	assert.Nil(t, resp.Error)
+	var validators []result.Validator
+	assert.Nil(t, json.Unmarshal(resp.Result, &validators))
+
+	// With no votes the standby validators are the active ones.
+	cfg, err := config.Load("../../config", config.ModePrivNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(cfg.ProtocolConfiguration.StandbyValidators), len(validators))
+	for _, v := range validators {
+		assert.Contains(t, cfg.ProtocolConfiguration.StandbyValidators, hex.EncodeToString(v.PublicKey.Bytes()))
+		assert.Equal(t, util.Fixed8(0), v.Votes)
+		assert.True(t, v.Active)
+	}
+}
+
+func TestGetAssetState(t *testing.T) {
+	s, chain := newTestServer(t)
+	issueTX := getGenesisIssueTX(t, chain)
+	neo := issueTX.Outputs[0].AssetID
+
+	resp := doRPCCall(t, s, "getassetstate", fmt.Sprintf(`["%s"]`, neo))
+	assert.Nil(t, resp.Error)
+	var asset struct {
+		ID   util.Uint256 `json:"id"`
+		Type string       `json:"type"`
+		Name []struct {
+			Lang string `json:"lang"`
+			Name string `json:"name"`
+		} `json:"name"`
+		Precision uint8 `json:"precision"`
+	}
+	assert.Nil(t, json.Unmarshal(resp.Result, &asset))
+	assert.Equal(t, neo, asset.ID)
+	assert.Equal(t, "GoverningToken", asset.Type)
+	assert.Equal(t, uint8(0), asset.Precision)
+	assert.NotEmpty(t, asset.Name)
+
+	resp = doRPCCall(t, s, "getassetstate", fmt.Sprintf(`["%s"]`, util.Uint256{1, 2, 3}))
+	assert.NotNil(t, resp.Error)
+	assert.Equal(t, int64(-100), resp.Error.Code)
+}
+
+func TestGetRawTransaction(t *testing.T) {
+	s, chain := newTestServer(t)
+	issueTX := getGenesisIssueTX(t, chain)
+
+	resp := doRPCCall(t, s, "getrawtransaction", fmt.Sprintf(`["%s"]`, issueTX.Hash()))
+	assert.Nil(t, resp.Error)
+	var rawHex string
+	assert.Nil(t, json.Unmarshal(resp.Result, &rawHex))
+	buf := new(bytes.Buffer)
+	assert.Nil(t, issueTX.EncodeBinary(buf))
+	assert.Equal(t, hex.EncodeToString(buf.Bytes()), rawHex)
+
+	resp = doRPCCall(t, s, "getrawtransaction", fmt.Sprintf(`["%s", 1]`, issueTX.Hash()))
+	assert.Nil(t, resp.Error)
+	var tx struct {
+		TxID          util.Uint256 `json:"txid"`
+		Type          string       `json:"type"`
+		Size          int          `json:"size"`
+		Blockhash     util.Uint256 `json:"blockhash"`
+		Confirmations uint32       `json:"confirmations"`
+		Vout          []struct {
+			N     int          `json:"n"`
+			Asset util.Uint256 `json:"asset"`
+			Value util.Fixed8  `json:"value"`
+		} `json:"vout"`
+	}
+	assert.Nil(t, json.Unmarshal(resp.Result, &tx))
+	assert.Equal(t, issueTX.Hash(), tx.TxID)
+	assert.Equal(t, "IssueTransaction", tx.Type)
+	assert.Equal(t, buf.Len(), tx.Size)
+	assert.Equal(t, chain.GetHeaderHash(0), tx.Blockhash)
+	assert.Equal(t, uint32(1), tx.Confirmations)
+	assert.Equal(t, len(issueTX.Outputs), len(tx.Vout))
+	assert.Equal(t, issueTX.Outputs[0].Amount, tx.Vout[0].Value)
+
+	resp = doRPCCall(t, s, "getrawtransaction", fmt.Sprintf(`["%s"]`, util.Uint256{1, 2, 3}))
+	assert.NotNil(t, resp.Error)
+}
+
+func TestGetApplicationLog(t *testing.T) {
+	s, chain := newTestServer(t)
+	issueTX := getGenesisIssueTX(t, chain)
+
+	resp := doRPCCall(t, s, "getapplicationlog", fmt.Sprintf(`["%s"]`, issueTX.Hash()))
+	if assert.NotNil(t, resp.Error) {
+		assert.Equal(t, "Not an invocation transaction", resp.Error.Message)
+	}
+
+	resp = doRPCCall(t, s, "getapplicationlog", fmt.Sprintf(`["%s"]`, util.Uint256{1, 2, 3}))
+	if assert.NotNil(t, resp.Error) {
+		assert.Equal(t, "Unknown transaction", resp.Error.Message)
+	}
+
+	resp = doRPCCall(t, s, "getapplicationlog", `[]`)
+	if assert.NotNil(t, resp.Error) {
+		assert.Equal(t, int64(-32602), resp.Error.Code)
+	}
+}
+
+func TestGetTxOut(t *testing.T) {
+	s, chain := newTestServer(t)
+	issueTX := getGenesisIssueTX(t, chain)
+	out := issueTX.Outputs[0]
+
+	resp := doRPCCall(t, s, "gettxout", fmt.Sprintf(`["%s", 0]`, issueTX.Hash()))
+	assert.Nil(t, resp.Error)
+	expected := fmt.Sprintf(`{"n": 0, "asset": "0x%s", "value": "%s", "address": "%s"}`,
+		out.AssetID, out.Amount, crypto.AddressFromUint160(out.ScriptHash))
+	assert.JSONEq(t, expected, string(resp.Result))
+
+	// Outputs that don't exist are reported as null.
+	resp = doRPCCall(t, s, "gettxout", fmt.Sprintf(`["%s", %d]`, issueTX.Hash(), len(issueTX.Outputs)))
+	assert.Nil(t, resp.Error)
+	assert.Nil(t, resp.Result)
+}
+
+func TestGetBlockSysFee(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	resp := doRPCCall(t, s, "getblocksysfee", `[0]`)
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, `"0"`, string(resp.Result))
+
+	resp = doRPCCall(t, s, "getblocksysfee", `[1]`)
+	assert.NotNil(t, resp.Error)
+}
+
+func TestGetContractStateAndStorage(t *testing.T) {
+	s, _ := newTestServer(t)
+	hash := util.Uint160{1, 2, 3}
+	hashHex := hex.EncodeToString(hash.BytesReverse())
+
+	resp := doRPCCall(t, s, "getcontractstate", fmt.Sprintf(`["%s"]`, hashHex))
+	assert.NotNil(t, resp.Error)
+	assert.Equal(t, int64(-100), resp.Error.Code)
+
+	resp = doRPCCall(t, s, "getstorage", fmt.Sprintf(`["%s", "0102"]`, hashHex))
+	assert.Nil(t, resp.Error)
+	assert.Nil(t, resp.Result)
+}
+
+func TestGetStorageWithMockChain(t *testing.T) {
+	chain := coretest.NewChain()
+	server := NewServer(chain, 0, network.NewServer(network.ServerConfig{}, chain))
+	hash := util.Uint160{1, 2, 3}
+	hashHex := hex.EncodeToString(hash.BytesReverse())
+	chain.PutStorageItem(hash, []byte{1, 2}, &core.StorageItem{Value: []byte{42}})
+
+	resp := doRPCCall(t, &server, "getstorage", fmt.Sprintf(`["%s", "0102"]`, hashHex))
+	assert.Nil(t, resp.Error)
+	var value string
+	if err := json.Unmarshal(resp.Result, &value); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "2a", value)
+}
+
+func TestGetRawMempool(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	resp := doRPCCall(t, s, "getrawmempool", `[]`)
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, `[]`, string(resp.Result))
+}
+
+func TestInvokeScript(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	// PUSH2 PUSH3 ADD
+	resp := doRPCCall(t, s, "invokescript", `["525393"]`)
+	assert.Nil(t, resp.Error)
+	assert.JSONEq(t, `{
+		"state": "HALT",
+		"gas_consumed": "0.001",
+		"script": "525393",
+		"stack": [{"type": "Integer", "value": "5"}]
+	}`, string(resp.Result))
+
+	resp = doRPCCall(t, s, "invokescript", `["zz"]`)
+	assert.NotNil(t, resp.Error)
+}
+
+func TestInvokeFunction(t *testing.T) {
+	s, _ := newTestServer(t)
+	hash := util.Uint160{1, 2, 3}
+	hashHex := hex.EncodeToString(hash.BytesReverse())
+
+	// The contract doesn't exist, so the VM fails.
+	resp := doRPCCall(t, s, "invokefunction", fmt.Sprintf(`["%s", "name", [{"type": "String", "value": "a"}]]`, hashHex))
+	assert.Nil(t, resp.Error)
+	var res struct {
+		State  string `json:"state"`
+		Script string `json:"script"`
+	}
+	assert.Nil(t, json.Unmarshal(resp.Result, &res))
+	assert.Equal(t, "FAULT", res.State)
+	// PUSHBYTES1 "a" PUSH1 PACK PUSHBYTES4 "name" APPCALL <hash>
+	assert.Equal(t, "0161"+"51c1"+"046e616d65"+"67"+hex.EncodeToString(hash.Bytes()), res.Script)
+
+	resp = doRPCCall(t, s, "invokefunction", fmt.Sprintf(`["%s", "name", [{"type": "Unknown", "value": "a"}]]`, hashHex))
+	assert.NotNil(t, resp.Error)
+}
+
+func TestSendRawTransaction(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	resp := doRPCCall(t, s, "sendrawtransaction", `["zz"]`)
+	assert.NotNil(t, resp.Error)
+	assert.Equal(t, int64(-32602), resp.Error.Code)
+
+	// Spends an output that doesn't exist.
+	tx := &transaction.Transaction{
+		Type:       transaction.ContractType,
+		Data:       &transaction.ContractTX{},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{{PrevHash: util.Uint256{1, 2, 3}}},
+		Outputs:    []*transaction.Output{},
+		Scripts:    []*transaction.Witness{},
+	}
+	buf := new(bytes.Buffer)
+	assert.Nil(t, tx.EncodeBinary(buf))
+	resp = doRPCCall(t, s, "sendrawtransaction", fmt.Sprintf(`["%s"]`, hex.EncodeToString(buf.Bytes())))
+	assert.NotNil(t, resp.Error)
+	assert.Equal(t, int64(-504), resp.Error.Code)
+}
+
+func TestSubmitBlock(t *testing.T) {
+	s, chain := newTestServer(t)
+	genesis := getGenesisBlock(t, chain)
+
+	resp := doRPCCall(t, s, "submitblock", `["0102"]`)
+	assert.NotNil(t, resp.Error)
+
+	// The genesis block is already in the chain.
+	buf := new(bytes.Buffer)
+	assert.Nil(t, genesis.EncodeBinary(buf))
+	resp = doRPCCall(t, s, "submitblock", fmt.Sprintf(`["%s"]`, hex.EncodeToString(buf.Bytes())))
+	assert.NotNil(t, resp.Error)
+	assert.Equal(t, int64(-501), resp.Error.Code)
+}
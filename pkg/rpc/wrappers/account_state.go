@@ -0,0 +1,49 @@
+package wrappers
+
+import (
+	"sort"
+
+	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+type (
+	// AccountState wrapper used for the representation of
+	// core.AccountState on the RPC Server.
+	AccountState struct {
+		Version    uint8               `json:"version"`
+		ScriptHash util.Uint160        `json:"script_hash"`
+		IsFrozen   bool                `json:"frozen"`
+		Votes      []*crypto.PublicKey `json:"votes"`
+		Balances   []Balance           `json:"balances"`
+	}
+
+	// Balance represents the balance of a single asset of an account.
+	Balance struct {
+		Asset util.Uint256 `json:"asset"`
+		Value util.Fixed8  `json:"value"`
+	}
+)
+
+// NewAccountState creates a new AccountState wrapper.
+func NewAccountState(a *core.AccountState) AccountState {
+	balances := make([]Balance, 0, len(a.Balances))
+	for asset, value := range a.Balances {
+		balances = append(balances, Balance{
+			Asset: asset,
+			Value: value,
+		})
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Asset.String() < balances[j].Asset.String()
+	})
+
+	return AccountState{
+		Version:    a.Version,
+		ScriptHash: a.ScriptHash,
+		IsFrozen:   a.IsFrozen,
+		Votes:      a.Votes,
+		Balances:   balances,
+	}
+}
@@ -0,0 +1,56 @@
+package wrappers
+
+import (
+	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/smartcontract"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+type (
+	// ApplicationLog wrapper used for the representation of the
+	// core.AppExecResult on the RPC Server.
+	ApplicationLog struct {
+		TxHash     util.Uint256 `json:"txid"`
+		Executions []Execution  `json:"executions"`
+	}
+
+	// Execution represents an execution of the script of a transaction.
+	Execution struct {
+		Trigger       string                    `json:"trigger"`
+		ScriptHash    util.Uint160              `json:"contract"`
+		VMState       string                    `json:"vmstate"`
+		GasConsumed   util.Fixed8               `json:"gas_consumed"`
+		Stack         []smartcontract.Parameter `json:"stack"`
+		Notifications []Notification            `json:"notifications"`
+	}
+
+	// Notification represents an event sent by a contract during an
+	// execution.
+	Notification struct {
+		ScriptHash util.Uint160            `json:"contract"`
+		State      smartcontract.Parameter `json:"state"`
+	}
+)
+
+// NewApplicationLog creates a new ApplicationLog wrapper of the given result
+// of the execution of the script with the given hash.
+func NewApplicationLog(aer *core.AppExecResult, scriptHash util.Uint160) ApplicationLog {
+	notifications := make([]Notification, len(aer.Events))
+	for i, event := range aer.Events {
+		notifications[i] = Notification{
+			ScriptHash: event.ScriptHash,
+			State:      event.Item,
+		}
+	}
+	return ApplicationLog{
+		TxHash: aer.TxHash,
+		Executions: []Execution{{
+			Trigger:       aer.Trigger.String(),
+			ScriptHash:    scriptHash,
+			VMState:       aer.VMState,
+			GasConsumed:   aer.GasConsumed,
+			Stack:         aer.Stack,
+			Notifications: notifications,
+		}},
+	}
+}
@@ -0,0 +1,53 @@
+package wrappers
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+type (
+	// AssetState wrapper used for the representation of
+	// core.AssetState on the RPC Server.
+	AssetState struct {
+		ID         util.Uint256          `json:"id"`
+		AssetType  transaction.AssetType `json:"type"`
+		Name       interface{}           `json:"name"`
+		Amount     util.Fixed8           `json:"amount"`
+		Available  util.Fixed8           `json:"available"`
+		Precision  uint8                 `json:"precision"`
+		Owner      *crypto.PublicKey     `json:"owner"`
+		Admin      string                `json:"admin"`
+		Issuer     string                `json:"issuer"`
+		Expiration uint32                `json:"expiration"`
+		IsFrozen   bool                  `json:"frozen"`
+	}
+)
+
+// NewAssetState creates a new AssetState wrapper.
+func NewAssetState(a *core.AssetState) AssetState {
+	// The names of the system assets are JSON lists of localized names,
+	// those are returned as is.
+	var name interface{} = a.Name
+	if strings.HasPrefix(a.Name, "[") && json.Valid([]byte(a.Name)) {
+		name = json.RawMessage(a.Name)
+	}
+
+	return AssetState{
+		ID:         a.ID,
+		AssetType:  a.AssetType,
+		Name:       name,
+		Amount:     a.Amount,
+		Available:  a.Available,
+		Precision:  a.Precision,
+		Owner:      a.Owner,
+		Admin:      crypto.AddressFromUint160(a.Admin),
+		Issuer:     crypto.AddressFromUint160(a.Issuer),
+		Expiration: a.Expiration,
+		IsFrozen:   a.IsFrozen,
+	}
+}
@@ -0,0 +1,28 @@
+package wrappers
+
+import (
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+type (
+	// TransactionOutput wrapper used for the representation of
+	// transaction.Output on the RPC Server.
+	TransactionOutput struct {
+		N       int          `json:"n"`
+		Asset   util.Uint256 `json:"asset"`
+		Value   util.Fixed8  `json:"value"`
+		Address string       `json:"address"`
+	}
+)
+
+// NewTransactionOutput creates a new TransactionOutput wrapper.
+func NewTransactionOutput(out *transaction.Output, n int) TransactionOutput {
+	return TransactionOutput{
+		N:       n,
+		Asset:   out.AssetID,
+		Value:   out.Amount,
+		Address: crypto.AddressFromUint160(out.ScriptHash),
+	}
+}
@@ -0,0 +1,56 @@
+package wrappers
+
+import (
+	"bytes"
+
+	"github.com/CityOfZion/neo-go/pkg/core"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+type (
+	// TransactionOutputRaw wrapper used for the representation of
+	// transaction.Transaction on the RPC Server.
+	TransactionOutputRaw struct {
+		*transaction.Transaction
+		TxHash  util.Uint256        `json:"txid"`
+		Size    int                 `json:"size"`
+		Outputs []TransactionOutput `json:"vout"`
+		SysFee  util.Fixed8         `json:"sys_fee"`
+		NetFee  util.Fixed8         `json:"net_fee"`
+
+		// These are only set for the transactions included in a block.
+		Blockhash     *util.Uint256 `json:"blockhash,omitempty"`
+		Confirmations uint32        `json:"confirmations,omitempty"`
+		Timestamp     uint32        `json:"blocktime,omitempty"`
+	}
+)
+
+// NewTransactionOutputRaw creates a new TransactionOutputRaw wrapper. The
+// header is the one of the block including the transaction, or nil if the
+// transaction is not in the chain yet.
+func NewTransactionOutputRaw(tx *transaction.Transaction, header *core.Header, chain core.Blockchainer) TransactionOutputRaw {
+	buf := new(bytes.Buffer)
+	tx.EncodeBinary(buf)
+
+	outputs := make([]TransactionOutput, len(tx.Outputs))
+	for i, out := range tx.Outputs {
+		outputs[i] = NewTransactionOutput(out, i)
+	}
+
+	raw := TransactionOutputRaw{
+		Transaction: tx,
+		TxHash:      tx.Hash(),
+		Size:        buf.Len(),
+		Outputs:     outputs,
+		SysFee:      chain.SystemFee(tx),
+		NetFee:      chain.NetworkFee(tx),
+	}
+	if header != nil {
+		hash := header.Hash()
+		raw.Blockhash = &hash
+		raw.Confirmations = chain.BlockHeight() - header.Index + 1
+		raw.Timestamp = header.Timestamp
+	}
+	return raw
+}
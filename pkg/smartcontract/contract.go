@@ -32,3 +32,16 @@ func CreateMultiSigRedeemScript(m int, publicKeys crypto.PublicKeys) ([]byte, er
 
 	return buf.Bytes(), nil
 }
+
+// CreateSignatureRedeemScript will create a script that checks the signature
+// of the given public key, runnable by the VM.
+func CreateSignatureRedeemScript(key *crypto.PublicKey) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := vm.EmitBytes(buf, key.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := vm.EmitOpcode(buf, vm.Ochecksig); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
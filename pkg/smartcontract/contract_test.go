@@ -39,3 +39,23 @@ func TestCreateMultiSigRedeemScript(t *testing.T) {
 	b, _ = buf.ReadByte()
 	assert.Equal(t, vm.Ocheckmultisig, vm.Opcode(b))
 }
+
+func TestCreateSignatureRedeemScript(t *testing.T) {
+	key, _ := crypto.NewPublicKeyFromString("03b209fd4f53a7170ea4444e0cb0a6bb6a53c2bd016926989cf85f9b0fba17a70c")
+
+	out, err := CreateSignatureRedeemScript(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.NewBuffer(out)
+	b, err := util.ReadVarBytes(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, key.Bytes(), b)
+
+	op, _ := buf.ReadByte()
+	assert.Equal(t, vm.Ochecksig, vm.Opcode(op))
+	assert.Equal(t, 0, buf.Len())
+}
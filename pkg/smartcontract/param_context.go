@@ -1,23 +1,95 @@
 package smartcontract
 
-import "github.com/CityOfZion/neo-go/pkg/util"
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/vm"
+)
 
 // ParamType represent the Type of the contract parameter
 type ParamType int
 
 // A list of supported smart contract parameter types.
 const (
-	SignatureType ParamType = iota
-	BoolType
-	IntegerType
-	Hash160Type
-	Hash256Type
-	ByteArrayType
-	PublicKeyType
-	StringType
-	ArrayType
+	SignatureType        ParamType = 0x00
+	BoolType             ParamType = 0x01
+	IntegerType          ParamType = 0x02
+	Hash160Type          ParamType = 0x03
+	Hash256Type          ParamType = 0x04
+	ByteArrayType        ParamType = 0x05
+	PublicKeyType        ParamType = 0x06
+	StringType           ParamType = 0x07
+	ArrayType            ParamType = 0x10
+	InteropInterfaceType ParamType = 0xf0
+	VoidType             ParamType = 0xff
 )
 
+// String implements the stringer interface.
+func (pt ParamType) String() string {
+	switch pt {
+	case SignatureType:
+		return "Signature"
+	case BoolType:
+		return "Boolean"
+	case IntegerType:
+		return "Integer"
+	case Hash160Type:
+		return "Hash160"
+	case Hash256Type:
+		return "Hash256"
+	case ByteArrayType:
+		return "ByteArray"
+	case PublicKeyType:
+		return "PublicKey"
+	case StringType:
+		return "String"
+	case ArrayType:
+		return "Array"
+	case InteropInterfaceType:
+		return "InteropInterface"
+	case VoidType:
+		return "Void"
+	default:
+		return ""
+	}
+}
+
+// ParseParamType returns the ParamType with the given name.
+func ParseParamType(s string) (ParamType, error) {
+	for _, pt := range []ParamType{
+		SignatureType, BoolType, IntegerType, Hash160Type, Hash256Type, ByteArrayType,
+		PublicKeyType, StringType, ArrayType, InteropInterfaceType, VoidType,
+	} {
+		if pt.String() == s {
+			return pt, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown parameter type %q", s)
+}
+
+// MarshalJSON implements the json marshaller interface.
+func (pt ParamType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pt.String())
+}
+
+// UnmarshalJSON implements the json unmarshaller interface.
+func (pt *ParamType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	p, err := ParseParamType(s)
+	if err != nil {
+		return err
+	}
+	*pt = p
+	return nil
+}
+
 // Parameter represents a smart contract parameter.
 type Parameter struct {
 	// Type of the parameter
@@ -35,6 +107,27 @@ func NewParameter(t ParamType) Parameter {
 	}
 }
 
+// NewParameterFromStackItem converts an item of the VM stack to the
+// parameter representing it in the invocation results.
+func NewParameterFromStackItem(item vm.StackItem) Parameter {
+	switch val := item.Value().(type) {
+	case *big.Int:
+		return Parameter{Type: IntegerType, Value: val.String()}
+	case bool:
+		return Parameter{Type: BoolType, Value: val}
+	case []byte:
+		return Parameter{Type: ByteArrayType, Value: hex.EncodeToString(val)}
+	case []vm.StackItem:
+		items := make([]Parameter, len(val))
+		for i := range val {
+			items[i] = NewParameterFromStackItem(val[i])
+		}
+		return Parameter{Type: ArrayType, Value: items}
+	default:
+		return Parameter{Type: InteropInterfaceType}
+	}
+}
+
 // ContextItem represents a transaction context item.
 type ContextItem struct {
 	Script     util.Uint160
@@ -23,3 +23,12 @@ func TestArrayReverseLen2(t *testing.T) {
 		t.Fatalf("expected %v got %v", want, have)
 	}
 }
+
+func TestArrayReverseOddLen(t *testing.T) {
+	arr := []byte{0x01, 0x02, 0x03}
+	have := ArrayReverse(arr)
+	want := []byte{0x03, 0x02, 0x01}
+	if bytes.Compare(have, want) != 0 {
+		t.Fatalf("expected %v got %v", want, have)
+	}
+}
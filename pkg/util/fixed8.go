@@ -35,7 +35,7 @@ func (f Fixed8) String() string {
 		for i := len(str); i < 8; i++ {
 			buf.WriteRune('0')
 		}
-		buf.WriteString(str)
+		buf.WriteString(strings.TrimRight(str, "0"))
 	}
 	return buf.String()
 }
@@ -71,6 +71,11 @@ func Fixed8DecodeString(s string) (Fixed8, error) {
 	return Fixed8(ip*decimals + fp), nil
 }
 
+// MarshalJSON implements the json marshaller interface.
+func (f Fixed8) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
 // UnmarshalJSON implements the json unmarshaller interface.
 func (f *Fixed8) UnmarshalJSON(data []byte) error {
 	var s string
@@ -55,3 +55,13 @@ func TestFixed8UnmarshalJSON(t *testing.T) {
 	assert.Nil(t, json.Unmarshal(s, &u2))
 	assert.Equal(t, expected, u2)
 }
+
+func TestFixed8MarshalJSON(t *testing.T) {
+	s, err := json.Marshal(Fixed8(12345000000))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`"123.45"`), s)
+
+	var f Fixed8
+	assert.Nil(t, json.Unmarshal(s, &f))
+	assert.Equal(t, Fixed8(12345000000), f)
+}
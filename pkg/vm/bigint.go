@@ -0,0 +1,56 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// BigIntFromBytes decodes the given little-endian two's complement byte
+// array the way NEO integers are stored, an empty array being 0.
+func BigIntFromBytes(data []byte) *big.Int {
+	n := new(big.Int)
+	if len(data) == 0 {
+		return n
+	}
+	b := util.ArrayReverse(data)
+	if b[0]&0x80 == 0 {
+		return n.SetBytes(b)
+	}
+
+	// Negative number: invert the bits to get -n - 1.
+	for i := range b {
+		b[i] = ^b[i]
+	}
+	n.SetBytes(b)
+	n.Add(n, big.NewInt(1))
+	return n.Neg(n)
+}
+
+// BigIntToBytes encodes the given integer as a little-endian two's
+// complement byte array, the shortest one representing it, 0 being an
+// empty array.
+func BigIntToBytes(n *big.Int) []byte {
+	if n.Sign() == 0 {
+		return []byte{}
+	}
+	if n.Sign() > 0 {
+		b := n.Bytes()
+		if b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+		return util.ArrayReverse(b)
+	}
+
+	// Negative number: invert the bits of -n - 1.
+	m := new(big.Int).Neg(n)
+	m.Sub(m, big.NewInt(1))
+	b := m.Bytes()
+	for i := range b {
+		b[i] = ^b[i]
+	}
+	if len(b) == 0 || b[0]&0x80 == 0 {
+		b = append([]byte{0xff}, b...)
+	}
+	return util.ArrayReverse(b)
+}
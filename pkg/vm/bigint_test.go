@@ -0,0 +1,40 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var bigIntTestCases = []struct {
+	n    int64
+	data []byte
+}{
+	{0, []byte{}},
+	{1, []byte{0x01}},
+	{127, []byte{0x7f}},
+	{128, []byte{0x80, 0x00}},
+	{255, []byte{0xff, 0x00}},
+	{1000, []byte{0xe8, 0x03}},
+	{100000, []byte{0xa0, 0x86, 0x01}},
+	{-1, []byte{0xff}},
+	{-128, []byte{0x80}},
+	{-129, []byte{0x7f, 0xff}},
+	{-1000, []byte{0x18, 0xfc}},
+}
+
+func TestBigIntToBytes(t *testing.T) {
+	for _, tc := range bigIntTestCases {
+		assert.Equal(t, tc.data, BigIntToBytes(big.NewInt(tc.n)), "%d", tc.n)
+	}
+}
+
+func TestBigIntFromBytes(t *testing.T) {
+	for _, tc := range bigIntTestCases {
+		assert.Equal(t, tc.n, BigIntFromBytes(tc.data).Int64(), "%x", tc.data)
+	}
+	// Non-minimal encodings are accepted as well.
+	assert.Equal(t, int64(1), BigIntFromBytes([]byte{0x01, 0x00, 0x00}).Int64())
+	assert.Equal(t, int64(-1), BigIntFromBytes([]byte{0xff, 0xff}).Int64())
+}
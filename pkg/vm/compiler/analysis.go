@@ -219,3 +219,12 @@ func isNoRetSyscall(name string) bool {
 func isStringType(t types.Type) bool {
 	return t.String() == "string"
 }
+
+func isByteSliceType(t types.Type) bool {
+	slice, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	elem, ok := slice.Elem().Underlying().(*types.Basic)
+	return ok && elem.Kind() == types.Byte
+}
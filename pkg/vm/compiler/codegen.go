@@ -558,7 +558,17 @@ func (c *codegen) convertBuiltin(expr *ast.CallExpr) {
 			emitOpcode(c.prog, vm.Oarraysize)
 		}
 	case "append":
-		emitOpcode(c.prog, vm.Oappend)
+		arg := expr.Args[0]
+		typ := c.typeInfo.Types[arg].Type
+		if isByteSliceType(typ) {
+			emitOpcode(c.prog, vm.Ocat)
+		} else {
+			// APPEND modifies the array in place, a reference to it
+			// is kept as the result.
+			emitOpcode(c.prog, vm.Oover)
+			emitOpcode(c.prog, vm.Oswap)
+			emitOpcode(c.prog, vm.Oappend)
+		}
 	case "SHA256":
 		emitOpcode(c.prog, vm.Osha256)
 	case "SHA1":
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"math/big"
 
-	"github.com/CityOfZion/neo-go/pkg/util"
 	"github.com/CityOfZion/neo-go/pkg/vm"
 )
 
@@ -43,7 +42,7 @@ func emitInt(w *bytes.Buffer, i int64) error {
 	}
 
 	bInt := big.NewInt(i)
-	val := util.ArrayReverse(bInt.Bytes())
+	val := vm.BigIntToBytes(bInt)
 	return emitBytes(w, val)
 }
 
@@ -2,6 +2,8 @@ package vm
 
 import (
 	"encoding/binary"
+
+	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
 // Context represent the current execution context of the VM.
@@ -14,6 +16,9 @@ type Context struct {
 
 	// Breakpoints
 	breakPoints []int
+
+	// The hash of the program, computed on the first call to ScriptHash.
+	scriptHash *util.Uint160
 }
 
 // NewContext return a new Context object.
@@ -60,6 +65,7 @@ func (c *Context) Copy() *Context {
 		ip:          c.ip,
 		prog:        c.prog,
 		breakPoints: c.breakPoints,
+		scriptHash:  c.scriptHash,
 	}
 }
 
@@ -68,6 +74,15 @@ func (c *Context) Program() []byte {
 	return c.prog
 }
 
+// ScriptHash returns the hash of the loaded program.
+func (c *Context) ScriptHash() util.Uint160 {
+	if c.scriptHash == nil {
+		h, _ := util.Uint160FromScript(c.prog)
+		c.scriptHash = &h
+	}
+	return *c.scriptHash
+}
+
 // Value implements StackItem interface.
 func (c *Context) Value() interface{} {
 	return c
@@ -46,7 +46,7 @@ func EmitInt(w *bytes.Buffer, i int64) error {
 	}
 
 	bInt := big.NewInt(i)
-	val := util.ArrayReverse(bInt.Bytes())
+	val := BigIntToBytes(bInt)
 	return EmitBytes(w, val)
 }
 
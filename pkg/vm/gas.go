@@ -0,0 +1,77 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// gasPerPriceUnit is the GAS paid for each unit of the prices of the
+// instructions and interop functions, a unit is 0.001 GAS.
+const gasPerPriceUnit util.Fixed8 = 100000
+
+// SetGasLimit sets the maximum amount of GAS the execution can consume, the
+// VM faults once it's exceeded. Zero means no limit.
+func (v *VM) SetGasLimit(limit util.Fixed8) {
+	v.gasLimit = limit
+}
+
+// GasConsumed returns the amount of GAS consumed by the execution so far.
+func (v *VM) GasConsumed() util.Fixed8 {
+	return v.gasConsumed
+}
+
+// AddGas charges the given price to the execution and returns an error if
+// the gas limit is exceeded. Interop functions whose price depends on their
+// arguments use it to charge them.
+func (v *VM) AddGas(price int) error {
+	v.gasConsumed += util.Fixed8(price) * gasPerPriceUnit
+	if v.gasLimit > 0 && v.gasConsumed > v.gasLimit {
+		return fmt.Errorf("gas limit exceeded: %s > %s", v.gasConsumed, v.gasLimit)
+	}
+	return nil
+}
+
+// getPrice returns the price of the given instruction, SYSCALL is charged
+// the price of the interop function it calls instead.
+func (v *VM) getPrice(op Opcode) int {
+	if op <= Opush16 {
+		return 0
+	}
+	switch op {
+	case Onop, Osyscall:
+		return 0
+	case Oappcall, Otailcall, Osha1, Osha256:
+		return 10
+	case Ohash160, Ohash256:
+		return 20
+	case Ochecksig:
+		return 100
+	case Ocheckmultisig:
+		return 100 * v.getMultisigKeysCount()
+	default:
+		return 1
+	}
+}
+
+// getMultisigKeysCount returns the number of public keys CHECKMULTISIG is
+// about to check, or 1 if it can't be told from the evaluation stack.
+func (v *VM) getMultisigKeysCount() int {
+	if v.estack.Len() == 0 {
+		return 1
+	}
+	var n int
+	switch t := v.estack.Peek(0).value.Value().(type) {
+	case []StackItem:
+		n = len(t)
+	case *big.Int:
+		n = int(t.Int64())
+	case []byte:
+		n = int(v.estack.Peek(0).BigInt().Int64())
+	}
+	if n < 1 {
+		return 1
+	}
+	return n
+}
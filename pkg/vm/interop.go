@@ -7,6 +7,12 @@ import (
 // InteropFunc allows to hook into the VM.
 type InteropFunc func(vm *VM) error
 
+// interopFuncPrice is an InteropFunc along with the price of its calls.
+type interopFuncPrice struct {
+	f     InteropFunc
+	price int
+}
+
 // runtimeLog will handle the syscall "Neo.Runtime.Log" for printing and logging stuff.
 func runtimeLog(vm *VM) error {
 	item := vm.Estack().Pop()
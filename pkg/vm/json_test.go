@@ -0,0 +1,248 @@
+package vm
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The tests below are driven by the JSON test vectors stored under
+// testdata/neo-vm, which follow the format of the neo-vm ones. The stacks
+// are listed from their top.
+type (
+	vmUT struct {
+		Category string      `json:"category"`
+		Name     string      `json:"name"`
+		Tests    []vmUTEntry `json:"tests"`
+	}
+
+	vmUTEntry struct {
+		Name   string     `json:"name"`
+		Script vmUTScript `json:"script"`
+		Steps  []vmUTStep `json:"steps"`
+	}
+
+	vmUTStep struct {
+		Actions []string       `json:"actions"`
+		Result  vmUTStepResult `json:"result"`
+	}
+
+	vmUTStepResult struct {
+		State           string                 `json:"state"`
+		InvocationStack []vmUTExecutionContext `json:"invocationStack"`
+		ResultStack     []vmUTStackItem        `json:"resultStack"`
+	}
+
+	vmUTExecutionContext struct {
+		InstructionPointer int             `json:"instructionPointer"`
+		NextInstruction    string          `json:"nextInstruction"`
+		EvaluationStack    []vmUTStackItem `json:"evaluationStack"`
+		AltStack           []vmUTStackItem `json:"altStack"`
+	}
+
+	vmUTStackItem struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+
+	vmUTScript []byte
+)
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (s *vmUTScript) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+	if err != nil {
+		return err
+	}
+	*s = b
+	return nil
+}
+
+func TestNeoVMVectors(t *testing.T) {
+	err := filepath.Walk("testdata/neo-vm", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var ut vmUT
+		if err := json.Unmarshal(data, &ut); err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		for _, test := range ut.Tests {
+			t.Run(ut.Category+"/"+ut.Name+"/"+test.Name, func(t *testing.T) {
+				runVMUTEntry(t, test)
+			})
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+func runVMUTEntry(t *testing.T, test vmUTEntry) {
+	v := New(ModeMute)
+	v.Load(test.Script)
+	v.state = noneState
+
+	for i, step := range test.Steps {
+		for _, action := range step.Actions {
+			switch action {
+			case "Execute":
+				v.Run()
+			case "StepInto":
+				if v.state == noneState {
+					v.Step()
+				}
+			default:
+				t.Fatalf("unknown action %s", action)
+			}
+		}
+
+		state := v.State()
+		if v.state == noneState {
+			state = "BREAK"
+		}
+		if !assert.Equal(t, step.Result.State, state, "step %d", i) {
+			return
+		}
+		if step.Result.InvocationStack != nil {
+			checkVMUTContexts(t, v, step.Result.InvocationStack)
+		}
+		if step.Result.ResultStack != nil {
+			checkVMUTStack(t, step.Result.ResultStack, v.estack)
+		}
+	}
+}
+
+func checkVMUTContexts(t *testing.T, v *VM, expected []vmUTExecutionContext) {
+	if !assert.Equal(t, len(expected), v.istack.Len(), "invocation stack length") {
+		return
+	}
+	for i, ectx := range expected {
+		ctx := v.istack.Peek(i).value.Value().(*Context)
+		assert.Equal(t, ectx.InstructionPointer, ctx.IP(), "instruction pointer")
+
+		next := Oret
+		if ctx.IP() < len(ctx.prog) {
+			next = Opcode(ctx.prog[ctx.IP()])
+		}
+		assert.Equal(t, ectx.NextInstruction, strings.ToUpper(strings.TrimPrefix(next.String(), "O")))
+
+		// The evaluation and the alt stacks are shared by the contexts.
+		if i == 0 {
+			checkVMUTStack(t, ectx.EvaluationStack, v.estack)
+			checkVMUTStack(t, ectx.AltStack, v.astack)
+		}
+	}
+}
+
+func checkVMUTStack(t *testing.T, expected []vmUTStackItem, s *Stack) {
+	if !assert.Equal(t, len(expected), s.Len(), "%s stack length", s.name) {
+		return
+	}
+	for i, item := range expected {
+		checkVMUTStackItem(t, item, s.Peek(i).value)
+	}
+}
+
+func checkVMUTStackItem(t *testing.T, expected vmUTStackItem, actual StackItem) {
+	switch expected.Type {
+	case "Integer":
+		var n json.Number
+		if err := json.Unmarshal(expected.Value, &n); err != nil {
+			t.Fatal(err)
+		}
+		want, ok := new(big.Int).SetString(n.String(), 10)
+		if !ok {
+			t.Fatalf("invalid integer %s", n)
+		}
+		item, ok := actual.(*BigIntegerItem)
+		if assert.True(t, ok, "%s is not an integer", actual) {
+			assert.Equal(t, 0, want.Cmp(item.value), "%s != %s", want, item.value)
+		}
+	case "Boolean":
+		var want bool
+		if err := json.Unmarshal(expected.Value, &want); err != nil {
+			t.Fatal(err)
+		}
+		item, ok := actual.(*BoolItem)
+		if assert.True(t, ok, "%s is not a boolean", actual) {
+			assert.Equal(t, want, item.value)
+		}
+	case "ByteArray":
+		want := decodeVMUTBytes(t, expected.Value)
+		item, ok := actual.(*ByteArrayItem)
+		if assert.True(t, ok, "%s is not a byte array", actual) {
+			assert.Equal(t, want, item.value)
+		}
+	case "Array", "Struct":
+		var want []vmUTStackItem
+		if err := json.Unmarshal(expected.Value, &want); err != nil {
+			t.Fatal(err)
+		}
+		var items []StackItem
+		switch item := actual.(type) {
+		case *ArrayItem:
+			assert.Equal(t, "Array", expected.Type)
+			items = item.value
+		case *StructItem:
+			assert.Equal(t, "Struct", expected.Type)
+			items = item.value
+		default:
+			t.Errorf("%s is not an array", actual)
+			return
+		}
+		if assert.Equal(t, len(want), len(items)) {
+			for i := range want {
+				checkVMUTStackItem(t, want[i], items[i])
+			}
+		}
+	case "Map":
+		var want map[string]vmUTStackItem
+		if err := json.Unmarshal(expected.Value, &want); err != nil {
+			t.Fatal(err)
+		}
+		item, ok := actual.(*MapItem)
+		if !assert.True(t, ok, "%s is not a map", actual) || !assert.Equal(t, len(want), len(item.value)) {
+			return
+		}
+		for k, v := range want {
+			key, err := hex.DecodeString(strings.TrimPrefix(k, "0x"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, ok := item.Get(NewByteArrayItem(key))
+			if assert.True(t, ok, "missing key %s", k) {
+				checkVMUTStackItem(t, v, value)
+			}
+		}
+	default:
+		t.Fatalf("unknown stack item type %s", expected.Type)
+	}
+}
+
+func decodeVMUTBytes(t *testing.T, data json.RawMessage) []byte {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		t.Fatal(err)
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
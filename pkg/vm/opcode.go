@@ -121,9 +121,13 @@ const (
 	Osetitem   Opcode = 0xC4
 	Onewarray  Opcode = 0xC5 // Pops size from stack and creates a new array with that size, and pushes the array into the stack
 	Onewstruct Opcode = 0xC6
+	Onewmap    Opcode = 0xC7 // Creates a new empty map and pushes it into the stack.
 	Oappend    Opcode = 0xC8
 	Oreverse   Opcode = 0xC9
 	Oremove    Opcode = 0xCA
+	Ohaskey    Opcode = 0xCB // Returns 1 if the map has the key or the array has the index, 0 otherwise.
+	Okeys      Opcode = 0xCC // Pushes an array with the keys of the map.
+	Ovalues    Opcode = 0xCD // Pushes an array with the values of the map or the array.
 
 	// exceptions
 	Othrow      Opcode = 0xF0
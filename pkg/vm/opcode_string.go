@@ -4,7 +4,7 @@ package vm
 
 import "strconv"
 
-const _Opcode_name = "Opush0Opushbytes1Opushbytes75Opushdata1Opushdata2Opushdata4Opushm1Opush1Opush2Opush3Opush4Opush5Opush6Opush7Opush8Opush9Opush10Opush11Opush12Opush13Opush14Opush15Opush16OnopOjmpOjmpifOjmpifnotOcallOretOappcallOsyscallOtailcallOdupfromaltstackOtoaltstackOfromaltstackOxdropOxswapOxtuckOdepthOdropOdupOnipOoverOpickOrollOrotOswapOtuckOcatOsubstrOleftOrightOsizeOinvertOandOorOxorOequalOincOdecOsignOnegateOabsOnotOnzOaddOsubOmulOdivOmodOshlOshrOboolandOboolorOnumequalOnumnotequalOltOgtOlteOgteOminOmaxOwithinOsha1Osha256Ohash160Ohash256OchecksigOcheckmultisigOarraysizeOpackOunpackOpickitemOsetitemOnewarrayOnewstructOappendOreverseOremoveOthrowOthrowifnot"
+const _Opcode_name = "Opush0Opushbytes1Opushbytes75Opushdata1Opushdata2Opushdata4Opushm1Opush1Opush2Opush3Opush4Opush5Opush6Opush7Opush8Opush9Opush10Opush11Opush12Opush13Opush14Opush15Opush16OnopOjmpOjmpifOjmpifnotOcallOretOappcallOsyscallOtailcallOdupfromaltstackOtoaltstackOfromaltstackOxdropOxswapOxtuckOdepthOdropOdupOnipOoverOpickOrollOrotOswapOtuckOcatOsubstrOleftOrightOsizeOinvertOandOorOxorOequalOincOdecOsignOnegateOabsOnotOnzOaddOsubOmulOdivOmodOshlOshrOboolandOboolorOnumequalOnumnotequalOltOgtOlteOgteOminOmaxOwithinOsha1Osha256Ohash160Ohash256OchecksigOcheckmultisigOarraysizeOpackOunpackOpickitemOsetitemOnewarrayOnewstructOnewmapOappendOreverseOremoveOhaskeyOkeysOvaluesOthrowOthrowifnot"
 
 var _Opcode_map = map[Opcode]string{
 	0:   _Opcode_name[0:6],
@@ -103,11 +103,15 @@ var _Opcode_map = map[Opcode]string{
 	196: _Opcode_name[589:597],
 	197: _Opcode_name[597:606],
 	198: _Opcode_name[606:616],
-	200: _Opcode_name[616:623],
-	201: _Opcode_name[623:631],
-	202: _Opcode_name[631:638],
-	240: _Opcode_name[638:644],
-	241: _Opcode_name[644:655],
+	199: _Opcode_name[616:623],
+	200: _Opcode_name[623:630],
+	201: _Opcode_name[630:638],
+	202: _Opcode_name[638:645],
+	203: _Opcode_name[645:652],
+	204: _Opcode_name[652:657],
+	205: _Opcode_name[657:664],
+	240: _Opcode_name[664:670],
+	241: _Opcode_name[670:681],
 }
 
 func (i Opcode) String() string {
@@ -0,0 +1,175 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/CityOfZion/neo-go/pkg/util"
+)
+
+// Types of the serialized stack items, as in the reference implementation.
+const (
+	byteArrayItemType byte = 0x00
+	booleanItemType   byte = 0x01
+	integerItemType   byte = 0x02
+	arrayItemType     byte = 0x80
+	structItemType    byte = 0x81
+	mapItemType       byte = 0x82
+)
+
+// SerializeItem returns the binary representation of the given item as
+// stored by Neo.Runtime.Serialize. Interop items can't be serialized, nor
+// collections referenced more than once.
+func SerializeItem(item StackItem) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := serializeItem(buf, item, make(map[StackItem]bool)); err != nil {
+		return nil, err
+	}
+	if buf.Len() > MaxItemSize {
+		return nil, fmt.Errorf("serialized item is too big: %d", buf.Len())
+	}
+	return buf.Bytes(), nil
+}
+
+func serializeItem(w io.Writer, item StackItem, seen map[StackItem]bool) error {
+	switch t := item.(type) {
+	case *ByteArrayItem:
+		if _, err := w.Write([]byte{byteArrayItemType}); err != nil {
+			return err
+		}
+		return util.WriteVarBytes(w, t.value)
+	case *BoolItem:
+		return binary.Write(w, binary.LittleEndian, []byte{booleanItemType, boolByte(t.value)})
+	case *BigIntegerItem:
+		if _, err := w.Write([]byte{integerItemType}); err != nil {
+			return err
+		}
+		return util.WriteVarBytes(w, BigIntToBytes(t.value))
+	case *ArrayItem, *StructItem:
+		if seen[item] {
+			return errors.New("collection referenced more than once")
+		}
+		seen[item] = true
+		typ := arrayItemType
+		if _, ok := item.(*StructItem); ok {
+			typ = structItemType
+		}
+		items := item.Value().([]StackItem)
+		if _, err := w.Write([]byte{typ}); err != nil {
+			return err
+		}
+		if err := util.WriteVarUint(w, uint64(len(items))); err != nil {
+			return err
+		}
+		for _, i := range items {
+			if err := serializeItem(w, i, seen); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *MapItem:
+		if seen[item] {
+			return errors.New("collection referenced more than once")
+		}
+		seen[item] = true
+		if _, err := w.Write([]byte{mapItemType}); err != nil {
+			return err
+		}
+		if err := util.WriteVarUint(w, uint64(len(t.value))); err != nil {
+			return err
+		}
+		for _, e := range t.value {
+			if err := serializeItem(w, e.Key, seen); err != nil {
+				return err
+			}
+			if err := serializeItem(w, e.Value, seen); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s can't be serialized", item)
+	}
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// DeserializeItem returns the item serialized by SerializeItem.
+func DeserializeItem(b []byte) (StackItem, error) {
+	return deserializeItem(bytes.NewReader(b))
+}
+
+func deserializeItem(r io.Reader) (StackItem, error) {
+	var typ byte
+	if err := binary.Read(r, binary.LittleEndian, &typ); err != nil {
+		return nil, err
+	}
+	switch typ {
+	case byteArrayItemType:
+		b, err := util.ReadVarBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		return NewByteArrayItem(b), nil
+	case booleanItemType:
+		var b byte
+		if err := binary.Read(r, binary.LittleEndian, &b); err != nil {
+			return nil, err
+		}
+		return NewBoolItem(b != 0), nil
+	case integerItemType:
+		b, err := util.ReadVarBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		return &BigIntegerItem{value: BigIntFromBytes(b)}, nil
+	case arrayItemType, structItemType:
+		n := util.ReadVarUint(r)
+		if n > MaxArraySize {
+			return nil, fmt.Errorf("too many serialized items: %d", n)
+		}
+		items := make([]StackItem, n)
+		for i := range items {
+			item, err := deserializeItem(r)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		if typ == structItemType {
+			return NewStructItem(items), nil
+		}
+		return NewArrayItem(items), nil
+	case mapItemType:
+		n := util.ReadVarUint(r)
+		if n > MaxArraySize {
+			return nil, fmt.Errorf("too many serialized items: %d", n)
+		}
+		m := NewMapItem()
+		for i := uint64(0); i < n; i++ {
+			key, err := deserializeItem(r)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := itemBytes(key); !ok {
+				return nil, fmt.Errorf("invalid map key %s", key)
+			}
+			value, err := deserializeItem(r)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key, value)
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("invalid serialized item type %#x", typ)
+	}
+}
@@ -0,0 +1,71 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSerializeItem(t *testing.T) {
+	m := NewMapItem()
+	m.Set(NewByteArrayItem([]byte("key")), NewBoolItem(true))
+	m.Set(NewBigIntegerItem(1), NewStructItem([]StackItem{NewBigIntegerItem(-300)}))
+	item := NewArrayItem([]StackItem{
+		NewByteArrayItem([]byte{1, 2, 3}),
+		NewBoolItem(false),
+		&BigIntegerItem{value: big.NewInt(0)},
+		m,
+	})
+
+	b, err := SerializeItem(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []byte{0x80, 4, 0x00, 3, 1, 2, 3, 0x01, 0, 0x02, 0, 0x82, 2,
+		0x00, 3, 'k', 'e', 'y', 0x01, 1,
+		0x02, 1, 1, 0x81, 1, 0x02, 2, 0xd4, 0xfe}, b)
+
+	decoded, err := DeserializeItem(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serialized, err := SerializeItem(decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, b, serialized)
+	if arr, ok := decoded.(*ArrayItem); assert.True(t, ok) {
+		assert.Equal(t, 4, len(arr.value))
+		_, ok := arr.value[3].(*MapItem)
+		assert.True(t, ok)
+	}
+}
+
+func TestSerializeItemInvalid(t *testing.T) {
+	_, err := SerializeItem(NewInteropItem(42))
+	assert.NotNil(t, err)
+
+	// Collections can't be referenced twice.
+	inner := NewArrayItem([]StackItem{})
+	_, err = SerializeItem(NewArrayItem([]StackItem{inner, inner}))
+	assert.NotNil(t, err)
+	cyclic := NewArrayItem([]StackItem{})
+	cyclic.value = append(cyclic.value, cyclic)
+	_, err = SerializeItem(cyclic)
+	assert.NotNil(t, err)
+
+	_, err = SerializeItem(NewByteArrayItem(make([]byte, MaxItemSize)))
+	assert.NotNil(t, err)
+}
+
+func TestDeserializeItemInvalid(t *testing.T) {
+	for _, b := range [][]byte{
+		{},
+		{0x40},
+		{0x00, 3, 1},
+		{0x80, 1},
+		{0x82, 1, 0x80, 0, 0x01, 1},
+	} {
+		_, err := DeserializeItem(b)
+		assert.NotNil(t, err, "%x", b)
+	}
+}
@@ -1,9 +1,9 @@
 package vm
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
-
-	"github.com/CityOfZion/neo-go/pkg/util"
 )
 
 // Stack implementation for the neo-go virtual machine. The stack implements
@@ -41,6 +41,11 @@ func NewElement(v interface{}) *Element {
 	}
 }
 
+// Item returns the StackItem held by the element.
+func (e *Element) Item() StackItem {
+	return e.value
+}
+
 // Next returns the next element in the stack.
 func (e *Element) Next() *Element {
 	if elem := e.next; e.stack != nil && elem != &e.stack.top {
@@ -63,25 +68,45 @@ func (e *Element) BigInt() *big.Int {
 	switch t := e.value.(type) {
 	case *BigIntegerItem:
 		return t.value
+	case *BoolItem:
+		if t.value {
+			return big.NewInt(1)
+		}
+		return big.NewInt(0)
 	default:
 		b := t.Value().([]uint8)
-		return new(big.Int).SetBytes(util.ArrayReverse(b))
+		return BigIntFromBytes(b)
 	}
 }
 
 // Bool attempts to get the underlying value of the element as a boolean.
 // Will panic if the assertion failed which will be catched by the VM.
 func (e *Element) Bool() bool {
-	if v, ok := e.value.Value().(*big.Int); ok {
-		return v.Int64() == 1
+	switch t := e.value.Value().(type) {
+	case *big.Int:
+		return t.Sign() != 0
+	case []byte:
+		for _, b := range t {
+			if b != 0 {
+				return true
+			}
+		}
+		return false
+	case []StackItem, []MapElement:
+		return true
+	default:
+		return t.(bool)
 	}
-	return e.value.Value().(bool)
 }
 
 // Bytes attempts to get the underlying value of the element as a byte array.
 // Will panic if the assertion failed which will be catched by the VM.
 func (e *Element) Bytes() []byte {
-	return e.value.Value().([]byte)
+	b, ok := itemBytes(e.value)
+	if !ok {
+		panic(fmt.Sprintf("can't convert %s to a byte array", e.value))
+	}
+	return b
 }
 
 // Stack represents a Stack backed by a double linked list.
@@ -142,6 +167,9 @@ func (s *Stack) InsertBefore(e, mark *Element) *Element {
 
 // InsertAt will insert the given item (n) deep on the stack.
 func (s *Stack) InsertAt(e *Element, n int) *Element {
+	if n == s.len {
+		return s.insert(e, s.top.prev)
+	}
 	before := s.Peek(n)
 	if before == nil {
 		return nil
@@ -185,7 +213,8 @@ func (s *Stack) Back() *Element {
 
 // Peek returns the element (n) far in the stack beginning from
 // the top of the stack.
-// 	n = 0 => will return the element on top of the stack.
+//
+//	n = 0 => will return the element on top of the stack.
 func (s *Stack) Peek(n int) *Element {
 	i := 0
 	for e := s.Top(); e != nil; e = e.Next() {
@@ -219,8 +248,9 @@ func (s *Stack) Remove(e *Element) *Element {
 
 // Dup will duplicate and return the element at position n.
 // Dup is used for copying elements on to the top of its own stack.
-// 	s.Push(s.Peek(0)) // will result in unexpected behaviour.
-// 	s.Push(s.Dup(0)) // is the correct approach.
+//
+//	s.Push(s.Peek(0)) // will result in unexpected behaviour.
+//	s.Push(s.Dup(0)) // is the correct approach.
 func (s *Stack) Dup(n int) *Element {
 	e := s.Peek(n)
 	if e == nil {
@@ -234,11 +264,48 @@ func (s *Stack) Dup(n int) *Element {
 
 // Iter will iterate over all the elements int the stack, starting from the top
 // of the stack.
-// 	s.Iter(func(elem *Element) {
+//
+//	s.Iter(func(elem *Element) {
 //		// do something with the element.
-// 	})
+//	})
 func (s *Stack) Iter(f func(*Element)) {
 	for e := s.Top(); e != nil; e = e.Next() {
 		f(e)
 	}
 }
+
+// popSigElements pops the public keys or signatures used by CHECKMULTISIG
+// from the stack. They are either given as an array or as a number n
+// followed by n elements.
+func (s *Stack) popSigElements() ([][]byte, error) {
+	var elems [][]byte
+
+	item := s.Pop()
+	if item == nil {
+		return nil, errors.New("nothing on the stack")
+	}
+	switch t := item.value.(type) {
+	case *ArrayItem:
+		elems = make([][]byte, len(t.value))
+		for i, v := range t.value {
+			b, ok := v.Value().([]byte)
+			if !ok {
+				return nil, fmt.Errorf("item %d is not a byte array", i)
+			}
+			elems[i] = b
+		}
+	default:
+		n := int(item.BigInt().Int64())
+		if n > s.Len() {
+			return nil, fmt.Errorf("%d elements expected, stack has %d", n, s.Len())
+		}
+		elems = make([][]byte, n)
+		for i := 0; i < n; i++ {
+			elems[i] = s.Pop().Bytes()
+		}
+	}
+	if len(elems) == 0 {
+		return nil, errors.New("no elements")
+	}
+	return elems, nil
+}
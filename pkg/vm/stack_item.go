@@ -1,6 +1,7 @@
 package vm
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"math/big"
@@ -69,6 +70,25 @@ func (i *StructItem) String() string {
 	return "Struct"
 }
 
+// Clone returns a copy of the struct, the structs it holds are copied as
+// well while the other items are shared.
+func (i *StructItem) Clone() *StructItem {
+	items := make([]StackItem, len(i.value))
+	for j, item := range i.value {
+		items[j] = cloneIfStruct(item)
+	}
+	return &StructItem{items}
+}
+
+// cloneIfStruct returns a clone of the item if it's a struct, structs being
+// copied when stored in an array, a struct or a map.
+func cloneIfStruct(item StackItem) StackItem {
+	if s, ok := item.(*StructItem); ok {
+		return s.Clone()
+	}
+	return item
+}
+
 // BigIntegerItem represents a big integer on the stack.
 type BigIntegerItem struct {
 	value *big.Int
@@ -171,3 +191,159 @@ func (i *ArrayItem) MarshalJSON() ([]byte, error) {
 func (i *ArrayItem) String() string {
 	return "Array"
 }
+
+// InteropItem represents an object of the interop layer on the stack,
+// like a block or a transaction.
+type InteropItem struct {
+	value interface{}
+}
+
+// NewInteropItem returns a new InteropItem object.
+func NewInteropItem(value interface{}) *InteropItem {
+	return &InteropItem{
+		value: value,
+	}
+}
+
+// Value implements StackItem interface.
+func (i *InteropItem) Value() interface{} {
+	return i.value
+}
+
+func (i *InteropItem) String() string {
+	return "InteropItem"
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (i *InteropItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.value)
+}
+
+// MapElement is a key-value pair held by a MapItem.
+type MapElement struct {
+	Key   StackItem `json:"key"`
+	Value StackItem `json:"value"`
+}
+
+// MapItem represents a map on the stack, its elements are kept in the
+// order they were added in.
+type MapItem struct {
+	value []MapElement
+}
+
+// NewMapItem returns a new empty MapItem object.
+func NewMapItem() *MapItem {
+	return &MapItem{
+		value: []MapElement{},
+	}
+}
+
+// Value implements StackItem interface.
+func (i *MapItem) Value() interface{} {
+	return i.value
+}
+
+func (i *MapItem) String() string {
+	return "Map"
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (i *MapItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.value)
+}
+
+// Index returns the index of the element with the given key, -1 if there
+// is none.
+func (i *MapItem) Index(key StackItem) int {
+	for j := range i.value {
+		if equals(i.value[j].Key, key) {
+			return j
+		}
+	}
+	return -1
+}
+
+// Get returns the value stored under the given key.
+func (i *MapItem) Get(key StackItem) (StackItem, bool) {
+	if j := i.Index(key); j >= 0 {
+		return i.value[j].Value, true
+	}
+	return nil, false
+}
+
+// Set stores the value under the given key, replacing the previous one.
+func (i *MapItem) Set(key, value StackItem) {
+	if j := i.Index(key); j >= 0 {
+		i.value[j].Value = value
+		return
+	}
+	i.value = append(i.value, MapElement{Key: key, Value: value})
+}
+
+// Delete removes the element with the given key if there is one.
+func (i *MapItem) Delete(key StackItem) {
+	if j := i.Index(key); j >= 0 {
+		i.value = append(i.value[:j], i.value[j+1:]...)
+	}
+}
+
+// itemBytes returns the byte array representation of a primitive item, ok
+// being false for the collections and the interop items.
+func itemBytes(item StackItem) (b []byte, ok bool) {
+	switch t := item.(type) {
+	case *ByteArrayItem:
+		return t.value, true
+	case *BigIntegerItem:
+		return BigIntToBytes(t.value), true
+	case *BoolItem:
+		if t.value {
+			return []byte{1}, true
+		}
+		return []byte{}, true
+	default:
+		return nil, false
+	}
+}
+
+// equals reports whether the two items are equal as compared by the EQUAL
+// instruction: primitive items are equal if their byte arrays are, structs
+// are compared item by item and the other items by reference.
+func equals(a, b StackItem) bool {
+	if a == b {
+		return true
+	}
+	switch t := a.(type) {
+	case *BigIntegerItem:
+		if o, ok := b.(*BigIntegerItem); ok {
+			return t.value.Cmp(o.value) == 0
+		}
+	case *BoolItem:
+		if o, ok := b.(*BoolItem); ok {
+			return t.value == o.value
+		}
+	case *StructItem:
+		o, ok := b.(*StructItem)
+		if !ok || len(t.value) != len(o.value) {
+			return false
+		}
+		for i := range t.value {
+			if !equals(t.value[i], o.value[i]) {
+				return false
+			}
+		}
+		return true
+	case *InteropItem:
+		o, ok := b.(*InteropItem)
+		if !ok || t.value == nil || o.value == nil {
+			return false
+		}
+		return reflect.TypeOf(t.value).Comparable() && t.value == o.value
+	}
+
+	ab, ok := itemBytes(a)
+	if !ok {
+		return false
+	}
+	bb, ok := itemBytes(b)
+	return ok && bytes.Equal(ab, bb)
+}
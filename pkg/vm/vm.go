@@ -10,10 +10,20 @@ import (
 	"os"
 	"text/tabwriter"
 
+	"github.com/CityOfZion/neo-go/pkg/crypto"
 	"github.com/CityOfZion/neo-go/pkg/util"
 	"golang.org/x/crypto/ripemd160"
 )
 
+const (
+	// MaxItemSize is the maximum size of the byte arrays built by the VM.
+	MaxItemSize = 1024 * 1024
+
+	// MaxArraySize is the maximum number of items of the arrays and
+	// structs created by NEWARRAY and NEWSTRUCT.
+	MaxArraySize = 1024
+)
+
 // Mode configures behaviour of the VM.
 type Mode uint
 
@@ -27,23 +37,34 @@ type VM struct {
 	state State
 
 	// registered interop hooks.
-	interop map[string]InteropFunc
+	interop map[string]interopFuncPrice
 
 	// scripts loaded in memory.
 	scripts map[util.Uint160][]byte
 
+	// Used to look up the scripts that are not in the script table.
+	getScript func(util.Uint160) []byte
+
 	istack *Stack // invocation stack.
 	estack *Stack // execution stack.
 	astack *Stack // alt stack.
 
 	// Mute all output after execution.
 	mute bool
+
+	// Hash that is verified by the CHECKSIG and CHECKMULTISIG instructions.
+	checkhash []byte
+
+	// GAS consumed by the execution and the maximum it can consume, zero
+	// meaning no limit.
+	gasConsumed util.Fixed8
+	gasLimit    util.Fixed8
 }
 
 // New returns a new VM object ready to load .avm bytecode scripts.
 func New(mode Mode) *VM {
 	vm := &VM{
-		interop: make(map[string]InteropFunc),
+		interop: make(map[string]interopFuncPrice),
 		scripts: make(map[util.Uint160][]byte),
 		state:   haltState,
 		istack:  NewStack("invocation"),
@@ -55,15 +76,44 @@ func New(mode Mode) *VM {
 	}
 
 	// Register native interop hooks.
-	vm.RegisterInteropFunc("Neo.Runtime.Log", runtimeLog)
-	vm.RegisterInteropFunc("Neo.Runtime.Notify", runtimeNotify)
+	vm.RegisterInteropFunc("Neo.Runtime.Log", runtimeLog, 1)
+	vm.RegisterInteropFunc("Neo.Runtime.Notify", runtimeNotify, 1)
 
 	return vm
 }
 
-// RegisterInteropFunc will register the given InteropFunc to the VM.
-func (v *VM) RegisterInteropFunc(name string, f InteropFunc) {
-	v.interop[name] = f
+// RegisterInteropFunc will register the given InteropFunc to the VM, each
+// call to it costs the given price.
+func (v *VM) RegisterInteropFunc(name string, f InteropFunc, price int) {
+	v.interop[name] = interopFuncPrice{f, price}
+}
+
+// SetScriptGetter sets the function used to look up the scripts called by
+// APPCALL and TAILCALL that are not in the script table.
+func (v *VM) SetScriptGetter(f func(util.Uint160) []byte) {
+	v.getScript = f
+}
+
+// SetCheckedHash sets the hash the signatures are verified against by
+// the CHECKSIG and CHECKMULTISIG instructions.
+func (v *VM) SetCheckedHash(h []byte) {
+	v.checkhash = make([]byte, len(h))
+	copy(v.checkhash, h)
+}
+
+// HasFailed returns whether the VM is in the fault state.
+func (v *VM) HasFailed() bool {
+	return v.state == faultState
+}
+
+// HasHalted returns whether the VM is in the halt state.
+func (v *VM) HasHalted() bool {
+	return v.state == haltState
+}
+
+// State returns the string representation of the state of the VM.
+func (v *VM) State() string {
+	return v.state.String()
 }
 
 // Estack will return the evalutation stack so interop hooks can utilize this.
@@ -158,6 +208,12 @@ func (v *VM) Context() *Context {
 	return v.istack.Peek(0).value.Value().(*Context)
 }
 
+// GetContextScriptHash returns the script hash of the context n levels
+// down the invocation stack, 0 being the current context.
+func (v *VM) GetContextScriptHash(n int) util.Uint160 {
+	return v.istack.Peek(n).value.Value().(*Context).ScriptHash()
+}
+
 // PopResult is used to pop the first item of the evaluation stack. This allows
 // us to test compiler and vm in a bi-directional way.
 func (v *VM) PopResult() interface{} {
@@ -206,7 +262,9 @@ func (v *VM) Run() {
 			fmt.Printf("at breakpoint %d (%s)\n", i, op)
 			return
 		case faultState:
-			fmt.Println("FAULT")
+			if !v.mute {
+				fmt.Println("FAULT")
+			}
 			return
 		case noneState:
 			v.Step()
@@ -239,6 +297,13 @@ func (v *VM) execute(ctx *Context, op Opcode) {
 		}
 	}()
 
+	// The implicit RET at the end of the script is free.
+	if ctx == nil || ctx.ip < len(ctx.prog) {
+		if err := v.AddGas(v.getPrice(op)); err != nil {
+			panic(err)
+		}
+	}
+
 	if op >= Opushbytes1 && op <= Opushbytes75 {
 		b := ctx.readBytes(int(op))
 		v.estack.PushVal(b)
@@ -306,12 +371,24 @@ func (v *VM) execute(ctx *Context, op Opcode) {
 		}
 
 	case Otuck:
+		if v.estack.Len() < 2 {
+			panic("TUCK: not enough items")
+		}
+		v.estack.InsertAt(v.estack.Dup(0), 2)
+
+	case Oxtuck:
 		n := int(v.estack.Pop().BigInt().Int64())
-		if n <= 0 {
-			panic("OTUCK: invalid length")
+		if n <= 0 || n > v.estack.Len() {
+			panic("XTUCK: invalid length")
 		}
+		v.estack.InsertAt(v.estack.Dup(0), n)
 
-		v.estack.InsertAt(v.estack.Peek(0), n)
+	case Oxdrop:
+		n := int(v.estack.Pop().BigInt().Int64())
+		if n < 0 || n >= v.estack.Len() {
+			panic("XDROP: invalid length")
+		}
+		v.estack.RemoveAt(n)
 
 	case Orot:
 		c := v.estack.Pop()
@@ -336,6 +413,13 @@ func (v *VM) execute(ctx *Context, op Opcode) {
 		v.estack.Push(b)
 		v.estack.Push(a)
 
+	case Opick:
+		n := int(v.estack.Pop().BigInt().Int64())
+		if n < 0 || n >= v.estack.Len() {
+			panic("PICK: invalid length")
+		}
+		v.estack.Push(v.estack.Dup(n))
+
 	case Oroll:
 		n := int(v.estack.Pop().BigInt().Int64())
 		if n < 0 {
@@ -349,9 +433,66 @@ func (v *VM) execute(ctx *Context, op Opcode) {
 		v.estack.Pop()
 
 	case Oequal:
-		panic("TODO EQUAL")
+		b := v.estack.Pop()
+		a := v.estack.Pop()
+		v.estack.PushVal(equals(a.value, b.value))
+
+	// Splice operations.
+	case Ocat:
+		b := v.estack.Pop().Bytes()
+		a := v.estack.Pop().Bytes()
+		if len(a)+len(b) > MaxItemSize {
+			panic("CAT: item too big")
+		}
+		ab := make([]byte, 0, len(a)+len(b))
+		ab = append(ab, a...)
+		v.estack.PushVal(append(ab, b...))
+
+	case Osubstr:
+		count := int(v.estack.Pop().BigInt().Int64())
+		if count < 0 {
+			panic("SUBSTR: invalid count")
+		}
+		index := int(v.estack.Pop().BigInt().Int64())
+		if index < 0 {
+			panic("SUBSTR: invalid index")
+		}
+		s := v.estack.Pop().Bytes()
+		if index > len(s) {
+			panic("SUBSTR: index out of range")
+		}
+		if index+count > len(s) {
+			count = len(s) - index
+		}
+		v.estack.PushVal(copyBytes(s[index : index+count]))
+
+	case Oleft:
+		count := int(v.estack.Pop().BigInt().Int64())
+		if count < 0 {
+			panic("LEFT: invalid count")
+		}
+		s := v.estack.Pop().Bytes()
+		if count > len(s) {
+			count = len(s)
+		}
+		v.estack.PushVal(copyBytes(s[:count]))
+
+	case Oright:
+		count := int(v.estack.Pop().BigInt().Int64())
+		if count < 0 {
+			panic("RIGHT: invalid count")
+		}
+		s := v.estack.Pop().Bytes()
+		if count > len(s) {
+			panic("RIGHT: count out of range")
+		}
+		v.estack.PushVal(copyBytes(s[len(s)-count:]))
 
 	// Bit operations.
+	case Oinvert:
+		x := v.estack.Pop().BigInt()
+		v.estack.PushVal(new(big.Int).Not(x))
+
 	case Oand:
 		b := v.estack.Pop().BigInt()
 		a := v.estack.Pop().BigInt()
@@ -492,39 +633,120 @@ func (v *VM) execute(ctx *Context, op Opcode) {
 		v.estack.PushVal(!x)
 
 	case Onz:
-		panic("todo NZ")
-		// x := v.estack.Pop().BigInt()
+		x := v.estack.Pop().BigInt()
+		v.estack.PushVal(x.Sign() != 0)
 
 	// Object operations.
-	case Onewarray:
-		n := v.estack.Pop().BigInt().Int64()
-		items := make([]StackItem, n)
-		v.estack.PushVal(&ArrayItem{items})
+	case Onewarray, Onewstruct:
+		var items []StackItem
+		switch t := v.estack.Pop().value.(type) {
+		// Arrays and structs are converted into each other, the items
+		// being shared.
+		case *ArrayItem, *StructItem:
+			arr := t.Value().([]StackItem)
+			items = make([]StackItem, len(arr))
+			copy(items, arr)
+		default:
+			n := (&Element{value: t}).BigInt().Int64()
+			if n < 0 || n > MaxArraySize {
+				panic(fmt.Sprintf("%s: invalid length", op))
+			}
+			items = make([]StackItem, n)
+			for i := range items {
+				items[i] = NewBoolItem(false)
+			}
+		}
+		if op == Onewarray {
+			v.estack.PushVal(&ArrayItem{items})
+		} else {
+			v.estack.PushVal(&StructItem{items})
+		}
 
-	case Onewstruct:
-		n := v.estack.Pop().BigInt().Int64()
-		items := make([]StackItem, n)
-		v.estack.PushVal(&StructItem{items})
+	case Onewmap:
+		v.estack.PushVal(NewMapItem())
 
 	case Oappend:
 		itemElem := v.estack.Pop()
 		arrElem := v.estack.Pop()
 
+		val := cloneIfStruct(itemElem.value)
 		switch t := arrElem.value.(type) {
-		case *ArrayItem, *StructItem:
-			arr := t.Value().([]StackItem)
-			arr = append(arr, itemElem.value)
-			v.estack.PushVal(arr)
-		case *ByteArrayItem:
-			newVal := append(t.value, itemElem.value.Value().([]byte)...)
-			v.estack.PushVal(newVal)
+		case *ArrayItem:
+			t.value = append(t.value, val)
+		case *StructItem:
+			t.value = append(t.value, val)
 		default:
 			panic("APPEND: not of underlying type Array")
 		}
 
 	case Oreverse:
+		switch t := v.estack.Pop().value.(type) {
+		case *ArrayItem, *StructItem:
+			arr := t.Value().([]StackItem)
+			for i, j := 0, len(arr)-1; i < j; i, j = i+1, j-1 {
+				arr[i], arr[j] = arr[j], arr[i]
+			}
+		default:
+			panic(fmt.Sprintf("REVERSE: invalid item type %s", t))
+		}
 
 	case Oremove:
+		key := v.estack.Pop()
+		switch t := v.estack.Pop().value.(type) {
+		case *ArrayItem:
+			t.value = removeItem(t.value, int(key.BigInt().Int64()))
+		case *StructItem:
+			t.value = removeItem(t.value, int(key.BigInt().Int64()))
+		case *MapItem:
+			t.Delete(key.value)
+		default:
+			panic(fmt.Sprintf("REMOVE: invalid item type %s", t))
+		}
+
+	case Ohaskey:
+		key := v.estack.Pop()
+		switch t := v.estack.Pop().value.(type) {
+		case *ArrayItem, *StructItem:
+			index := int(key.BigInt().Int64())
+			if index < 0 {
+				panic("HASKEY: invalid index")
+			}
+			v.estack.PushVal(index < len(t.Value().([]StackItem)))
+		case *MapItem:
+			v.estack.PushVal(t.Index(key.value) >= 0)
+		default:
+			panic(fmt.Sprintf("HASKEY: invalid item type %s", t))
+		}
+
+	case Okeys:
+		t, ok := v.estack.Pop().value.(*MapItem)
+		if !ok {
+			panic("KEYS: not a map")
+		}
+		keys := make([]StackItem, len(t.value))
+		for i := range t.value {
+			keys[i] = t.value[i].Key
+		}
+		v.estack.PushVal(keys)
+
+	case Ovalues:
+		var values []StackItem
+		switch t := v.estack.Pop().value.(type) {
+		case *ArrayItem, *StructItem:
+			arr := t.Value().([]StackItem)
+			values = make([]StackItem, len(arr))
+			for i := range arr {
+				values[i] = cloneIfStruct(arr[i])
+			}
+		case *MapItem:
+			values = make([]StackItem, len(t.value))
+			for i := range t.value {
+				values[i] = cloneIfStruct(t.value[i].Value)
+			}
+		default:
+			panic(fmt.Sprintf("VALUES: invalid item type %s", t))
+		}
+		v.estack.PushVal(values)
 
 	case Opack:
 		n := int(v.estack.Pop().BigInt().Int64())
@@ -540,44 +762,63 @@ func (v *VM) execute(ctx *Context, op Opcode) {
 		v.estack.PushVal(items)
 
 	case Ounpack:
-		panic("TODO")
+		elem := v.estack.Pop()
+		arr, ok := elem.value.Value().([]StackItem)
+		if !ok {
+			panic("UNPACK: not an array")
+		}
+		for i := len(arr) - 1; i >= 0; i-- {
+			v.estack.PushVal(arr[i])
+		}
+		v.estack.PushVal(len(arr))
 
 	case Opickitem:
 		var (
-			key   = v.estack.Pop()
-			obj   = v.estack.Pop()
-			index = int(key.BigInt().Int64())
+			key = v.estack.Pop()
+			obj = v.estack.Pop()
 		)
 
 		switch t := obj.value.(type) {
 		// Struct and Array items have their underlying value as []StackItem.
 		case *ArrayItem, *StructItem:
 			arr := t.Value().([]StackItem)
+			index := int(key.BigInt().Int64())
 			if index < 0 || index >= len(arr) {
 				panic("PICKITEM: invalid index")
 			}
 			item := arr[index]
 			v.estack.PushVal(item)
+		case *MapItem:
+			item, ok := t.Get(key.value)
+			if !ok {
+				panic("PICKITEM: unknown key")
+			}
+			v.estack.PushVal(item)
 		default:
 			panic("PICKITEM: unknown type")
 		}
 
 	case Osetitem:
 		var (
-			item  = v.estack.Pop().value
-			key   = v.estack.Pop()
-			obj   = v.estack.Pop()
-			index = int(key.BigInt().Int64())
+			item = cloneIfStruct(v.estack.Pop().value)
+			key  = v.estack.Pop()
+			obj  = v.estack.Pop()
 		)
 
 		switch t := obj.value.(type) {
 		// Struct and Array items have their underlying value as []StackItem.
 		case *ArrayItem, *StructItem:
 			arr := t.Value().([]StackItem)
+			index := int(key.BigInt().Int64())
 			if index < 0 || index >= len(arr) {
 				panic("SETITEM: invalid index")
 			}
 			arr[index] = item
+		case *MapItem:
+			if _, ok := itemBytes(key.value); !ok {
+				panic(fmt.Sprintf("SETITEM: invalid key type %s", key.value))
+			}
+			t.Set(key.value, item)
 		default:
 			panic(fmt.Sprintf("SETITEM: invalid item type %s", t))
 		}
@@ -589,19 +830,15 @@ func (v *VM) execute(ctx *Context, op Opcode) {
 		switch t := elem.value.Value().(type) {
 		case []StackItem:
 			v.estack.PushVal(len(t))
-		case []uint8:
+		case []MapElement:
 			v.estack.PushVal(len(t))
 		default:
-			panic("ARRAYSIZE: item not of type []StackItem")
+			v.estack.PushVal(len(elem.Bytes()))
 		}
 
 	case Osize:
 		elem := v.estack.Pop()
-		arr, ok := elem.value.Value().([]uint8)
-		if !ok {
-			panic("SIZE: item not of type []uint8")
-		}
-		v.estack.PushVal(len(arr))
+		v.estack.PushVal(len(elem.Bytes()))
 
 	case Ojmp, Ojmpif, Ojmpifnot:
 		var (
@@ -633,12 +870,15 @@ func (v *VM) execute(ctx *Context, op Opcode) {
 		if !ok {
 			panic(fmt.Sprintf("interop hook (%s) not registered", api))
 		}
-		if err := ifunc(v); err != nil {
+		if err := v.AddGas(ifunc.price); err != nil {
+			panic(err)
+		}
+		if err := ifunc.f(v); err != nil {
 			panic(fmt.Sprintf("failed to invoke syscall: %s", err))
 		}
 
 	case Oappcall, Otailcall:
-		if len(v.scripts) == 0 {
+		if len(v.scripts) == 0 && v.getScript == nil {
 			panic("script table is empty")
 		}
 
@@ -648,6 +888,10 @@ func (v *VM) execute(ctx *Context, op Opcode) {
 		}
 
 		script, ok := v.scripts[hash]
+		if !ok && v.getScript != nil {
+			script = v.getScript(hash)
+			ok = script != nil
+		}
 		if !ok {
 			panic("could not find script")
 		}
@@ -696,10 +940,45 @@ func (v *VM) execute(ctx *Context, op Opcode) {
 		v.estack.PushVal(sha.Sum(nil))
 
 	case Ochecksig:
-		//pubkey := v.estack.Pop().Bytes()
-		//sig := v.estack.Pop().Bytes()
+		pubkey := v.estack.Pop().Bytes()
+		signature := v.estack.Pop().Bytes()
+		pkey := &crypto.PublicKey{}
+		if err := pkey.DecodeBytes(pubkey); err != nil {
+			v.estack.PushVal(false)
+			break
+		}
+		v.estack.PushVal(pkey.Verify(signature, v.checkhash))
 
 	case Ocheckmultisig:
+		pkeys, err := v.estack.popSigElements()
+		if err != nil {
+			panic(fmt.Sprintf("CHECKMULTISIG: wrong public keys: %s", err))
+		}
+		sigs, err := v.estack.popSigElements()
+		if err != nil {
+			panic(fmt.Sprintf("CHECKMULTISIG: wrong signatures: %s", err))
+		}
+		if len(pkeys) < len(sigs) {
+			panic("CHECKMULTISIG: more signatures than there are keys")
+		}
+
+		// Signatures have to be in the same order as the keys they were
+		// made with, so every key is only tried once.
+		sigok := true
+		for i, j := 0, 0; i < len(sigs); j++ {
+			if len(pkeys)-j < len(sigs)-i {
+				sigok = false
+				break
+			}
+			pkey := &crypto.PublicKey{}
+			if err := pkey.DecodeBytes(pkeys[j]); err != nil {
+				panic(fmt.Sprintf("CHECKMULTISIG: invalid public key: %s", err))
+			}
+			if pkey.Verify(sigs[i], v.checkhash) {
+				i++
+			}
+		}
+		v.estack.PushVal(sigok)
 
 	case Onop:
 		// unlucky ^^
@@ -717,6 +996,23 @@ func (v *VM) execute(ctx *Context, op Opcode) {
 	}
 }
 
+// removeItem removes the item at the given index of the array, panicking if
+// it is out of range.
+func removeItem(arr []StackItem, index int) []StackItem {
+	if index < 0 || index >= len(arr) {
+		panic("REMOVE: invalid index")
+	}
+	return append(arr[:index], arr[index+1:]...)
+}
+
+// copyBytes returns a copy of the given slice so that the items pushed
+// don't share their underlying array.
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
+
 func init() {
 	log.SetPrefix("NEO-GO-VM > ")
 	log.SetFlags(0)
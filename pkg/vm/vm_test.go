@@ -2,12 +2,14 @@ package vm
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"math/big"
 	"math/rand"
 	"testing"
 
 	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/CityOfZion/neo-go/pkg/wallet"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,7 +18,7 @@ func TestInteropHook(t *testing.T) {
 	v.RegisterInteropFunc("foo", func(evm *VM) error {
 		evm.Estack().PushVal(1)
 		return nil
-	})
+	}, 1)
 
 	buf := new(bytes.Buffer)
 	EmitSyscall(buf, "foo")
@@ -30,12 +32,41 @@ func TestInteropHook(t *testing.T) {
 func TestRegisterInterop(t *testing.T) {
 	v := New(ModeMute)
 	currRegistered := len(v.interop)
-	v.RegisterInteropFunc("foo", func(evm *VM) error { return nil })
+	v.RegisterInteropFunc("foo", func(evm *VM) error { return nil }, 1)
 	assert.Equal(t, currRegistered+1, len(v.interop))
 	_, ok := v.interop["foo"]
 	assert.Equal(t, true, ok)
 }
 
+func TestGasConsumed(t *testing.T) {
+	// PUSH2 and PUSH3 are free, ADD costs 1 and the final RET is implicit.
+	v := load([]byte{byte(Opush2), byte(Opush3), byte(Oadd)})
+	v.Run()
+	assert.True(t, v.HasHalted())
+	assert.Equal(t, util.Fixed8(100000), v.GasConsumed())
+}
+
+func TestInteropPrice(t *testing.T) {
+	v := load(nil)
+	v.RegisterInteropFunc("foo", func(evm *VM) error { return nil }, 200)
+
+	buf := new(bytes.Buffer)
+	EmitSyscall(buf, "foo")
+	v.Load(buf.Bytes())
+	v.Run()
+	assert.True(t, v.HasHalted())
+	assert.Equal(t, util.Fixed8(20000000), v.GasConsumed())
+}
+
+func TestGasLimit(t *testing.T) {
+	// An endless loop of NOP and a JMP back to it.
+	v := load([]byte{byte(Onop), byte(Ojmp), 0x00, 0x00})
+	v.SetGasLimit(util.Fixed8(100000000))
+	v.Run()
+	assert.True(t, v.HasFailed())
+	assert.True(t, v.GasConsumed() > util.Fixed8(100000000))
+}
+
 func TestPushBytes1to75(t *testing.T) {
 	buf := new(bytes.Buffer)
 	for i := 1; i <= 75; i++ {
@@ -231,6 +262,101 @@ func TestSimpleCall(t *testing.T) {
 	assert.Equal(t, result, int(vm.estack.Pop().BigInt().Int64()))
 }
 
+func TestCheckSig(t *testing.T) {
+	msg := []byte("NEO - An Open Network For Smart Economy")
+	digest := sha256.Sum256(msg)
+	priv, err := wallet.NewPrivateKeyFromHex("1dd37fba80fec4e6a6f13fd708d8dcb3b29def768017052f6c930fa1c5d90bbb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := priv.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := priv.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	EmitBytes(buf, sig)
+	EmitBytes(buf, pub)
+	EmitOpcode(buf, Ochecksig)
+
+	vm := load(buf.Bytes())
+	vm.SetCheckedHash(digest[:])
+	vm.Run()
+	assert.True(t, vm.HasHalted())
+	assert.Equal(t, true, vm.estack.Pop().Bool())
+
+	vm = load(buf.Bytes())
+	vm.SetCheckedHash([]byte{1, 2, 3})
+	vm.Run()
+	assert.True(t, vm.HasHalted())
+	assert.Equal(t, false, vm.estack.Pop().Bool())
+}
+
+func TestCheckMultiSig(t *testing.T) {
+	msg := []byte("NEO - An Open Network For Smart Economy")
+	digest := sha256.Sum256(msg)
+	privs := []string{
+		"1dd37fba80fec4e6a6f13fd708d8dcb3b29def768017052f6c930fa1c5d90bbb",
+		"ba6ae1c73ee8a8de4a4b4bbd5ee2bee0e18a8fd55cde9ec3b39e0fc6a4a4e1b5",
+		"73e7f7b35c2a5d3b8f7e1d5c9c4c8e8e6f1f2f3f4f5f6f7f8f9fafbfcfdfeff0",
+	}
+	var pubs, sigs [][]byte
+	for _, p := range privs {
+		priv, err := wallet.NewPrivateKeyFromHex(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := priv.PublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := priv.Sign(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubs = append(pubs, pub)
+		sigs = append(sigs, sig)
+	}
+
+	multisig := func(sigs, pubs [][]byte) *VM {
+		buf := new(bytes.Buffer)
+		for _, sig := range sigs {
+			EmitBytes(buf, sig)
+		}
+		EmitInt(buf, int64(len(sigs)))
+		for _, pub := range pubs {
+			EmitBytes(buf, pub)
+		}
+		EmitInt(buf, int64(len(pubs)))
+		EmitOpcode(buf, Ocheckmultisig)
+		vm := load(buf.Bytes())
+		vm.SetCheckedHash(digest[:])
+		vm.Run()
+		return vm
+	}
+
+	vm := multisig(sigs[:2], pubs)
+	assert.True(t, vm.HasHalted())
+	assert.Equal(t, true, vm.estack.Pop().Bool())
+
+	vm = multisig([][]byte{sigs[0], sigs[2]}, pubs)
+	assert.True(t, vm.HasHalted())
+	assert.Equal(t, true, vm.estack.Pop().Bool())
+
+	// Signatures in the wrong order.
+	vm = multisig([][]byte{sigs[1], sigs[0]}, pubs)
+	assert.True(t, vm.HasHalted())
+	assert.Equal(t, false, vm.estack.Pop().Bool())
+
+	// More signatures than keys.
+	vm = multisig(sigs, pubs[:2])
+	assert.True(t, vm.HasFailed())
+}
+
 func makeProgram(opcodes ...Opcode) []byte {
 	prog := make([]byte, len(opcodes)+1) // Oret
 	for i := 0; i < len(opcodes); i++ {